@@ -0,0 +1,181 @@
+package ctlog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrNoCheckpoint is returned by CheckpointStore.Load() if no checkpoint is saved for the log.
+var ErrNoCheckpoint = errors.New("no checkpoint")
+
+// CheckpointStore stores the index of the next entry to fetch for each log.
+// The logs are identified by their URI.
+//
+// A CheckpointStore must be safe for concurrent use.
+type CheckpointStore interface {
+
+	// Load returns the saved index of the log uri.
+	// Returns ErrNoCheckpoint if no index is saved.
+	Load(uri string) (int64, error)
+
+	// Save saves the index of the log uri.
+	Save(uri string, index int64) error
+}
+
+// MemoryCheckpoint is a CheckpointStore that keeps the checkpoints in memory.
+type MemoryCheckpoint struct {
+	indexes map[string]int64
+	m       *sync.Mutex
+}
+
+// NewMemoryCheckpoint creates an empty MemoryCheckpoint.
+func NewMemoryCheckpoint() *MemoryCheckpoint {
+
+	return &MemoryCheckpoint{indexes: make(map[string]int64), m: new(sync.Mutex)}
+}
+
+// Load returns the saved index of the log uri.
+func (c *MemoryCheckpoint) Load(uri string) (int64, error) {
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	i, ok := c.indexes[uri]
+	if !ok {
+		return 0, ErrNoCheckpoint
+	}
+
+	return i, nil
+}
+
+// Save saves the index of the log uri.
+func (c *MemoryCheckpoint) Save(uri string, index int64) error {
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.indexes[uri] = index
+
+	return nil
+}
+
+// FileCheckpoint is a CheckpointStore that keeps the checkpoints of every log in a JSON file (eg.: {"https://ct.googleapis.com/logs/us1/argon2024/": 1234}).
+//
+// The file is replaced atomically on every Save(), a crash never leaves a partially written file.
+type FileCheckpoint struct {
+	path string
+	m    *sync.Mutex
+}
+
+// NewFileCheckpoint creates a FileCheckpoint that uses the file at path.
+// The file is created on the first Save().
+func NewFileCheckpoint(path string) *FileCheckpoint {
+
+	return &FileCheckpoint{path: path, m: new(sync.Mutex)}
+}
+
+// read reads the checkpoints from the file.
+// Returns an empty map if the file does not exist.
+func (c *FileCheckpoint) read() (map[string]int64, error) {
+
+	indexes := make(map[string]int64)
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return indexes, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", c.path, err)
+	}
+
+	if err := json.Unmarshal(data, &indexes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", c.path, err)
+	}
+
+	return indexes, nil
+}
+
+// Load returns the saved index of the log uri.
+func (c *FileCheckpoint) Load(uri string) (int64, error) {
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	indexes, err := c.read()
+	if err != nil {
+		return 0, err
+	}
+
+	i, ok := indexes[uri]
+	if !ok {
+		return 0, ErrNoCheckpoint
+	}
+
+	return i, nil
+}
+
+// Save saves the index of the log uri.
+func (c *FileCheckpoint) Save(uri string, index int64) error {
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	indexes, err := c.read()
+	if err != nil {
+		return err
+	}
+
+	indexes[uri] = index
+
+	data, err := json.MarshalIndent(indexes, "", "\t")
+	if err != nil {
+		return fmt.Errorf("failed to marshal: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync %s: %w", tmp.Name(), err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", tmp.Name(), err)
+	}
+
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to rename %s: %w", tmp.Name(), err)
+	}
+
+	// Sync the directory to persist the rename
+	return syncDir(filepath.Dir(c.path))
+}
+
+// syncDir flushes the directory entries of dir to the disk.
+func syncDir(dir string) error {
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", dir, err)
+	}
+	defer d.Close()
+
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("failed to sync %s: %w", dir, err)
+	}
+
+	return nil
+}
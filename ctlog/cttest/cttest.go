@@ -0,0 +1,694 @@
+/*
+Package cttest implements an in-memory Certificate Transparency log for testing.
+
+The Log serves the RFC 6962 HTTP API (add-chain, add-pre-chain, get-sth, get-entries, get-proof-by-hash, get-sth-consistency and get-roots)
+with a real Merkle tree and signed tree heads. The entries are created from locally generated certificates and precertificates.
+
+The misbehaviour of a log can be simulated with SetTreeSize() (old tree head), SetSplitView() (inconsistent tree) and SetFailures() (rate limit).
+
+Example:
+
+	l, err := cttest.NewLog()
+	...
+	l.AddPrecert(l.Template("example.com", "www.example.com"))
+
+	srv := httptest.NewServer(l)
+	defer srv.Close()
+*/
+package cttest
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	gct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
+	ctx509 "github.com/google/certificate-transparency-go/x509"
+)
+
+var (
+	oidPoison  = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 3}
+	oidSCTList = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+)
+
+// Log is an in-memory CT log.
+// By default the tree head covers every added entry (see SetTreeSize()).
+//
+// A Log is safe for concurrent use.
+type Log struct {
+	key      *ecdsa.PrivateKey // Key of the log
+	caKey    *ecdsa.PrivateKey
+	ca       *x509.Certificate // The root that issues the certificates
+	roots    [][]byte          // Accepted roots of add-chain and add-pre-chain
+	certKey  *ecdsa.PrivateKey // Key of the issued certificates
+	leaves   [][]byte          // TLS encoded MerkleTreeLeafs
+	entries  []gct.LeafEntry
+	maxBatch int
+	size     int  // Size of the served tree, 0 serves every entry
+	fork     bool // Serve a split view
+	failures int  // Number of the next requests to fail with 429
+	m        *sync.Mutex
+}
+
+// NewLog creates an empty Log with new keys and a new root certificate.
+// The default max batch size of get-entries is 1000.
+func NewLog() (*Log, error) {
+
+	l := &Log{maxBatch: 1000, m: new(sync.Mutex)}
+
+	var err error
+
+	for _, k := range []**ecdsa.PrivateKey{&l.key, &l.caKey, &l.certKey} {
+		*k, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate key: %w", err)
+		}
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "cttest Root", Organization: []string{"cttest"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(10 * 365 * 24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, l.caKey.Public(), l.caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create root: %w", err)
+	}
+
+	l.ca, err = x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse root: %w", err)
+	}
+
+	l.roots = [][]byte{der}
+
+	return l, nil
+}
+
+// PublicKey returns the public key of the log.
+func (l *Log) PublicKey() crypto.PublicKey {
+
+	return l.key.Public()
+}
+
+// PubKey returns the base64 encoded DER public key of the log (the format of ctlog.Log.PubKey).
+func (l *Log) PubKey() string {
+
+	der, err := x509.MarshalPKIXPublicKey(l.key.Public())
+	if err != nil {
+		// P-256 key can always be marshaled
+		panic(err)
+	}
+
+	return base64.StdEncoding.EncodeToString(der)
+}
+
+// Root returns the root certificate that issues the certificates.
+func (l *Log) Root() *x509.Certificate {
+
+	return l.ca
+}
+
+// AddRoot adds root to the accepted roots of add-chain and add-pre-chain (eg.: the root of an other Log).
+func (l *Log) AddRoot(root *x509.Certificate) {
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.roots = append(l.roots, root.Raw)
+}
+
+// isRoot returns whether der is an accepted root.
+func (l *Log) isRoot(der []byte) bool {
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	for i := range l.roots {
+		if bytes.Equal(l.roots[i], der) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetMaxBatch sets the max number of entries returned by get-entries.
+func (l *Log) SetMaxBatch(n int) {
+
+	if n < 1 {
+		n = 1
+	}
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.maxBatch = n
+}
+
+// SetTreeSize sets the size of the served tree (eg.: to serve an older tree head), the entries above n are not served by get-entries.
+// 0 serves every entry (the default).
+func (l *Log) SetTreeSize(n int) {
+
+	if n < 0 {
+		n = 0
+	}
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.size = n
+}
+
+// SetSplitView sets whether the log presents a split view: the last leaf of the served tree is replaced with a different one,
+// so the served tree is inconsistent with the honest tree.
+func (l *Log) SetSplitView(fork bool) {
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.fork = fork
+}
+
+// SetFailures sets the number of the next requests to answer with 429 Too Many Requests.
+func (l *Log) SetFailures(n int) {
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.failures = n
+}
+
+// fail returns whether the request must fail and decrements the number of failures.
+func (l *Log) fail() bool {
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if l.failures <= 0 {
+		return false
+	}
+
+	l.failures--
+
+	return true
+}
+
+// Len returns the number of entries.
+func (l *Log) Len() int {
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	return len(l.leaves)
+}
+
+// Leaf returns the TLS encoded MerkleTreeLeaf at index (the leaf_input of get-entries).
+// Returns nil if index is out of range.
+func (l *Log) Leaf(index int) []byte {
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if index < 0 || index >= len(l.leaves) {
+		return nil
+	}
+
+	return l.leaves[index]
+}
+
+// Template returns a certificate template for names.
+// The first name is the common name, every name is added to the DNS SANs.
+// The template is valid for 90 days and has a random serial number.
+func (l *Log) Template(names ...string) *x509.Certificate {
+
+	serial, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		NotBefore:    time.Now().Truncate(time.Second),
+		NotAfter:     time.Now().Truncate(time.Second).Add(90 * 24 * time.Hour),
+		DNSNames:     names,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	if len(names) > 0 {
+		tmpl.Subject.CommonName = names[0]
+	}
+
+	return tmpl
+}
+
+// Sign issues a certificate from tmpl with the root of the log and returns the DER encoded certificate.
+// The certificate is not modified (eg.: to issue a certificate with SCTs from other logs).
+func (l *Log) Sign(tmpl *x509.Certificate) ([]byte, error) {
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, l.ca, l.certKey.Public(), l.caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+
+	return der, nil
+}
+
+// issue creates the certificate from tmpl with ext appended to the extensions.
+func (l *Log) issue(tmpl *x509.Certificate, ext pkix.Extension) ([]byte, error) {
+
+	t := *tmpl
+	t.ExtraExtensions = append(append([]pkix.Extension{}, tmpl.ExtraExtensions...), ext)
+
+	return l.Sign(&t)
+}
+
+// add appends the leaf to the log and returns its index.
+func (l *Log) add(leaf *gct.MerkleTreeLeaf, extra []byte) (int64, error) {
+
+	input, err := tls.Marshal(*leaf)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal leaf: %w", err)
+	}
+
+	return l.AddEntry(gct.LeafEntry{LeafInput: input, ExtraData: extra}), nil
+}
+
+// AddEntry appends a raw entry to the log and returns its index.
+// The entry is not validated (eg.: to test the handling of malformed entries).
+func (l *Log) AddEntry(e gct.LeafEntry) int64 {
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.leaves = append(l.leaves, e.LeafInput)
+	l.entries = append(l.entries, e)
+
+	return int64(len(l.leaves) - 1)
+}
+
+// LogID returns the SHA-256 hash of the public key of the log.
+func (l *Log) LogID() [sha256.Size]byte {
+
+	der, err := x509.MarshalPKIXPublicKey(l.key.Public())
+	if err != nil {
+		// P-256 key can always be marshaled
+		panic(err)
+	}
+
+	return sha256.Sum256(der)
+}
+
+// SCT returns the SCT signed by the log for the leaf.
+func (l *Log) SCT(leaf *gct.MerkleTreeLeaf) (*gct.SignedCertificateTimestamp, error) {
+
+	sct := &gct.SignedCertificateTimestamp{SCTVersion: gct.V1, LogID: gct.LogID{KeyID: l.LogID()}, Timestamp: leaf.TimestampedEntry.Timestamp}
+
+	input, err := gct.SerializeSCTSignatureInput(*sct, gct.LogEntry{Leaf: *leaf})
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize SCT: %w", err)
+	}
+
+	sig, err := tls.CreateSignature(*l.key, tls.SHA256, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign SCT: %w", err)
+	}
+
+	sct.Signature = gct.DigitallySigned(sig)
+
+	return sct, nil
+}
+
+// precert issues a precertificate from tmpl and returns the DER encoded precertificate and its MerkleTreeLeaf.
+func (l *Log) precert(tmpl *x509.Certificate) ([]byte, *gct.MerkleTreeLeaf, error) {
+
+	der, err := l.issue(tmpl, pkix.Extension{Id: oidPoison, Critical: true, Value: asn1.NullBytes})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse precertificate: %w", err)
+	}
+
+	tbs, err := ctx509.RemoveCTPoison(cert.RawTBSCertificate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to remove poison: %w", err)
+	}
+
+	leaf := &gct.MerkleTreeLeaf{
+		Version:  gct.V1,
+		LeafType: gct.TimestampedEntryLeafType,
+		TimestampedEntry: &gct.TimestampedEntry{
+			Timestamp:    uint64(time.Now().UnixMilli()),
+			EntryType:    gct.PrecertLogEntryType,
+			PrecertEntry: &gct.PreCert{IssuerKeyHash: sha256.Sum256(l.ca.RawSubjectPublicKeyInfo), TBSCertificate: tbs},
+		},
+	}
+
+	return der, leaf, nil
+}
+
+// IssuePrecert issues a precertificate from tmpl and returns the DER encoded precertificate.
+// The precertificate is not added to the log.
+func (l *Log) IssuePrecert(tmpl *x509.Certificate) ([]byte, error) {
+
+	der, _, err := l.precert(tmpl)
+
+	return der, err
+}
+
+// Issue issues a final certificate from tmpl with an SCT of the log embedded and returns the DER encoded certificate.
+// The certificate is not added to the log.
+func (l *Log) Issue(tmpl *x509.Certificate) ([]byte, error) {
+
+	_, leaf, err := l.precert(tmpl)
+	if err != nil {
+		return nil, err
+	}
+
+	sct, err := l.SCT(leaf)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := tls.Marshal(*sct)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal SCT: %w", err)
+	}
+
+	list, err := tls.Marshal(ctx509.SignedCertificateTimestampList{SCTList: []ctx509.SerializedSCT{{Val: raw}}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal SCT list: %w", err)
+	}
+
+	value, err := asn1.Marshal(list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal SCT list: %w", err)
+	}
+
+	return l.issue(tmpl, pkix.Extension{Id: oidSCTList, Value: value})
+}
+
+// AddCert issues a final certificate from tmpl, appends it to the log and returns its index and the DER encoded certificate.
+//
+// The certificate contains an embedded SCT of the log (see Issue()), so the certificate issued from the same template
+// with AddPrecert() has the same TBSCertificate without the poison and the SCT list.
+func (l *Log) AddCert(tmpl *x509.Certificate) (int64, []byte, error) {
+
+	der, err := l.Issue(tmpl)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	leaf := gct.CreateX509MerkleTreeLeaf(gct.ASN1Cert{Data: der}, uint64(time.Now().UnixMilli()))
+
+	extra, err := tls.Marshal(gct.CertificateChain{Entries: []gct.ASN1Cert{{Data: l.ca.Raw}}})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to marshal chain: %w", err)
+	}
+
+	i, err := l.add(leaf, extra)
+
+	return i, der, err
+}
+
+// AddPrecert issues a precertificate from tmpl, appends it to the log and returns its index and the DER encoded precertificate.
+func (l *Log) AddPrecert(tmpl *x509.Certificate) (int64, []byte, error) {
+
+	der, leaf, err := l.precert(tmpl)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	extra, err := tls.Marshal(gct.PrecertChainEntry{PreCertificate: gct.ASN1Cert{Data: der}, CertificateChain: []gct.ASN1Cert{{Data: l.ca.Raw}}})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to marshal chain: %w", err)
+	}
+
+	i, err := l.add(leaf, extra)
+
+	return i, der, err
+}
+
+// forkLeaf returns a different leaf: the timestamp of the MerkleTreeLeaf is changed,
+// or a byte is appended if leaf is not a valid MerkleTreeLeaf.
+func forkLeaf(leaf []byte) []byte {
+
+	var v gct.MerkleTreeLeaf
+
+	if rest, err := tls.Unmarshal(leaf, &v); err == nil && len(rest) == 0 && v.TimestampedEntry != nil {
+
+		v.TimestampedEntry.Timestamp++
+
+		if r, err := tls.Marshal(v); err == nil {
+			return r
+		}
+	}
+
+	return append(append([]byte{}, leaf...), 0)
+}
+
+// snapshot returns the leaves, the entries, the size of the served tree and the max batch size.
+// In case of split view, the last leaf of the served tree is forked.
+func (l *Log) snapshot() ([][]byte, []gct.LeafEntry, int, int) {
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	leaves, entries, size := l.leaves, l.entries, len(l.leaves)
+
+	if l.size > 0 && l.size < size {
+		size = l.size
+	}
+
+	if l.fork && size > 0 {
+
+		leaves = append([][]byte{}, leaves...)
+		leaves[size-1] = forkLeaf(leaves[size-1])
+
+		entries = append([]gct.LeafEntry{}, entries...)
+		entries[size-1].LeafInput = leaves[size-1]
+	}
+
+	return leaves, entries, size, l.maxBatch
+}
+
+// STH returns the signed tree head of the served tree.
+func (l *Log) STH() (*gct.SignedTreeHead, error) {
+
+	leaves, _, size, _ := l.snapshot()
+
+	sth := &gct.SignedTreeHead{Version: gct.V1, TreeSize: uint64(size), Timestamp: uint64(time.Now().UnixMilli())}
+	copy(sth.SHA256RootHash[:], rootHash(leaves[:size]))
+
+	input, err := gct.SerializeSTHSignatureInput(*sth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize tree head: %w", err)
+	}
+
+	sig, err := tls.CreateSignature(*l.key, tls.SHA256, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign tree head: %w", err)
+	}
+
+	sth.TreeHeadSignature = gct.DigitallySigned(sig)
+
+	return sth, nil
+}
+
+// addChain handles the add-chain and add-pre-chain requests.
+// The submitted entries are merged immediately, the chain must end with an accepted root.
+func (l *Log) addChain(w http.ResponseWriter, r *http.Request, typ gct.LogEntryType) {
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req gct.AddChainRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Chain) < 2 || !l.isRoot(req.Chain[len(req.Chain)-1]) {
+		http.Error(w, "chain must end with an accepted root", http.StatusBadRequest)
+		return
+	}
+
+	chain := make([]gct.ASN1Cert, len(req.Chain))
+	for i := range req.Chain {
+		chain[i] = gct.ASN1Cert{Data: req.Chain[i]}
+	}
+
+	leaf, err := gct.MerkleTreeLeafFromRawChain(chain, typ, uint64(time.Now().UnixMilli()))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var extra []byte
+
+	if typ == gct.PrecertLogEntryType {
+		extra, err = tls.Marshal(gct.PrecertChainEntry{PreCertificate: chain[0], CertificateChain: chain[1:]})
+	} else {
+		extra, err = tls.Marshal(gct.CertificateChain{Entries: chain[1:]})
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sct, err := l.SCT(leaf)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sig, err := tls.Marshal(sct.Signature)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := l.add(leaf, extra); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, gct.AddChainResponse{SCTVersion: sct.SCTVersion, ID: sct.LogID.KeyID[:], Timestamp: sct.Timestamp, Signature: sig})
+}
+
+// writeJSON writes v as the JSON response.
+func writeJSON(w http.ResponseWriter, v any) {
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// ServeHTTP implements the http.Handler.
+func (l *Log) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if l.fail() {
+		http.Error(w, "too many requests", http.StatusTooManyRequests)
+		return
+	}
+
+	// The proofs are served for every entry (eg.: the tree head is from a lagging frontend), the entries up to the tree size
+	leaves, entries, size, maxBatch := l.snapshot()
+	q := r.URL.Query()
+
+	switch r.URL.Path {
+	case "/ct/v1/get-sth":
+
+		sth, err := l.STH()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		sig, err := tls.Marshal(sth.TreeHeadSignature)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, gct.GetSTHResponse{TreeSize: sth.TreeSize, Timestamp: sth.Timestamp, SHA256RootHash: sth.SHA256RootHash[:], TreeHeadSignature: sig})
+
+	case "/ct/v1/get-entries":
+
+		start, err1 := strconv.Atoi(q.Get("start"))
+		end, err2 := strconv.Atoi(q.Get("end"))
+
+		if err1 != nil || err2 != nil || start < 0 || start > end || start >= size {
+			http.Error(w, "invalid range", http.StatusBadRequest)
+			return
+		}
+
+		if end >= size {
+			end = size - 1
+		}
+
+		if end-start+1 > maxBatch {
+			end = start + maxBatch - 1
+		}
+
+		writeJSON(w, gct.GetEntriesResponse{Entries: entries[start : end+1]})
+
+	case "/ct/v1/get-proof-by-hash":
+
+		hash, err1 := base64.StdEncoding.DecodeString(q.Get("hash"))
+		size, err2 := strconv.Atoi(q.Get("tree_size"))
+
+		if err1 != nil || err2 != nil || size < 1 || size > len(leaves) {
+			http.Error(w, "invalid parameters", http.StatusBadRequest)
+			return
+		}
+
+		for i := 0; i < size; i++ {
+			if bytes.Equal(leafHash(leaves[i]), hash) {
+				writeJSON(w, gct.GetProofByHashResponse{LeafIndex: int64(i), AuditPath: inclusionProof(i, leaves[:size])})
+				return
+			}
+		}
+
+		http.Error(w, "hash not found", http.StatusNotFound)
+
+	case "/ct/v1/get-sth-consistency":
+
+		first, err1 := strconv.Atoi(q.Get("first"))
+		second, err2 := strconv.Atoi(q.Get("second"))
+
+		if err1 != nil || err2 != nil || first < 1 || first > second || second > len(leaves) {
+			http.Error(w, "invalid tree sizes", http.StatusBadRequest)
+			return
+		}
+
+		writeJSON(w, gct.GetSTHConsistencyResponse{Consistency: consistencyProof(first, leaves[:second])})
+
+	case "/ct/v1/add-chain":
+
+		l.addChain(w, r, gct.X509LogEntryType)
+
+	case "/ct/v1/add-pre-chain":
+
+		l.addChain(w, r, gct.PrecertLogEntryType)
+
+	case "/ct/v1/get-roots":
+
+		l.m.Lock()
+
+		var resp gct.GetRootsResponse
+		for i := range l.roots {
+			resp.Certificates = append(resp.Certificates, base64.StdEncoding.EncodeToString(l.roots[i]))
+		}
+
+		l.m.Unlock()
+
+		writeJSON(w, resp)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
@@ -0,0 +1,214 @@
+package cttest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elmasy-com/elnet/ctlog"
+	gct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/client"
+	"github.com/google/certificate-transparency-go/jsonclient"
+	"github.com/google/certificate-transparency-go/tls"
+	ctx509 "github.com/google/certificate-transparency-go/x509"
+)
+
+func TestLog(t *testing.T) {
+
+	l, err := NewLog()
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	l.SetMaxBatch(3)
+
+	for i := 0; i < 5; i++ {
+
+		tmpl := l.Template("example.com", "www.example.com")
+
+		if _, _, err := l.AddPrecert(tmpl); err != nil {
+			t.Fatalf("FAIL: %s\n", err)
+		}
+
+		if _, _, err := l.AddCert(tmpl); err != nil {
+			t.Fatalf("FAIL: %s\n", err)
+		}
+	}
+
+	// Verify the embedded SCT
+	der, err := l.Issue(l.Template("example.net"))
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	cert, err := ctx509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	root, _ := ctx509.ParseCertificate(l.Root().Raw)
+
+	if len(cert.SCTList.SCTList) != 1 {
+		t.Fatalf("FAIL: want 1 embedded SCT, got %d\n", len(cert.SCTList.SCTList))
+	}
+
+	var sct gct.SignedCertificateTimestamp
+	if _, err := tls.Unmarshal(cert.SCTList.SCTList[0].Val, &sct); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	leaf, err := gct.MerkleTreeLeafForEmbeddedSCT([]*ctx509.Certificate{cert, root}, sct.Timestamp)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	v, err := gct.NewSignatureVerifier(l.PublicKey())
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if err := v.VerifySCTSignature(sct, gct.LogEntry{Leaf: *leaf}); err != nil {
+		t.Fatalf("FAIL: invalid embedded SCT: %s\n", err)
+	}
+
+	srv := httptest.NewServer(l)
+	defer srv.Close()
+
+	c, err := client.New(srv.URL, http.DefaultClient, jsonclient.Options{})
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	ctx := context.Background()
+
+	resp, err := c.GetRawEntries(ctx, 2, 9)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if len(resp.Entries) != 3 {
+		t.Fatalf("FAIL: max batch not applied: %d entries\n", len(resp.Entries))
+	}
+
+	for i := range resp.Entries {
+
+		e, err := gct.LogEntryFromLeaf(int64(i+2), &resp.Entries[i])
+		if err != nil {
+			t.Fatalf("FAIL: entry %d: %s\n", i+2, err)
+		}
+
+		if (i%2 == 0) != (e.Precert != nil) {
+			t.Fatalf("FAIL: entry %d has invalid type\n", i+2)
+		}
+	}
+
+	roots, err := c.GetAcceptedRoots(ctx)
+	if err != nil || len(roots) != 1 {
+		t.Fatalf("FAIL: invalid roots: %v\n", err)
+	}
+
+	// Verify the signature, the consistency and the inclusion proofs with the Auditor
+	a, err := ctlog.NewAuditor(ctlog.Log{Name: "cttest", URI: srv.URL, PubKey: l.PubKey()})
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if _, err := a.Update(ctx); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	for i := 0; i < 7; i++ {
+		if _, _, err := l.AddCert(l.Template("example.org")); err != nil {
+			t.Fatalf("FAIL: %s\n", err)
+		}
+	}
+
+	sth, err := a.Update(ctx)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if sth.TreeSize != uint64(l.Len()) {
+		t.Fatalf("FAIL: tree size want %d, got %d\n", l.Len(), sth.TreeSize)
+	}
+
+	for i := 0; i < l.Len(); i++ {
+		if err := a.VerifyInclusion(ctx, int64(i), l.Leaf(i)); err != nil {
+			t.Fatalf("FAIL: inclusion of %d: %s\n", i, err)
+		}
+	}
+}
+
+func TestLogMisbehave(t *testing.T) {
+
+	l, err := NewLog()
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		if _, _, err := l.AddCert(l.Template("example.com")); err != nil {
+			t.Fatalf("FAIL: %s\n", err)
+		}
+	}
+
+	srv := httptest.NewServer(l)
+	defer srv.Close()
+
+	c, err := client.New(srv.URL, http.DefaultClient, jsonclient.Options{})
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	ctx := context.Background()
+
+	l.SetFailures(2)
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetSTH(ctx); err == nil {
+			t.Fatalf("FAIL: request %d not failed\n", i)
+		}
+	}
+
+	honest, err := c.GetSTH(ctx)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	l.SetTreeSize(4)
+
+	sth, err := c.GetSTH(ctx)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if sth.TreeSize != 4 {
+		t.Fatalf("FAIL: tree size want 4, got %d\n", sth.TreeSize)
+	}
+
+	if _, err := c.GetRawEntries(ctx, 4, 5); err == nil {
+		t.Fatalf("FAIL: entry above the tree size served\n")
+	}
+
+	l.SetTreeSize(0)
+	l.SetSplitView(true)
+
+	forked, err := c.GetSTH(ctx)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if forked.TreeSize != honest.TreeSize || forked.SHA256RootHash == honest.SHA256RootHash {
+		t.Fatalf("FAIL: split view not served\n")
+	}
+
+	resp, err := c.GetRawEntries(ctx, 9, 9)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if _, err := gct.LogEntryFromLeaf(9, &resp.Entries[0]); err != nil {
+		t.Fatalf("FAIL: invalid forked entry: %s\n", err)
+	}
+}
@@ -0,0 +1,94 @@
+package cttest
+
+import "crypto/sha256"
+
+// The reference implementation of the Merkle Hash Tree of RFC 6962 section 2.1.
+// The hashes are recomputed on every request, which is fine for test sized trees.
+
+func leafHash(leaf []byte) []byte {
+
+	h := sha256.Sum256(append([]byte{0x00}, leaf...))
+
+	return h[:]
+}
+
+func nodeHash(l, r []byte) []byte {
+
+	b := make([]byte, 0, 1+len(l)+len(r))
+	b = append(b, 0x01)
+	b = append(b, l...)
+	b = append(b, r...)
+
+	h := sha256.Sum256(b)
+
+	return h[:]
+}
+
+// split returns the largest power of 2 smaller than n.
+func split(n int) int {
+
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+
+	return k
+}
+
+// rootHash returns the Merkle Tree Hash of the leaves.
+func rootHash(leaves [][]byte) []byte {
+
+	switch len(leaves) {
+	case 0:
+		h := sha256.Sum256(nil)
+		return h[:]
+	case 1:
+		return leafHash(leaves[0])
+	}
+
+	k := split(len(leaves))
+
+	return nodeHash(rootHash(leaves[:k]), rootHash(leaves[k:]))
+}
+
+// inclusionProof returns the audit path of the m. leaf (RFC 6962 section 2.1.1).
+func inclusionProof(m int, leaves [][]byte) [][]byte {
+
+	if len(leaves) <= 1 {
+		return nil
+	}
+
+	k := split(len(leaves))
+
+	if m < k {
+		return append(inclusionProof(m, leaves[:k]), rootHash(leaves[k:]))
+	}
+
+	return append(inclusionProof(m-k, leaves[k:]), rootHash(leaves[:k]))
+}
+
+// consistencyProof returns the consistency proof between the first m leaves and the leaves (RFC 6962 section 2.1.2).
+func consistencyProof(m int, leaves [][]byte) [][]byte {
+
+	return subproof(m, leaves, true)
+}
+
+func subproof(m int, leaves [][]byte, b bool) [][]byte {
+
+	n := len(leaves)
+
+	if m == n {
+		if b {
+			return nil
+		}
+		return [][]byte{rootHash(leaves)}
+	}
+
+	k := split(n)
+
+	if m <= k {
+		return append(subproof(m, leaves[:k], b), rootHash(leaves[k:]))
+	}
+
+	return append(subproof(m-k, leaves[k:], false), rootHash(leaves[:k]))
+}
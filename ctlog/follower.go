@@ -0,0 +1,471 @@
+package ctlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	gct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/client"
+	"github.com/google/certificate-transparency-go/jsonclient"
+)
+
+// Entry is a log entry emitted by the Follower.
+type Entry struct {
+	Index int64
+	Raw   *gct.RawLogEntry // The raw entry, nil if Err is not nil
+	Err   error            // Error of the leaf parsing
+}
+
+// Follower tails a log and emits the entries in order.
+//
+// The entries are fetched concurrently in parallel ranges, but always emitted in the order of the index.
+// The index of the next entry is saved to the CheckpointStore after every round and when Run() returns,
+// so a restarted Follower continues without gaps or duplicates.
+//
+// The HTTP 429 and 5xx responses and the network errors are retried with exponential backoff.
+type Follower struct {
+	uri        string
+	client     *client.LogClient
+	store      CheckpointStore
+	start      int64         // Index used if no checkpoint saved
+	workers    int           // Number of parallel requests
+	batch      int64         // Number of entries requested at once, 0 means unknown
+	poll       time.Duration // Wait time if no new entry
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	autoCommit bool
+	auditor    *Auditor
+	committed  int64 // Index of the next uncommitted entry
+	m          *sync.Mutex
+}
+
+// NewFollower creates a Follower for the log uri.
+// If store is nil, the checkpoints are not saved.
+//
+// The default is 4 workers, 30 seconds poll interval, 1 second minimum and 5 minutes maximum backoff.
+// The batch size is detected on the first run (see MaxBatchSize()).
+func NewFollower(uri string, store CheckpointStore) (*Follower, error) {
+
+	c, err := client.New(uri, http.DefaultClient, jsonclient.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	f := &Follower{
+		uri:        uri,
+		client:     c,
+		store:      store,
+		workers:    4,
+		poll:       30 * time.Second,
+		minBackoff: time.Second,
+		maxBackoff: 5 * time.Minute,
+		autoCommit: true,
+		m:          new(sync.Mutex),
+	}
+
+	return f, nil
+}
+
+// SetHTTPClient sets the HTTP client used to query the log.
+func (f *Follower) SetHTTPClient(hc *http.Client) error {
+
+	c, err := client.New(f.uri, hc, jsonclient.Options{})
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	f.client = c
+
+	return nil
+}
+
+// SetStart sets the index of the first entry if no checkpoint is saved.
+// The default is 0.
+func (f *Follower) SetStart(index int64) {
+
+	if index < 0 {
+		index = 0
+	}
+
+	f.start = index
+}
+
+// SetWorkers sets the number of parallel requests.
+func (f *Follower) SetWorkers(n int) {
+
+	if n < 1 {
+		n = 1
+	}
+
+	f.workers = n
+}
+
+// SetBatchSize sets the number of entries requested at once, and disables the detection of the max batch size.
+func (f *Follower) SetBatchSize(n int64) {
+
+	if n < 1 {
+		n = 1
+	}
+
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	f.batch = n
+}
+
+// BatchSize returns the number of entries requested at once.
+// Returns 0 if the batch size is not detected yet.
+func (f *Follower) BatchSize() int64 {
+
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	return f.batch
+}
+
+// SetPollInterval sets the time to wait for new entries when the Follower reached the end of the log.
+func (f *Follower) SetPollInterval(d time.Duration) {
+
+	f.poll = d
+}
+
+// SetBackoff sets the minimum and the maximum wait time between the retries.
+func (f *Follower) SetBackoff(min, max time.Duration) {
+
+	if max < min {
+		max = min
+	}
+
+	f.minBackoff = min
+	f.maxBackoff = max
+}
+
+// SetAutoCommit sets whether the entries are committed when received from the channel.
+// The default is true.
+//
+// If auto commit is disabled, the processed entries must be committed with Commit(),
+// the uncommitted entries are emitted again after a restart.
+func (f *Follower) SetAutoCommit(v bool) {
+
+	f.autoCommit = v
+}
+
+// SetAuditor sets the Auditor that verifies the tree heads of the log.
+// If set, only the entries of the verified and consistent trees are fetched,
+// and Run() returns if the log is misbehaving (ErrInvalidSignature or ErrInconsistentSTH).
+func (f *Follower) SetAuditor(a *Auditor) {
+
+	f.auditor = a
+}
+
+// Commit marks the entries up to index (inclusive) as processed.
+func (f *Follower) Commit(index int64) {
+
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if index+1 > f.committed {
+		f.committed = index + 1
+	}
+}
+
+// Checkpoint returns the index of the next uncommitted entry.
+func (f *Follower) Checkpoint() int64 {
+
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	return f.committed
+}
+
+// save saves the checkpoint to the store.
+func (f *Follower) save() error {
+
+	if f.store == nil {
+		return nil
+	}
+
+	if err := f.store.Save(f.uri, f.Checkpoint()); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// retryable returns whether the request that returned err can be retried.
+// Only the transport errors (eg.: timeout, connection reset) and the 429 and 5xx responses are retryable.
+func retryable(err error) bool {
+
+	var (
+		rspErr jsonclient.RspError
+		netErr net.Error
+	)
+
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.As(err, &rspErr):
+		return rspErr.StatusCode == http.StatusTooManyRequests || rspErr.StatusCode >= 500
+	case errors.As(err, &netErr):
+		return true
+	default:
+		return errors.Is(err, io.ErrUnexpectedEOF)
+	}
+}
+
+// retry calls fn until it returns nil or a non retryable error.
+func (f *Follower) retry(ctx context.Context, fn func() error) error {
+
+	delay := f.minBackoff
+
+	for {
+
+		err := fn()
+		if err == nil || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > f.maxBackoff {
+			delay = f.maxBackoff
+		}
+	}
+}
+
+// size returns the tree size of the log.
+func (f *Follower) size(ctx context.Context) (int64, error) {
+
+	var sth *gct.SignedTreeHead
+
+	err := f.retry(ctx, func() error {
+
+		var err error
+
+		if f.auditor != nil {
+			sth, err = f.auditor.Update(ctx)
+		} else {
+			sth, err = f.client.GetSTH(ctx)
+		}
+
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get SignedTreeHead: %w", err)
+	}
+
+	return int64(sth.TreeSize), nil
+}
+
+// detectBatchSize sets the batch size to the max batch size of the log if not set.
+// size is the tree size of the log.
+func (f *Follower) detectBatchSize(ctx context.Context, size int64) error {
+
+	if f.BatchSize() > 0 {
+		return nil
+	}
+
+	end := int64(10000)
+	if end > size {
+		end = size
+	}
+
+	var resp *gct.GetEntriesResponse
+
+	err := f.retry(ctx, func() error {
+		var err error
+		resp, err = f.client.GetRawEntries(ctx, 0, end-1)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get raw entries: %w", err)
+	}
+
+	f.SetBatchSize(int64(len(resp.Entries)))
+
+	return nil
+}
+
+// fetch fetches the entries in the range [start, end).
+// The log can return less entries than requested, the rest is fetched with new requests.
+func (f *Follower) fetch(ctx context.Context, start, end int64) ([]Entry, error) {
+
+	r := make([]Entry, 0, end-start)
+
+	for start < end {
+
+		var resp *gct.GetEntriesResponse
+
+		err := f.retry(ctx, func() error {
+			var err error
+			resp, err = f.client.GetRawEntries(ctx, start, end-1)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get entries %d-%d: %w", start, end-1, err)
+		}
+
+		if len(resp.Entries) == 0 {
+			return nil, fmt.Errorf("failed to get entries %d-%d: empty response", start, end-1)
+		}
+
+		if int64(len(resp.Entries)) > end-start {
+			resp.Entries = resp.Entries[:end-start]
+		}
+
+		for i := range resp.Entries {
+
+			e := Entry{Index: start + int64(i)}
+			e.Raw, e.Err = gct.RawLogEntryFromLeaf(e.Index, &resp.Entries[i])
+
+			r = append(r, e)
+		}
+
+		start += int64(len(resp.Entries))
+	}
+
+	return r, nil
+}
+
+// round fetches the entries from next in parallel ranges up to size and returns them in order.
+func (f *Follower) round(ctx context.Context, next, size int64) ([]Entry, error) {
+
+	batch := f.BatchSize()
+
+	var (
+		results [][]Entry
+		errs    []error
+		wg      sync.WaitGroup
+	)
+
+	for i := 0; i < f.workers && next < size; i++ {
+
+		end := next + batch
+		if end > size {
+			end = size
+		}
+
+		results = append(results, nil)
+		errs = append(errs, nil)
+
+		wg.Add(1)
+
+		go func(i int, start, end int64) {
+			defer wg.Done()
+			results[i], errs[i] = f.fetch(ctx, start, end)
+		}(i, next, end)
+
+		next = end
+	}
+
+	wg.Wait()
+
+	var r []Entry
+
+	// Return the entries up to the first failed range to keep the order
+	for i := range results {
+
+		if errs[i] != nil {
+			return r, errs[i]
+		}
+
+		r = append(r, results[i]...)
+	}
+
+	return r, nil
+}
+
+// Run follows the log and sends the entries in order to entries.
+// The entries channel is closed when Run returns.
+//
+// Run starts at the saved checkpoint, or at the index set with SetStart() if no checkpoint saved.
+// When the end of the log is reached, Run waits for new entries.
+//
+// Run returns when ctx is done (returns ctx.Err()), or on a non retryable error.
+func (f *Follower) Run(ctx context.Context, entries chan<- Entry) (err error) {
+
+	defer close(entries)
+
+	next := f.start
+
+	if f.store != nil {
+
+		i, err := f.store.Load(f.uri)
+
+		switch {
+		case err == nil:
+			next = i
+		case !errors.Is(err, ErrNoCheckpoint):
+			return fmt.Errorf("failed to load checkpoint: %w", err)
+		}
+	}
+
+	f.m.Lock()
+	f.committed = next
+	f.m.Unlock()
+
+	defer func() {
+		if serr := f.save(); serr != nil && err == nil {
+			err = serr
+		}
+	}()
+
+	for {
+
+		size, err := f.size(ctx)
+		if err != nil {
+			return err
+		}
+
+		if next >= size {
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(f.poll):
+			}
+
+			continue
+		}
+
+		if err := f.detectBatchSize(ctx, size); err != nil {
+			return fmt.Errorf("failed to detect batch size: %w", err)
+		}
+
+		es, rerr := f.round(ctx, next, size)
+
+		for i := range es {
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case entries <- es[i]:
+			}
+
+			if f.autoCommit {
+				f.Commit(es[i].Index)
+			}
+
+			next = es[i].Index + 1
+		}
+
+		if rerr != nil {
+			return rerr
+		}
+
+		if err := f.save(); err != nil {
+			return err
+		}
+	}
+}
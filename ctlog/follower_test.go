@@ -0,0 +1,193 @@
+package ctlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/certificate-transparency-go/jsonclient"
+)
+
+// follow runs f until n entries received and returns the indexes.
+func follow(t *testing.T, f *Follower, n int) []int64 {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	entries := make(chan Entry)
+	errc := make(chan error, 1)
+
+	go func() { errc <- f.Run(ctx, entries) }()
+
+	var r []int64
+
+	for e := range entries {
+
+		if e.Err != nil {
+			t.Fatalf("FAIL: entry %d: %s\n", e.Index, e.Err)
+		}
+
+		if string(e.Raw.Cert.Data) != fmt.Sprintf("cert-%d", e.Index) {
+			t.Fatalf("FAIL: entry %d has invalid certificate: %s\n", e.Index, e.Raw.Cert.Data)
+		}
+
+		r = append(r, e.Index)
+
+		if len(r) == n {
+			cancel()
+		}
+	}
+
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("FAIL: Run returned: %v\n", err)
+	}
+
+	return r
+}
+
+func TestFollower(t *testing.T) {
+
+	l, log := newTestServer(t, 95)
+	l.SetMaxBatch(7)
+	l.SetFailures(2)
+
+	store := NewFileCheckpoint(filepath.Join(t.TempDir(), "checkpoint.json"))
+
+	f, err := NewFollower(log.URI, store)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	f.SetStart(10)
+	f.SetBackoff(time.Millisecond, 10*time.Millisecond)
+	f.SetPollInterval(10 * time.Millisecond)
+
+	r := follow(t, f, 85)
+
+	for i := range r {
+		if r[i] != int64(i)+10 {
+			t.Fatalf("FAIL: entry %d has index %d\n", i, r[i])
+		}
+	}
+
+	if f.BatchSize() != 7 {
+		t.Fatalf("FAIL: batch size want 7, got %d\n", f.BatchSize())
+	}
+
+	if i, err := store.Load(log.URI); err != nil || i != 95 {
+		t.Fatalf("FAIL: checkpoint want 95, got %d (%v)\n", i, err)
+	}
+
+	// Restart the follower after the log grown
+	addTestEntries(t, l, 120)
+
+	f, err = NewFollower(log.URI, store)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	f.SetBatchSize(10)
+	f.SetWorkers(3)
+	f.SetPollInterval(10 * time.Millisecond)
+
+	r = follow(t, f, 25)
+
+	for i := range r {
+		if r[i] != int64(i)+95 {
+			t.Fatalf("FAIL: entry %d has index %d after restart\n", i, r[i])
+		}
+	}
+}
+
+func TestFollowerManualCommit(t *testing.T) {
+
+	_, log := newTestServer(t, 20)
+
+	store := NewMemoryCheckpoint()
+
+	f, err := NewFollower(log.URI, store)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	f.SetAutoCommit(false)
+	f.SetPollInterval(10 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	entries := make(chan Entry)
+	errc := make(chan error, 1)
+
+	go func() { errc <- f.Run(ctx, entries) }()
+
+	for e := range entries {
+
+		// Process only the first 5 entries
+		if e.Index < 5 {
+			f.Commit(e.Index)
+		}
+
+		if e.Index == 19 {
+			cancel()
+		}
+	}
+
+	<-errc
+
+	if i, err := store.Load(log.URI); err != nil || i != 5 {
+		t.Fatalf("FAIL: checkpoint want 5, got %d (%v)\n", i, err)
+	}
+}
+
+func TestFollowerError(t *testing.T) {
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	f, err := NewFollower(srv.URL, nil)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	f.SetBackoff(time.Millisecond, time.Millisecond)
+
+	if err := f.Run(context.Background(), make(chan Entry)); err == nil {
+		t.Fatalf("FAIL: error is nil on 404\n")
+	}
+}
+
+func TestRetryable(t *testing.T) {
+
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"429", jsonclient.RspError{StatusCode: http.StatusTooManyRequests}, true},
+		{"503", fmt.Errorf("failed: %w", jsonclient.RspError{StatusCode: http.StatusServiceUnavailable}), true},
+		{"404", jsonclient.RspError{StatusCode: http.StatusNotFound}, false},
+		{"timeout", &net.OpError{Op: "dial", Err: os.ErrDeadlineExceeded}, true},
+		{"url error", &url.Error{Op: "Get", URL: "https://example.com", Err: io.ErrUnexpectedEOF}, true},
+		{"unexpected EOF", fmt.Errorf("failed: %w", io.ErrUnexpectedEOF), true},
+		{"canceled", &url.Error{Op: "Get", URL: "https://example.com", Err: context.Canceled}, false},
+		{"deadline", context.DeadlineExceeded, false},
+		{"invalid signature", fmt.Errorf("failed: %w", ErrInvalidSignature), false},
+		{"decode", errors.New("invalid character '<' looking for beginning of value"), false},
+	}
+
+	for _, c := range cases {
+		if got := retryable(c.err); got != c.want {
+			t.Fatalf("FAIL: %s: want %v, got %v\n", c.name, c.want, got)
+		}
+	}
+}
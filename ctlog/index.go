@@ -0,0 +1,628 @@
+package ctlog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elmasy-com/elnet/dns"
+)
+
+// Sighting is an appearance of a certificate in a log.
+type Sighting struct {
+	Log       string    // URI of the log
+	Index     int64     // Index of the entry in the log
+	Timestamp time.Time // Timestamp of the entry
+}
+
+// IndexEntry is a certificate stored in the Index.
+type IndexEntry struct {
+	Fingerprint string    // Hex encoded SHA-256 hash of the logged certificate
+	TBSHash     string    // See Record.TBSHash
+	Type        EntryType // Type of the entry
+	Issuer      string    // Distinguished name of the issuer
+	Names       []string  // Normalized names from the common name and the DNS SANs (eg.: "*.example.com", "www.example.com")
+	NotBefore   time.Time
+	NotAfter    time.Time
+	Serial      string     // Hex encoded serial number
+	Seen        []Sighting // Every log entry of the certificate
+}
+
+// sightingLine is a line in the index file that records a new sighting of an already stored certificate.
+type sightingLine struct {
+	Fingerprint string
+	Seen        []Sighting
+}
+
+// Query is the search criteria of Index.Search().
+// The empty fields are ignored, the non empty fields must match all.
+type Query struct {
+	Name   string    // Certificates for the exact name (eg.: "www.example.com")
+	Suffix string    // Certificates for the name or any subdomain of it (eg.: "example.com" matches "example.com" and "*.www.example.com")
+	Prefix string    // Certificates for names start with prefix (eg.: "www.")
+	Issuer string    // Certificates issued by the distinguished name
+	From   time.Time // Certificates valid at or after From
+	To     time.Time // Certificates valid at or before To
+}
+
+// Index is a local on-disk index of the certificates, fed with the log entries (eg.: from a Follower).
+// The certificates are indexed by name, registrable domain, SHA-256 fingerprint and issuer.
+//
+// The same certificate logged to multiple logs is stored once with every sighting.
+//
+// The index is stored in a JSON lines file and kept in memory.
+// The file is append only, use Compact() to merge the sightings.
+//
+// An Index is safe for concurrent use.
+type Index struct {
+	path          string
+	f             *os.File
+	entries       []*IndexEntry
+	byFingerprint map[string]*IndexEntry
+	byName        map[string][]*IndexEntry
+	byDomain      map[string]map[string]struct{} // Registrable domain -> names
+	byIssuer      map[string][]*IndexEntry
+	names         []string // Sorted names, nil if must be rebuilt
+	m             *sync.Mutex
+}
+
+// OpenIndex opens the Index stored at path.
+// The file is created if not exists.
+//
+// A partially written last line (eg.: after a crash) is removed.
+func OpenIndex(path string) (*Index, error) {
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	x := newIndex(path, f)
+
+	if err := x.load(); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return x, nil
+}
+
+// newIndex returns an empty Index.
+func newIndex(path string, f *os.File) *Index {
+
+	return &Index{
+		path:          path,
+		f:             f,
+		byFingerprint: make(map[string]*IndexEntry),
+		byName:        make(map[string][]*IndexEntry),
+		byDomain:      make(map[string]map[string]struct{}),
+		byIssuer:      make(map[string][]*IndexEntry),
+		m:             new(sync.Mutex),
+	}
+}
+
+// load reads the index file and positions the file to the end of the last complete line.
+func (x *Index) load() error {
+
+	var (
+		r      = bufio.NewReader(x.f)
+		offset int64
+	)
+
+	for n := 1; ; n++ {
+
+		line, err := r.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to read %s: %w", x.path, err)
+		}
+
+		// Partially written line
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		offset += int64(len(line))
+
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		e := new(IndexEntry)
+
+		if err := json.Unmarshal(line, e); err != nil {
+			return fmt.Errorf("failed to unmarshal line %d of %s: %w", n, x.path, err)
+		}
+
+		x.merge(e)
+	}
+
+	if err := x.f.Truncate(offset); err != nil {
+		return fmt.Errorf("failed to truncate %s: %w", x.path, err)
+	}
+
+	if _, err := x.f.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek %s: %w", x.path, err)
+	}
+
+	return nil
+}
+
+// merge adds e to the in-memory index, or appends the sightings of e if the certificate is already stored.
+// Returns the sightings that are new.
+func (x *Index) merge(e *IndexEntry) []Sighting {
+
+	stored, ok := x.byFingerprint[e.Fingerprint]
+	if !ok {
+
+		seen := e.Seen
+		e.Seen = nil
+		x.add(e)
+
+		stored = e
+		e = &IndexEntry{Seen: seen}
+	}
+
+	var added []Sighting
+
+	for _, s := range e.Seen {
+
+		if stored.seenIn(s.Log, s.Index) {
+			continue
+		}
+
+		stored.Seen = append(stored.Seen, s)
+		added = append(added, s)
+	}
+
+	return added
+}
+
+// add adds the new certificate e to the in-memory index.
+func (x *Index) add(e *IndexEntry) {
+
+	x.entries = append(x.entries, e)
+	x.byFingerprint[e.Fingerprint] = e
+	x.byIssuer[e.Issuer] = append(x.byIssuer[e.Issuer], e)
+
+	for _, n := range e.Names {
+
+		if _, ok := x.byName[n]; !ok {
+			x.names = nil
+		}
+
+		x.byName[n] = append(x.byName[n], e)
+
+		d := dns.GetDomain(strings.TrimPrefix(n, "*."))
+		if d == "" {
+			continue
+		}
+
+		if x.byDomain[d] == nil {
+			x.byDomain[d] = make(map[string]struct{})
+		}
+
+		x.byDomain[d][n] = struct{}{}
+	}
+}
+
+// seenIn returns whether e is seen in the entry index of log.
+func (e *IndexEntry) seenIn(log string, index int64) bool {
+
+	for i := range e.Seen {
+		if e.Seen[i].Log == log && e.Seen[i].Index == index {
+			return true
+		}
+	}
+
+	return false
+}
+
+// copy returns a deep copy of e.
+func (e *IndexEntry) copy() IndexEntry {
+
+	c := *e
+	c.Names = append([]string(nil), e.Names...)
+	c.Seen = append([]Sighting(nil), e.Seen...)
+
+	return c
+}
+
+// indexNames returns the normalized, valid and unique names of the common name and the DNS SANs of r.
+// The wildcard names are kept (eg.: "*.example.com").
+func indexNames(r *Record) []string {
+
+	var (
+		names []string
+		seen  = make(map[string]struct{})
+	)
+
+	for _, n := range append([]string{r.CommonName}, r.DNSNames...) {
+
+		n = dns.Clean(n)
+
+		if _, ok := seen[n]; ok || !dns.IsValid(strings.TrimPrefix(n, "*.")) {
+			continue
+		}
+
+		seen[n] = struct{}{}
+		names = append(names, n)
+	}
+
+	return names
+}
+
+// write appends v as a line to the index file.
+// If the write failed, the partially written line is removed, so the later lines are not appended after it.
+func (x *Index) write(v any) error {
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal: %w", err)
+	}
+
+	offset, err := x.f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("failed to seek %s: %w", x.path, err)
+	}
+
+	if _, err := x.f.Write(append(data, '\n')); err != nil {
+
+		if terr := x.f.Truncate(offset); terr != nil {
+			return fmt.Errorf("failed to write %s: %w (failed to truncate: %s)", x.path, err, terr)
+		}
+
+		if _, serr := x.f.Seek(offset, io.SeekStart); serr != nil {
+			return fmt.Errorf("failed to write %s: %w (failed to seek: %s)", x.path, err, serr)
+		}
+
+		return fmt.Errorf("failed to write %s: %w", x.path, err)
+	}
+
+	return nil
+}
+
+// Add adds the record r of log to the index.
+// If the certificate is already stored (eg.: logged to other log), only the sighting is stored.
+//
+// Returns whether the certificate is new.
+func (x *Index) Add(log string, r *Record) (bool, error) {
+
+	if r == nil {
+		return false, fmt.Errorf("record is nil")
+	}
+
+	x.m.Lock()
+	defer x.m.Unlock()
+
+	s := Sighting{Log: log, Index: r.Index, Timestamp: r.Timestamp}
+
+	if e, ok := x.byFingerprint[r.Fingerprint]; ok {
+
+		if e.seenIn(log, r.Index) {
+			return false, nil
+		}
+
+		if err := x.write(sightingLine{Fingerprint: r.Fingerprint, Seen: []Sighting{s}}); err != nil {
+			return false, err
+		}
+
+		e.Seen = append(e.Seen, s)
+
+		return false, nil
+	}
+
+	e := &IndexEntry{
+		Fingerprint: r.Fingerprint,
+		TBSHash:     r.TBSHash,
+		Type:        r.Type,
+		Issuer:      r.Issuer,
+		Names:       indexNames(r),
+		NotBefore:   r.NotBefore,
+		NotAfter:    r.NotAfter,
+		Serial:      r.Serial,
+		Seen:        []Sighting{s},
+	}
+
+	if err := x.write(e); err != nil {
+		return false, err
+	}
+
+	x.add(e)
+
+	return true, nil
+}
+
+// Run reads the entries of log until the channel is closed and adds them to the index.
+// The invalid entries (eg.: failed to parse the certificate) are skipped.
+//
+// Run returns nil if entries is closed, ctx.Err() if ctx is done or the error of the write.
+func (x *Index) Run(ctx context.Context, log string, entries <-chan Entry) error {
+
+	for {
+
+		var (
+			e  Entry
+			ok bool
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case e, ok = <-entries:
+			if !ok {
+				return nil
+			}
+		}
+
+		r, err := e.Record()
+		if err != nil {
+			continue
+		}
+
+		if _, err := x.Add(log, r); err != nil {
+			return err
+		}
+	}
+}
+
+// Len returns the number of the stored certificates.
+func (x *Index) Len() int {
+
+	x.m.Lock()
+	defer x.m.Unlock()
+
+	return len(x.entries)
+}
+
+// Get returns the certificate with the hex encoded SHA-256 fingerprint.
+// Returns nil if the certificate is not stored.
+func (x *Index) Get(fingerprint string) *IndexEntry {
+
+	x.m.Lock()
+	defer x.m.Unlock()
+
+	e, ok := x.byFingerprint[strings.ToLower(fingerprint)]
+	if !ok {
+		return nil
+	}
+
+	c := e.copy()
+
+	return &c
+}
+
+// hasSuffix returns whether name is suffix or a subdomain of suffix.
+func hasSuffix(name, suffix string) bool {
+
+	return name == suffix || strings.HasSuffix(name, "."+suffix)
+}
+
+// suffixNames returns the stored names equal to or under suffix.
+func (x *Index) suffixNames(suffix string) []string {
+
+	var r []string
+
+	d := dns.GetDomain(suffix)
+
+	// suffix is a public suffix (eg.: "com" or "co.uk"), check every name
+	if d == "" {
+
+		for n := range x.byName {
+			if hasSuffix(n, suffix) {
+				r = append(r, n)
+			}
+		}
+
+		return r
+	}
+
+	for n := range x.byDomain[d] {
+		if hasSuffix(n, suffix) {
+			r = append(r, n)
+		}
+	}
+
+	return r
+}
+
+// prefixNames returns the stored names start with prefix.
+func (x *Index) prefixNames(prefix string) []string {
+
+	if x.names == nil {
+
+		x.names = make([]string, 0, len(x.byName))
+
+		for n := range x.byName {
+			x.names = append(x.names, n)
+		}
+
+		sort.Strings(x.names)
+	}
+
+	var r []string
+
+	for i := sort.SearchStrings(x.names, prefix); i < len(x.names) && strings.HasPrefix(x.names[i], prefix); i++ {
+		r = append(r, x.names[i])
+	}
+
+	return r
+}
+
+// Names returns the sorted names equal to or under suffix (eg.: "example.com" returns "example.com", "www.example.com", "*.api.example.com", ...).
+// suffix is normalized with dns.Clean().
+//
+// This answers what names had certificates for a domain.
+func (x *Index) Names(suffix string) []string {
+
+	x.m.Lock()
+	defer x.m.Unlock()
+
+	r := x.suffixNames(dns.Clean(suffix))
+
+	sort.Strings(r)
+
+	return r
+}
+
+// match returns whether e matches q.
+// The names of q must be normalized.
+func (e *IndexEntry) match(q Query) bool {
+
+	if q.Issuer != "" && e.Issuer != q.Issuer {
+		return false
+	}
+
+	if !q.From.IsZero() && e.NotAfter.Before(q.From) {
+		return false
+	}
+
+	if !q.To.IsZero() && e.NotBefore.After(q.To) {
+		return false
+	}
+
+	if q.Name == "" && q.Suffix == "" && q.Prefix == "" {
+		return true
+	}
+
+	for _, n := range e.Names {
+		if (q.Name == "" || n == q.Name) && (q.Suffix == "" || hasSuffix(n, q.Suffix)) && (q.Prefix == "" || strings.HasPrefix(n, q.Prefix)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Search returns the certificates that match q, sorted by NotBefore.
+// The names in q are normalized with dns.Clean() (the Prefix only lower cased).
+func (x *Index) Search(q Query) []IndexEntry {
+
+	q.Name = dns.Clean(q.Name)
+	q.Suffix = dns.Clean(q.Suffix)
+	q.Prefix = strings.ToLower(q.Prefix)
+
+	x.m.Lock()
+	defer x.m.Unlock()
+
+	// Select the candidates with the most specific index
+	var candidates []*IndexEntry
+
+	switch {
+	case q.Name != "":
+		candidates = x.byName[q.Name]
+	case q.Suffix != "":
+		for _, n := range x.suffixNames(q.Suffix) {
+			candidates = append(candidates, x.byName[n]...)
+		}
+	case q.Prefix != "":
+		for _, n := range x.prefixNames(q.Prefix) {
+			candidates = append(candidates, x.byName[n]...)
+		}
+	case q.Issuer != "":
+		candidates = x.byIssuer[q.Issuer]
+	default:
+		candidates = x.entries
+	}
+
+	var (
+		r    []IndexEntry
+		seen = make(map[string]struct{})
+	)
+
+	for _, e := range candidates {
+
+		if _, ok := seen[e.Fingerprint]; ok || !e.match(q) {
+			continue
+		}
+
+		seen[e.Fingerprint] = struct{}{}
+		r = append(r, e.copy())
+	}
+
+	sort.Slice(r, func(i, j int) bool {
+		if r[i].NotBefore.Equal(r[j].NotBefore) {
+			return r[i].Fingerprint < r[j].Fingerprint
+		}
+		return r[i].NotBefore.Before(r[j].NotBefore)
+	})
+
+	return r
+}
+
+// Compact rewrites the index file with one line per certificate.
+// The file is replaced atomically, a crash never leaves a partially written file.
+// The mode of the file is kept (eg.: 0644).
+func (x *Index) Compact() error {
+
+	x.m.Lock()
+	defer x.m.Unlock()
+
+	fi, err := x.f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", x.path, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(x.path), filepath.Base(x.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+
+	for _, e := range x.entries {
+		if err := enc.Encode(e); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync %s: %w", tmp.Name(), err)
+	}
+
+	// os.CreateTemp creates the file with 0600
+	if err := tmp.Chmod(fi.Mode().Perm()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to chmod %s: %w", tmp.Name(), err)
+	}
+
+	if err := os.Rename(tmp.Name(), x.path); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to rename %s: %w", tmp.Name(), err)
+	}
+
+	// Continue with the compacted file, the old one is unlinked.
+	// The offset of tmp is at the end after the write.
+	x.f.Close()
+	x.f = tmp
+
+	// Sync the directory to persist the rename
+	return syncDir(filepath.Dir(x.path))
+}
+
+// Close closes the index file.
+func (x *Index) Close() error {
+
+	x.m.Lock()
+	defer x.m.Unlock()
+
+	return x.f.Close()
+}
@@ -0,0 +1,67 @@
+package ctlog
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestIndexPartialWrite(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "index.json")
+
+	x, err := OpenIndex(path)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+	defer x.Close()
+
+	if _, err := x.Add("https://ct.elmasy.com/", &Record{Index: 1, Fingerprint: "a", CommonName: "elmasy.com"}); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	// Limit the file size to get a partially written line (SIGXFSZ is ignored by the Go runtime, the write returns EFBIG)
+	var orig syscall.Rlimit
+
+	if err := syscall.Getrlimit(syscall.RLIMIT_FSIZE, &orig); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	limit := orig
+	limit.Cur = uint64(fi.Size()) + 10
+
+	if err := syscall.Setrlimit(syscall.RLIMIT_FSIZE, &limit); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	_, err = x.Add("https://ct.elmasy.com/", &Record{Index: 2, Fingerprint: "b", CommonName: "www.elmasy.com"})
+
+	if err := syscall.Setrlimit(syscall.RLIMIT_FSIZE, &orig); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if err == nil {
+		t.Fatalf("FAIL: write over the file size limit succeeded\n")
+	}
+
+	if _, err := x.Add("https://ct.elmasy.com/", &Record{Index: 3, Fingerprint: "c", CommonName: "api.elmasy.com"}); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	x.Close()
+
+	// The partial line must not be in the middle of the file
+	if x, err = OpenIndex(path); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if x.Len() != 2 || x.Get("a") == nil || x.Get("c") == nil {
+		t.Fatalf("FAIL: invalid index after a failed write: %d entries\n", x.Len())
+	}
+}
@@ -0,0 +1,209 @@
+package ctlog
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIndex(t *testing.T) {
+
+	a, aLog := newTestServer(t, 0)
+
+	b, bLog := newTestServer(t, 0)
+	b.AddRoot(a.Root())
+
+	tmpl := a.Template("elmasy.com", "www.elmasy.com", "*.api.elmasy.com", "invalid..name")
+
+	if _, _, err := a.AddPrecert(tmpl); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	_, final, err := a.AddCert(tmpl)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if _, _, err := a.AddCert(a.Template("Example.COM")); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The final certificate logged to other log too
+	if _, err := AddChain(ctx, bLog, [][]byte{final, a.Root().Raw}); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "index.jsonl")
+
+	x, err := OpenIndex(path)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	// Feed with a Follower
+	f, err := NewFollower(aLog.URI, nil)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	f.SetPollInterval(10 * time.Millisecond)
+
+	entries := make(chan Entry)
+	done := make(chan error)
+
+	go f.Run(ctx, entries)
+	go func() { done <- x.Run(ctx, aLog.URI, entries) }()
+
+	for x.Len() < 3 && ctx.Err() == nil {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	records, _, err := GetRecords(bLog.URI, 0)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	for i := range records {
+		if isNew, err := x.Add(bLog.URI, &records[i]); err != nil || isNew {
+			t.Fatalf("FAIL: the certificate of the other log is new: %v\n", err)
+		}
+	}
+
+	if isNew, err := x.Add(bLog.URI, &records[0]); err != nil || isNew {
+		t.Fatalf("FAIL: duplicate added: %v\n", err)
+	}
+
+	if x.Len() != 3 {
+		t.Fatalf("FAIL: want 3 certificates, got %d\n", x.Len())
+	}
+
+	check := func(x *Index) {
+
+		names := x.Names("ELMASY.com.")
+		if len(names) != 3 || names[0] != "*.api.elmasy.com" || names[1] != "elmasy.com" || names[2] != "www.elmasy.com" {
+			t.Fatalf("FAIL: invalid names: %v\n", names)
+		}
+
+		e := x.Get(fingerprint(final))
+		if e == nil || len(e.Seen) != 2 || e.Seen[0].Log != aLog.URI || e.Seen[1].Log != bLog.URI || e.Type != EntryX509 {
+			t.Fatalf("FAIL: invalid entry: %#v\n", e)
+		}
+
+		for _, c := range []struct {
+			q Query
+			n int
+		}{
+			{Query{Suffix: "api.elmasy.com"}, 2},
+			{Query{Suffix: "elmasy.com", Prefix: "www."}, 2},
+			{Query{Prefix: "www."}, 2},
+			{Query{Suffix: "com"}, 3},
+			{Query{Name: "example.com"}, 1},
+			{Query{Name: "other.com"}, 0},
+			{Query{Issuer: "CN=cttest Root,O=cttest"}, 3},
+			{Query{Issuer: "CN=cttest Root,O=cttest", Name: "elmasy.com"}, 2},
+			{Query{From: time.Now(), To: time.Now().Add(24 * time.Hour)}, 3},
+			{Query{From: time.Now().Add(200 * 24 * time.Hour)}, 0},
+			{Query{To: time.Now().Add(-24 * time.Hour)}, 0},
+		} {
+			if r := x.Search(c.q); len(r) != c.n {
+				t.Fatalf("FAIL: %+v: want %d certificates, got %d\n", c.q, c.n, len(r))
+			}
+		}
+	}
+
+	check(x)
+
+	cancel()
+
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("FAIL: want context.Canceled, got %v\n", err)
+	}
+
+	if err := x.Close(); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	// Simulate a crash during write
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	file.Write([]byte(`{"Fingerprint":"ab`))
+	file.Close()
+
+	if x, err = OpenIndex(path); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	check(x)
+
+	if err := x.Compact(); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if _, err := x.Add(bLog.URI, &Record{Index: 10, Fingerprint: "new", CommonName: "new.elmasy.com"}); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	x.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if n := bytes.Count(data, []byte{'\n'}); n != 4 {
+		t.Fatalf("FAIL: want 4 lines after compact, got %d\n", n)
+	}
+
+	if x, err = OpenIndex(path); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+	defer x.Close()
+
+	if x.Len() != 4 || len(x.Names("elmasy.com")) != 4 {
+		t.Fatalf("FAIL: invalid compacted index\n")
+	}
+}
+
+func TestIndexCompactMode(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "index.json")
+
+	x, err := OpenIndex(path)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+	defer x.Close()
+
+	if _, err := x.Add("https://ct.elmasy.com/", &Record{Index: 1, Fingerprint: "a", CommonName: "elmasy.com"}); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	for _, mode := range []os.FileMode{0o644, 0o640} {
+
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatalf("FAIL: %s\n", err)
+		}
+
+		if err := x.Compact(); err != nil {
+			t.Fatalf("FAIL: %s\n", err)
+		}
+
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("FAIL: %s\n", err)
+		}
+
+		if fi.Mode().Perm() != mode {
+			t.Fatalf("FAIL: want mode %v after compact, got %v\n", mode, fi.Mode().Perm())
+		}
+	}
+}
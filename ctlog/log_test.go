@@ -1,9 +1,13 @@
 package ctlog
 
 import (
-	"strings"
-	"sync/atomic"
+	"fmt"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/elmasy-com/elnet/ctlog/cttest"
+	gct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
 )
 
 func TestLogByName(t *testing.T) {
@@ -16,64 +20,130 @@ func TestLogByName(t *testing.T) {
 	t.Logf("%#v\n", l)
 }
 
-func TestSize(t *testing.T) {
+// addTestEntries appends entries to l until it has n entries.
+// The certificate of the entry i is "cert-i".
+func addTestEntries(t *testing.T, l *cttest.Log, n int) {
 
-	for i := range Logs {
+	for i := l.Len(); i < n; i++ {
 
-		if i > 9 {
-			break
+		input, err := tls.Marshal(*gct.CreateX509MerkleTreeLeaf(gct.ASN1Cert{Data: []byte(fmt.Sprintf("cert-%d", i))}, uint64(i)))
+		if err != nil {
+			t.Fatalf("FAIL: %s\n", err)
 		}
 
-		size, err := Size(Logs[i].URI)
+		extra, err := tls.Marshal(gct.CertificateChain{})
 		if err != nil {
-			t.Fatalf("Size of %s failed: %s\n", Logs[i].Name, err)
+			t.Fatalf("FAIL: %s\n", err)
 		}
 
-		t.Logf("Size of %s: %d\n", Logs[i].Name, size)
+		l.AddEntry(gct.LeafEntry{LeafInput: input, ExtraData: extra})
 	}
 }
 
-func TestMaxBatchSize(t *testing.T) {
+// addTestCerts adds n certificates to l, every second is a precertificate.
+// The domains of the certificate i are "i.example.com" and "www.i.example.com".
+func addTestCerts(t *testing.T, l *cttest.Log, n int) {
 
-	for i := range Logs {
+	for i := 0; i < n; i++ {
 
-		if i > 9 {
-			break
+		var err error
+
+		tmpl := l.Template(fmt.Sprintf("%d.example.com", i), fmt.Sprintf("www.%d.example.com", i))
+
+		if i%2 == 0 {
+			_, _, err = l.AddPrecert(tmpl)
+		} else {
+			_, _, err = l.AddCert(tmpl)
 		}
 
-		size, err := MaxBatchSize(Logs[i].URI)
-		if err != nil && !strings.Contains(err.Error(), "429 Too Many Requests") {
-			t.Fatalf("BatchSize of %s failed: %s\n", Logs[i].Name, err)
+		if err != nil {
+			t.Fatalf("FAIL: %s\n", err)
 		}
+	}
+}
+
+// newTestServer starts a test log with n entries (see addTestEntries()) and returns it with its Log.
+// The server is closed when the test ends.
+func newTestServer(t *testing.T, n int) (*cttest.Log, Log) {
 
-		t.Logf("BatchSize of %s: %d\n", Logs[i].Name, size)
+	l, err := cttest.NewLog()
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
 	}
+
+	addTestEntries(t, l, n)
+
+	srv := httptest.NewServer(l)
+	t.Cleanup(srv.Close)
+
+	return l, Log{Name: "Test", URI: srv.URL, PubKey: l.PubKey()}
 }
 
-func TestGetDomains(t *testing.T) {
+func TestSize(t *testing.T) {
 
-	index := new(atomic.Int64)
-	total := 0
+	_, l := newTestServer(t, 10)
 
-	for {
+	size, err := Size(l.URI)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
 
-		if index.Load() > 10000 {
-			break
-		}
+	if size != 10 {
+		t.Fatalf("FAIL: size want 10, got %d\n", size)
+	}
 
-		t.Logf("index: %d, total: %d\n", index.Load(), total)
+	left, err := NumLeft(l.URI, 4)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if left != 6 {
+		t.Fatalf("FAIL: left want 6, got %d\n", left)
+	}
+}
+
+func TestMaxBatchSize(t *testing.T) {
 
-		r, n, err := GetDomains("https://ct.googleapis.com/logs/argon2022/", index.Load())
+	l, log := newTestServer(t, 10)
+	l.SetMaxBatch(4)
+
+	size, err := MaxBatchSize(log.URI)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if size != 4 {
+		t.Fatalf("FAIL: batch size want 4, got %d\n", size)
+	}
+}
+
+func TestGetDomains(t *testing.T) {
+
+	l, log := newTestServer(t, 0)
+	addTestCerts(t, l, 25)
+	l.SetMaxBatch(10)
+
+	var (
+		index int64
+		total int
+	)
+
+	for index < 25 {
+
+		r, n, err := GetDomains(log.URI, index)
 		if err != nil {
 			t.Fatalf("FAIL: %s\n", err)
 		}
 
-		index.Add(n)
+		if n == 0 || n > 10 {
+			t.Fatalf("FAIL: invalid number of parsed entries: %d\n", n)
+		}
 
+		index += n
 		total += len(r)
-
 	}
 
-	t.Logf("index: %d, total: %d\n", index.Load(), total)
-
+	if total != 50 {
+		t.Fatalf("FAIL: want 50 domains, got %d\n", total)
+	}
 }
@@ -0,0 +1,401 @@
+package ctlog
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/certificate-transparency-go/loglist3"
+)
+
+// URLs of the log list used by Chrome (v3 schema).
+const (
+	LogListURL          = loglist3.LogListURL                                          // Log list of Chrome
+	LogListSignatureURL = loglist3.LogListSignatureURL                                 // Signature of LogListURL
+	AllLogListURL       = loglist3.AllLogListURL                                       // List of every known log, not signed
+	LogListPubKeyURL    = "https://www.gstatic.com/ct/log_list/v3/log_list_pubkey.pem" // Public key to verify LogListSignatureURL
+)
+
+// LogState is the state of a log (see https://googlechrome.github.io/CertificateTransparency/log_states.html).
+type LogState string
+
+const (
+	StatePending   LogState = "pending"
+	StateQualified LogState = "qualified"
+	StateUsable    LogState = "usable"
+	StateReadOnly  LogState = "readonly"
+	StateRetired   LogState = "retired"
+	StateRejected  LogState = "rejected"
+)
+
+// Operator is a log operator.
+type Operator struct {
+	Name  string
+	Email []string
+}
+
+// LogList is a list of logs.
+type LogList struct {
+	Version   string    // Version of the list, empty for the static list
+	Timestamp time.Time // Time of the publication, zero for the static list
+	Operators []Operator
+	Logs      []Log
+}
+
+// logName returns the name of the log from the description (eg.: "Google 'Argon2024' log" -> "Argon2024").
+// If the description does not contain a quoted name, returns the description.
+func logName(description string) string {
+
+	start := strings.IndexByte(description, '\'')
+	end := strings.LastIndexByte(description, '\'')
+
+	if start == -1 || end <= start+1 {
+		return description
+	}
+
+	return description[start+1 : end]
+}
+
+// logState returns the state of s.
+func logState(s *loglist3.LogStates) LogState {
+
+	switch s.LogStatus() {
+	case loglist3.PendingLogStatus:
+		return StatePending
+	case loglist3.QualifiedLogStatus:
+		return StateQualified
+	case loglist3.UsableLogStatus:
+		return StateUsable
+	case loglist3.ReadOnlyLogStatus:
+		return StateReadOnly
+	case loglist3.RetiredLogStatus:
+		return StateRetired
+	case loglist3.RejectedLogStatus:
+		return StateRejected
+	default:
+		return ""
+	}
+}
+
+// newLogList converts ll to LogList.
+func newLogList(ll *loglist3.LogList) *LogList {
+
+	r := &LogList{Version: ll.Version, Timestamp: ll.LogListTimestamp}
+
+	for _, op := range ll.Operators {
+
+		r.Operators = append(r.Operators, Operator{Name: op.Name, Email: op.Email})
+
+		for _, l := range op.Logs {
+
+			v := Log{
+				Name:     logName(l.Description),
+				URI:      l.URL,
+				PubKey:   base64.StdEncoding.EncodeToString(l.Key),
+				LogID:    base64.StdEncoding.EncodeToString(l.LogID),
+				Operator: op.Name,
+				State:    logState(l.State),
+				MMD:      int(l.MMD),
+			}
+
+			if l.TemporalInterval != nil {
+				v.StartInclusive = l.TemporalInterval.StartInclusive
+				v.EndExclusive = l.TemporalInterval.EndExclusive
+			}
+
+			r.Logs = append(r.Logs, v)
+		}
+	}
+
+	return r
+}
+
+// ParseLogList parses the log list in data (v3 schema, see https://www.gstatic.com/ct/log_list/v3/log_list_schema.json).
+func ParseLogList(data []byte) (*LogList, error) {
+
+	ll, err := loglist3.NewFromJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return newLogList(ll), nil
+}
+
+// ParseSignedLogList verifies the signature sig of data with key and parses the log list.
+// sig is the raw signature (eg.: the content of LogListSignatureURL).
+func ParseSignedLogList(data, sig []byte, key crypto.PublicKey) (*LogList, error) {
+
+	ll, err := loglist3.NewFromSignedJSON(data, sig, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return newLogList(ll), nil
+}
+
+// DefaultClient is the HTTP client used to download the log list if no client is given (eg.: FetchLogList()).
+var DefaultClient = &http.Client{Timeout: 30 * time.Second}
+
+// get returns the body of url.
+func get(ctx context.Context, hc *http.Client, url string) ([]byte, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// FetchLogList downloads and parses the log list from url (eg.: LogListURL) with DefaultClient.
+//
+// If key is not nil, the signature is downloaded from sigURL (eg.: LogListSignatureURL) and verified with key.
+func FetchLogList(url, sigURL string, key crypto.PublicKey) (*LogList, error) {
+
+	return FetchLogListContext(context.Background(), nil, url, sigURL, key)
+}
+
+// FetchLogListContext is like FetchLogList(), but uses ctx and hc to download the list.
+// If hc is nil, DefaultClient is used.
+func FetchLogListContext(ctx context.Context, hc *http.Client, url, sigURL string, key crypto.PublicKey) (*LogList, error) {
+
+	if hc == nil {
+		hc = DefaultClient
+	}
+
+	data, err := get(ctx, hc, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", url, err)
+	}
+
+	if key == nil {
+		return ParseLogList(data)
+	}
+
+	sig, err := get(ctx, hc, sigURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", sigURL, err)
+	}
+
+	return ParseSignedLogList(data, sig, key)
+}
+
+// ParseLogListKey parses the PEM encoded public key of the log list (eg.: downloaded from LogListPubKeyURL).
+func ParseLogListKey(data []byte) (crypto.PublicKey, error) {
+
+	b, _ := pem.Decode(data)
+	if b == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	return x509.ParsePKIXPublicKey(b.Bytes)
+}
+
+// FetchLogListKey downloads and parses the public key of the log list from url (eg.: LogListPubKeyURL).
+// If hc is nil, DefaultClient is used.
+func FetchLogListKey(ctx context.Context, hc *http.Client, url string) (crypto.PublicKey, error) {
+
+	if hc == nil {
+		hc = DefaultClient
+	}
+
+	data, err := get(ctx, hc, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", url, err)
+	}
+
+	return ParseLogListKey(data)
+}
+
+// DefaultLogList returns the static list (Logs) as LogList.
+func DefaultLogList() *LogList {
+
+	r := &LogList{Logs: make([]Log, len(Logs))}
+
+	copy(r.Logs, Logs)
+
+	for i := range r.Logs {
+
+		if r.Logs[i].LogID == "" {
+			if id, err := r.Logs[i].ID(); err == nil {
+				r.Logs[i].LogID = base64.StdEncoding.EncodeToString(id[:])
+			}
+		}
+
+		found := false
+
+		for ii := range r.Operators {
+			if r.Operators[ii].Name == r.Logs[i].Operator {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			r.Operators = append(r.Operators, Operator{Name: r.Logs[i].Operator})
+		}
+	}
+
+	return r
+}
+
+// LoadLogList returns the log list with FetchLogList().
+// If the download or the verification failed, returns DefaultLogList() with the error.
+func LoadLogList(url, sigURL string, key crypto.PublicKey) (*LogList, error) {
+
+	return LoadLogListContext(context.Background(), nil, url, sigURL, key)
+}
+
+// LoadLogListContext is like LoadLogList(), but uses ctx and hc to download the list (see FetchLogListContext()).
+func LoadLogListContext(ctx context.Context, hc *http.Client, url, sigURL string, key crypto.PublicKey) (*LogList, error) {
+
+	l, err := FetchLogListContext(ctx, hc, url, sigURL, key)
+	if err != nil {
+		return DefaultLogList(), err
+	}
+
+	return l, nil
+}
+
+// Filter returns a new LogList with the logs for which fn returns true.
+func (l *LogList) Filter(fn func(Log) bool) *LogList {
+
+	r := &LogList{Version: l.Version, Timestamp: l.Timestamp, Operators: l.Operators}
+
+	for i := range l.Logs {
+		if fn(l.Logs[i]) {
+			r.Logs = append(r.Logs, l.Logs[i])
+		}
+	}
+
+	return r
+}
+
+// ByState returns a new LogList with the logs in any of states.
+func (l *LogList) ByState(states ...LogState) *LogList {
+
+	return l.Filter(func(v Log) bool {
+
+		for i := range states {
+			if v.State == states[i] {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// Usable returns a new LogList with the logs in usable state.
+func (l *LogList) Usable() *LogList {
+
+	return l.ByState(StateUsable)
+}
+
+// ForExpiry returns a new LogList with the logs that accept a certificate that expires at notAfter (see Log.Covers()).
+func (l *LogList) ForExpiry(notAfter time.Time) *LogList {
+
+	return l.Filter(func(v Log) bool { return v.Covers(notAfter) })
+}
+
+// ByName returns the Log based on the name.
+// The name is case insensitive!
+//
+// Returns nil if the Log not found.
+func (l *LogList) ByName(name string) *Log {
+
+	for i := range l.Logs {
+		if strings.EqualFold(l.Logs[i].Name, name) {
+			return &l.Logs[i]
+		}
+	}
+
+	return nil
+}
+
+// ByURI returns the Log based on the URI.
+// The trailing slash is ignored.
+//
+// Returns nil if the Log not found.
+func (l *LogList) ByURI(uri string) *Log {
+
+	uri = strings.TrimSuffix(uri, "/")
+
+	for i := range l.Logs {
+		if strings.TrimSuffix(l.Logs[i].URI, "/") == uri {
+			return &l.Logs[i]
+		}
+	}
+
+	return nil
+}
+
+// ByLogID returns the Log based on the base64 encoded log ID.
+//
+// Returns nil if the Log not found.
+func (l *LogList) ByLogID(id string) *Log {
+
+	for i := range l.Logs {
+		if l.Logs[i].LogID == id {
+			return &l.Logs[i]
+		}
+	}
+
+	return nil
+}
+
+// Covers returns whether the log accepts a certificate that expires at notAfter.
+// A log without temporal interval accepts every certificate.
+func (l Log) Covers(notAfter time.Time) bool {
+
+	if l.StartInclusive.IsZero() && l.EndExclusive.IsZero() {
+		return true
+	}
+
+	return !notAfter.Before(l.StartInclusive) && notAfter.Before(l.EndExclusive)
+}
+
+// PublicKey parses the public key of the log.
+func (l Log) PublicKey() (crypto.PublicKey, error) {
+
+	der, err := base64.StdEncoding.DecodeString(l.PubKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	return key, nil
+}
+
+// ID returns the log ID, the SHA-256 hash of the public key.
+func (l Log) ID() ([sha256.Size]byte, error) {
+
+	der, err := base64.StdEncoding.DecodeString(l.PubKey)
+	if err != nil {
+		return [sha256.Size]byte{}, fmt.Errorf("failed to decode public key: %w", err)
+	}
+
+	return sha256.Sum256(der), nil
+}
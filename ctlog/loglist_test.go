@@ -0,0 +1,190 @@
+package ctlog
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testLogList = `{
+  "version": "1.2",
+  "log_list_timestamp": "2026-10-01T12:00:00Z",
+  "operators": [
+    {
+      "name": "Google",
+      "email": ["google-ct-logs@googlegroups.com"],
+      "logs": [
+        {
+          "description": "Google 'Argon2026h2' log",
+          "log_id": "DleUvPOuqT4zGyyZB7P3kN+bwj1xMiXdIaklrGHFTiE=",
+          "key": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEHblsqctplMVc5ramA7vSuNxUQxcomQwGAVAdnWTAWUYr3MgDHQW0LagJ95lB7QT75Ve6JgT2EVLOFGU7L3YrwA==",
+          "url": "https://ct.googleapis.com/logs/us1/argon2026h2/",
+          "mmd": 86400,
+          "state": {"usable": {"timestamp": "2025-01-01T00:00:00Z"}},
+          "temporal_interval": {"start_inclusive": "2026-07-01T00:00:00Z", "end_exclusive": "2027-01-01T00:00:00Z"}
+        },
+        {
+          "description": "Google 'Argon2022' log",
+          "log_id": "KXm+8J45OSHwVnOfY6V35b5XfZxgCvj5TV0mXCVdx4Q=",
+          "key": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEeIPc6fGmuBg6AJkv/z7NFckmHvf/OqmjchZJ6wm2qN200keRDg352dWpi7CHnSV51BpQYAj1CQY5JuRAwrrDwg==",
+          "url": "https://ct.googleapis.com/logs/argon2022/",
+          "mmd": 86400,
+          "state": {"retired": {"timestamp": "2023-02-15T00:00:00Z"}},
+          "temporal_interval": {"start_inclusive": "2022-01-01T00:00:00Z", "end_exclusive": "2023-01-01T00:00:00Z"}
+        }
+      ]
+    },
+    {
+      "name": "Sectigo",
+      "email": ["ctops@sectigo.com"],
+      "logs": [
+        {
+          "description": "Sectigo 'Sabre' CT log",
+          "log_id": "VYHUwhaQNgFK6gubVzxT8MDkOHhwJQgXL6OqHQcT0ww=",
+          "key": "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE8m/SiQ8/xfiHHqtls9m7FyOMBg4JVZY9CgiixXGz0akvKD6DEL8S0ERmFe9U4ZiA0M4kbT5nmuk3I85Sk4bagA==",
+          "url": "https://sabre.ct.comodo.com/",
+          "mmd": 86400,
+          "state": {"readonly": {"timestamp": "2025-04-01T00:00:00Z", "final_tree_head": {"sha256_root_hash": "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=", "tree_size": 10}}}
+        }
+      ]
+    }
+  ]
+}`
+
+func TestParseLogList(t *testing.T) {
+
+	l, err := ParseLogList([]byte(testLogList))
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if l.Version != "1.2" || len(l.Operators) != 2 || len(l.Logs) != 3 {
+		t.Fatalf("FAIL: invalid list: %#v\n", l)
+	}
+
+	argon := l.ByName("argon2026H2")
+	if argon == nil {
+		t.Fatalf("FAIL: Argon2026h2 not found\n")
+	}
+
+	if argon.Operator != "Google" || argon.State != StateUsable || argon.MMD != 86400 || argon.StartInclusive.Year() != 2026 {
+		t.Fatalf("FAIL: invalid log: %#v\n", argon)
+	}
+
+	if l.ByName("Sabre").State != StateReadOnly || l.ByURI("https://ct.googleapis.com/logs/argon2022") == nil {
+		t.Fatalf("FAIL: failed to find logs\n")
+	}
+
+	// The log ID must be the hash of the key
+	id, err := argon.ID()
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if l.ByLogID(argon.LogID) != argon || id == [sha256.Size]byte{} {
+		t.Fatalf("FAIL: invalid log ID\n")
+	}
+
+	if _, err := argon.PublicKey(); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if r := l.Usable(); len(r.Logs) != 1 || r.Logs[0].Name != "Argon2026h2" {
+		t.Fatalf("FAIL: invalid usable logs: %#v\n", r.Logs)
+	}
+
+	expiry := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	// Sabre is not sharded
+	if r := l.ForExpiry(expiry); len(r.Logs) != 2 || r.Logs[0].Name != "Argon2026h2" || r.Logs[1].Name != "Sabre" {
+		t.Fatalf("FAIL: invalid logs for expiry: %#v\n", r.Logs)
+	}
+
+	if r := l.Usable().ForExpiry(expiry.AddDate(1, 0, 0)); len(r.Logs) != 0 {
+		t.Fatalf("FAIL: invalid logs for expiry: %#v\n", r.Logs)
+	}
+}
+
+func TestFetchLogList(t *testing.T) {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	hash := sha256.Sum256([]byte(testLogList))
+
+	sig, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/log_list.json", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(testLogList)) })
+	mux.HandleFunc("/log_list.sig", func(w http.ResponseWriter, r *http.Request) { w.Write(sig) })
+	mux.HandleFunc("/log_list_pubkey.pem", func(w http.ResponseWriter, r *http.Request) {
+		pem.Encode(w, &pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	pub, err := FetchLogListKey(context.Background(), srv.Client(), srv.URL+"/log_list_pubkey.pem")
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	l, err := FetchLogList(srv.URL+"/log_list.json", srv.URL+"/log_list.sig", pub)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if len(l.Logs) != 3 {
+		t.Fatalf("FAIL: want 3 logs, got %d\n", len(l.Logs))
+	}
+
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	// Invalid signature must fall back to the static list
+	l, err = LoadLogList(srv.URL+"/log_list.json", srv.URL+"/log_list.sig", other.Public())
+	if err == nil {
+		t.Fatalf("FAIL: invalid signature accepted\n")
+	}
+
+	if len(l.Logs) != len(Logs) || l.ByName("Argon2022").State != StateRetired || l.ByName("Argon2022").LogID == "" {
+		t.Fatalf("FAIL: invalid fallback list\n")
+	}
+
+	// The static list must not select outdated shards
+	if r := l.Usable(); len(r.Logs) != 0 {
+		t.Fatalf("FAIL: want 0 usable logs in the static list, got %d\n", len(r.Logs))
+	}
+
+	if r := l.ForExpiry(time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)); len(r.Logs) != 1 || r.Logs[0].Name != "Sabre" {
+		t.Fatalf("FAIL: invalid logs for expiry in the static list: %v\n", r.Logs)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err = FetchLogListContext(ctx, srv.Client(), srv.URL+"/log_list.json", "", nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("FAIL: want context.Canceled, got %v\n", err)
+	}
+}
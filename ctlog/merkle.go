@@ -0,0 +1,159 @@
+package ctlog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+)
+
+// LeafHash returns the Merkle tree hash of the leaf (RFC 6962 section 2.1).
+// leaf is the TLS encoded MerkleTreeLeaf (the leaf_input of get-entries).
+func LeafHash(leaf []byte) [sha256.Size]byte {
+
+	return sha256.Sum256(append([]byte{0x00}, leaf...))
+}
+
+// NodeHash returns the Merkle tree hash of the interior node with children l and r (RFC 6962 section 2.1).
+func NodeHash(l, r []byte) [sha256.Size]byte {
+
+	b := make([]byte, 0, 1+len(l)+len(r))
+	b = append(b, 0x01)
+	b = append(b, l...)
+	b = append(b, r...)
+
+	return sha256.Sum256(b)
+}
+
+// VerifyInclusion verifies the inclusion proof of the leaf with hash leafHash at index in the tree with size and root
+// (RFC 9162 section 2.1.3.2).
+//
+// Returns ErrInvalidProof if the proof is invalid.
+func VerifyInclusion(index, size uint64, leafHash, root []byte, proof [][]byte) error {
+
+	if index >= size {
+		return fmt.Errorf("%w: index %d is out of range of tree size %d", ErrInvalidProof, index, size)
+	}
+
+	fn, sn := index, size-1
+	r := leafHash
+
+	for _, p := range proof {
+
+		if sn == 0 {
+			return fmt.Errorf("%w: proof is too long", ErrInvalidProof)
+		}
+
+		if fn&1 == 1 || fn == sn {
+
+			h := NodeHash(p, r)
+			r = h[:]
+
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+
+		} else {
+			h := NodeHash(r, p)
+			r = h[:]
+		}
+
+		fn >>= 1
+		sn >>= 1
+	}
+
+	if sn != 0 {
+		return fmt.Errorf("%w: proof is too short", ErrInvalidProof)
+	}
+
+	if !bytes.Equal(r, root) {
+		return fmt.Errorf("%w: root hash mismatch", ErrInvalidProof)
+	}
+
+	return nil
+}
+
+// isPowerOf2 returns whether n is an exact power of 2.
+func isPowerOf2(n uint64) bool {
+
+	return n != 0 && n&(n-1) == 0
+}
+
+// VerifyConsistency verifies the consistency proof between the tree with size1 and root1 and
+// the tree with size2 and root2 (RFC 9162 section 2.1.4.2).
+//
+// An empty tree is consistent with every tree.
+//
+// Returns ErrInvalidProof if the proof is invalid.
+func VerifyConsistency(size1, size2 uint64, root1, root2 []byte, proof [][]byte) error {
+
+	switch {
+	case size1 > size2:
+		return fmt.Errorf("%w: tree size %d is larger than %d", ErrInvalidProof, size1, size2)
+	case size1 == size2:
+		if len(proof) != 0 {
+			return fmt.Errorf("%w: proof must be empty for equal trees", ErrInvalidProof)
+		}
+		if !bytes.Equal(root1, root2) {
+			return fmt.Errorf("%w: root hash mismatch of equal trees", ErrInvalidProof)
+		}
+		return nil
+	case size1 == 0:
+		if len(proof) != 0 {
+			return fmt.Errorf("%w: proof must be empty for empty tree", ErrInvalidProof)
+		}
+		return nil
+	case len(proof) == 0:
+		return fmt.Errorf("%w: empty proof", ErrInvalidProof)
+	}
+
+	if isPowerOf2(size1) {
+		proof = append([][]byte{root1}, proof...)
+	}
+
+	fn, sn := size1-1, size2-1
+
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+
+	fr, sr := proof[0], proof[0]
+
+	for _, c := range proof[1:] {
+
+		if sn == 0 {
+			return fmt.Errorf("%w: proof is too long", ErrInvalidProof)
+		}
+
+		if fn&1 == 1 || fn == sn {
+
+			hf := NodeHash(c, fr)
+			hs := NodeHash(c, sr)
+			fr, sr = hf[:], hs[:]
+
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+
+		} else {
+			h := NodeHash(sr, c)
+			sr = h[:]
+		}
+
+		fn >>= 1
+		sn >>= 1
+	}
+
+	switch {
+	case sn != 0:
+		return fmt.Errorf("%w: proof is too short", ErrInvalidProof)
+	case !bytes.Equal(fr, root1):
+		return fmt.Errorf("%w: first root hash mismatch", ErrInvalidProof)
+	case !bytes.Equal(sr, root2):
+		return fmt.Errorf("%w: second root hash mismatch", ErrInvalidProof)
+	}
+
+	return nil
+}
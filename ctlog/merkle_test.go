@@ -0,0 +1,165 @@
+package ctlog
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// The reference implementation of RFC 6962 section 2.1.
+
+// split returns the largest power of 2 smaller than n.
+func split(n int) int {
+
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+
+	return k
+}
+
+// mth returns the Merkle Tree Hash of the leaves.
+func mth(leaves [][]byte) []byte {
+
+	switch len(leaves) {
+	case 0:
+		h := sha256.Sum256(nil)
+		return h[:]
+	case 1:
+		h := LeafHash(leaves[0])
+		return h[:]
+	}
+
+	k := split(len(leaves))
+	h := NodeHash(mth(leaves[:k]), mth(leaves[k:]))
+
+	return h[:]
+}
+
+// path returns the audit path of the m. leaf.
+func path(m int, leaves [][]byte) [][]byte {
+
+	if len(leaves) <= 1 {
+		return nil
+	}
+
+	k := split(len(leaves))
+
+	if m < k {
+		return append(path(m, leaves[:k]), mth(leaves[k:]))
+	}
+
+	return append(path(m-k, leaves[k:]), mth(leaves[:k]))
+}
+
+// subproof returns the consistency proof between the first m leaves and the leaves.
+func subproof(m int, leaves [][]byte, b bool) [][]byte {
+
+	n := len(leaves)
+
+	if m == n {
+		if b {
+			return nil
+		}
+		return [][]byte{mth(leaves)}
+	}
+
+	k := split(n)
+
+	if m <= k {
+		return append(subproof(m, leaves[:k], b), mth(leaves[k:]))
+	}
+
+	return append(subproof(m-k, leaves[k:], false), mth(leaves[:k]))
+}
+
+func testLeaves(n int) [][]byte {
+
+	r := make([][]byte, n)
+
+	for i := range r {
+		r[i] = []byte(fmt.Sprintf("leaf-%d", i))
+	}
+
+	return r
+}
+
+func TestVerifyInclusion(t *testing.T) {
+
+	leaves := testLeaves(33)
+
+	for n := 1; n <= len(leaves); n++ {
+
+		root := mth(leaves[:n])
+
+		for m := 0; m < n; m++ {
+
+			hash := LeafHash(leaves[m])
+			proof := path(m, leaves[:n])
+
+			if err := VerifyInclusion(uint64(m), uint64(n), hash[:], root, proof); err != nil {
+				t.Fatalf("FAIL: leaf %d in tree %d: %s\n", m, n, err)
+			}
+
+			// Wrong index
+			if err := VerifyInclusion(uint64(m+1), uint64(n), hash[:], root, proof); !errors.Is(err, ErrInvalidProof) {
+				t.Fatalf("FAIL: leaf %d in tree %d verified at wrong index\n", m, n)
+			}
+
+			// Modified proof
+			if len(proof) > 0 {
+
+				proof[0] = hash[:]
+
+				if err := VerifyInclusion(uint64(m), uint64(n), hash[:], root, proof); !errors.Is(err, ErrInvalidProof) {
+					t.Fatalf("FAIL: leaf %d in tree %d verified with modified proof\n", m, n)
+				}
+			}
+		}
+	}
+}
+
+func TestVerifyConsistency(t *testing.T) {
+
+	leaves := testLeaves(33)
+
+	for n := 1; n <= len(leaves); n++ {
+
+		root2 := mth(leaves[:n])
+
+		for m := 0; m <= n; m++ {
+
+			root1 := mth(leaves[:m])
+
+			var proof [][]byte
+			if m > 0 {
+				proof = subproof(m, leaves[:n], true)
+			}
+
+			if err := VerifyConsistency(uint64(m), uint64(n), root1, root2, proof); err != nil {
+				t.Fatalf("FAIL: %d -> %d: %s\n", m, n, err)
+			}
+
+			if m == 0 || m == n {
+				continue
+			}
+
+			// Fork: the first tree has a different leaf
+			forked := mth(append(testLeaves(m-1), []byte("fork")))
+
+			if err := VerifyConsistency(uint64(m), uint64(n), forked, root2, proof); !errors.Is(err, ErrInvalidProof) {
+				t.Fatalf("FAIL: %d -> %d: forked tree verified\n", m, n)
+			}
+
+			if err := VerifyConsistency(uint64(m), uint64(n), root1, root2, proof[:len(proof)-1]); !errors.Is(err, ErrInvalidProof) {
+				t.Fatalf("FAIL: %d -> %d: short proof verified\n", m, n)
+			}
+		}
+	}
+
+	if err := VerifyConsistency(5, 5, mth(leaves[:5]), mth(leaves[:6]), nil); !errors.Is(err, ErrInvalidProof) {
+		t.Fatalf("FAIL: equal trees with different roots verified\n")
+	}
+}
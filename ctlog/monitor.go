@@ -0,0 +1,134 @@
+package ctlog
+
+import (
+	"context"
+	"sync"
+)
+
+// Alert is a certificate that matched the Watchlist.
+type Alert struct {
+	Record  Record
+	Matches []Match
+}
+
+// Monitor matches the log entries against a Watchlist and emits an Alert for every matching certificate.
+//
+// The precert and the final form of a certificate are reported only once, the first logged form is emitted.
+// The TBSHash of the last alerts are remembered (see SetDedupSize()), so the same certificate logged to
+// multiple logs is reported only once too, if the entries are sent to the same Monitor.
+//
+// A Monitor is safe for concurrent use.
+type Monitor struct {
+	watchlist *Watchlist
+	seen      map[string]struct{}
+	order     []string // TBSHashes in the order of insertion, used as a ring buffer
+	next      int      // Index of the oldest hash in order
+	size      int
+	m         *sync.Mutex
+}
+
+// NewMonitor creates a Monitor with watchlist w.
+// The default dedup size is 100000.
+func NewMonitor(w *Watchlist) *Monitor {
+
+	return &Monitor{watchlist: w, seen: make(map[string]struct{}), size: 100000, m: new(sync.Mutex)}
+}
+
+// SetDedupSize sets the number of the last reported certificates to remember.
+// The remembered certificates are cleared.
+func (m *Monitor) SetDedupSize(n int) {
+
+	if n < 1 {
+		n = 1
+	}
+
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	m.size = n
+	m.seen = make(map[string]struct{})
+	m.order = nil
+	m.next = 0
+}
+
+// remember saves hash and returns whether hash is new.
+func (m *Monitor) remember(hash string) bool {
+
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	if _, ok := m.seen[hash]; ok {
+		return false
+	}
+
+	if len(m.order) < m.size {
+		m.order = append(m.order, hash)
+	} else {
+		delete(m.seen, m.order[m.next])
+		m.order[m.next] = hash
+		m.next = (m.next + 1) % m.size
+	}
+
+	m.seen[hash] = struct{}{}
+
+	return true
+}
+
+// Check matches r against the watchlist.
+// Returns nil if r does not match or r is already reported (eg.: the final certificate of a reported precert).
+func (m *Monitor) Check(r *Record) *Alert {
+
+	matches := m.watchlist.MatchRecord(r)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	if !m.remember(r.TBSHash) {
+		return nil
+	}
+
+	return &Alert{Record: *r, Matches: matches}
+}
+
+// Run reads the entries until the channel is closed and sends the alerts to alerts.
+// The alerts channel is closed when Run returns.
+// The invalid entries (eg.: failed to parse the certificate) are skipped.
+//
+// Run returns nil if entries is closed, or ctx.Err() if ctx is done.
+func (m *Monitor) Run(ctx context.Context, entries <-chan Entry, alerts chan<- Alert) error {
+
+	defer close(alerts)
+
+	for {
+
+		var (
+			e  Entry
+			ok bool
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case e, ok = <-entries:
+			if !ok {
+				return nil
+			}
+		}
+
+		r, err := e.Record()
+		if err != nil {
+			continue
+		}
+
+		a := m.Check(r)
+		if a == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case alerts <- *a:
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package ctlog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gct "github.com/google/certificate-transparency-go"
+)
+
+func TestWatchlist(t *testing.T) {
+
+	w := NewWatchlist()
+
+	w.AddExact("WWW.Example.com.")
+
+	if err := w.AddDomain("sub.elmasy.com"); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if err := w.AddDomain("co.uk"); err == nil {
+		t.Fatalf("FAIL: public suffix added as domain\n")
+	}
+
+	w.AddSuffix(".example.org")
+	w.AddKeyword("paypal")
+
+	if err := w.AddRegex(`^login[0-9]+\.`); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if err := w.AddRegex(`(`); err == nil {
+		t.Fatalf("FAIL: invalid regex added\n")
+	}
+
+	if w.Len() != 5 {
+		t.Fatalf("FAIL: want 5 patterns, got %d\n", w.Len())
+	}
+
+	cases := []struct {
+		Name string
+		Type MatchType
+	}{
+		{Name: "www.example.com", Type: MatchExact},
+		{Name: "elmasy.com", Type: MatchDomain},
+		{Name: "*.a.b.elmasy.com", Type: MatchDomain},
+		{Name: "a.example.org", Type: MatchSuffix},
+		{Name: "paypal-secure.example.net", Type: MatchKeyword},
+		{Name: "login12.example.net", Type: MatchRegex},
+		{Name: "example.org", Type: ""},
+		{Name: "notelmasy.com", Type: ""},
+		{Name: "example.com", Type: ""},
+	}
+
+	for _, c := range cases {
+
+		m := w.Match(c.Name)
+
+		if c.Type == "" {
+			if len(m) != 0 {
+				t.Fatalf("FAIL: %s matched: %v\n", c.Name, m)
+			}
+			continue
+		}
+
+		if len(m) != 1 || m[0].Type != c.Type {
+			t.Fatalf("FAIL: %s: want %s, got %v\n", c.Name, c.Type, m)
+		}
+	}
+}
+
+func TestMonitor(t *testing.T) {
+
+	l, log := newTestServer(t, 0)
+
+	tmpl := l.Template("elmasy.com", "www.elmasy.com")
+
+	// The precert and the final form, an invalid entry and some unrelated certificates
+	if _, _, err := l.AddPrecert(tmpl); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if _, _, err := l.AddCert(tmpl); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	l.AddEntry(gct.LeafEntry{LeafInput: []byte("invalid")})
+
+	for i := 0; i < 3; i++ {
+		if _, _, err := l.AddCert(l.Template("example.com")); err != nil {
+			t.Fatalf("FAIL: %s\n", err)
+		}
+	}
+
+	f, err := NewFollower(log.URI, nil)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	f.SetPollInterval(10 * time.Millisecond)
+
+	w := NewWatchlist()
+	w.AddKeyword("elmasy")
+
+	m := NewMonitor(w)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	entries := make(chan Entry)
+	alerts := make(chan Alert, 10)
+
+	go f.Run(ctx, entries)
+	go m.Run(ctx, entries, alerts)
+
+	// The Monitor processes the entries in order, so every matching entry is processed when the last one is received
+	for f.Checkpoint() < int64(l.Len()) && ctx.Err() == nil {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+
+	var r []Alert
+	for a := range alerts {
+		r = append(r, a)
+	}
+
+	if len(r) != 1 {
+		t.Fatalf("FAIL: want 1 alert, got %d\n", len(r))
+	}
+
+	if r[0].Record.Type != EntryPrecert || r[0].Record.Index != 0 || r[0].Record.Issuer != "CN=cttest Root,O=cttest" {
+		t.Fatalf("FAIL: invalid record: %#v\n", r[0].Record)
+	}
+
+	// elmasy.com and www.elmasy.com, the CN is not duplicated
+	if len(r[0].Matches) != 2 || r[0].Matches[0].Name != "elmasy.com" || r[0].Matches[1].Name != "www.elmasy.com" {
+		t.Fatalf("FAIL: invalid matches: %v\n", r[0].Matches)
+	}
+
+	// The dedup forgets the oldest certificate
+	m.SetDedupSize(1)
+
+	if m.Check(&Record{TBSHash: "a", CommonName: "elmasy.com"}) == nil {
+		t.Fatalf("FAIL: new certificate not reported\n")
+	}
+
+	if m.Check(&Record{TBSHash: "b", CommonName: "elmasy.com"}) == nil {
+		t.Fatalf("FAIL: new certificate not reported\n")
+	}
+
+	if m.Check(&Record{TBSHash: "a", CommonName: "elmasy.com"}) == nil {
+		t.Fatalf("FAIL: forgotten certificate not reported\n")
+	}
+
+	if m.Check(&Record{TBSHash: "a", CommonName: "elmasy.com"}) != nil {
+		t.Fatalf("FAIL: duplicate reported\n")
+	}
+}
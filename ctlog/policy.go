@@ -0,0 +1,211 @@
+package ctlog
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	gct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/x509"
+)
+
+// ErrPolicyNotMet is returned if the SCTs do not comply with a CT policy.
+var ErrPolicyNotMet = errors.New("CT policy not met")
+
+// SCTSource is the delivery path of an SCT.
+type SCTSource string
+
+const (
+	SourceEmbedded SCTSource = "embedded" // Embedded in the certificate
+	SourceTLS      SCTSource = "tls"      // The signed_certificate_timestamp TLS extension
+	SourceOCSP     SCTSource = "ocsp"     // The stapled OCSP response
+)
+
+// SCTStatus is the result of the verification of an SCT.
+type SCTStatus struct {
+	Source SCTSource
+	SCT    *gct.SignedCertificateTimestamp
+	Log    *Log  // The log that issued the SCT, nil if the log is not in the list
+	Valid  bool  // The signature is valid
+	Err    error // The reason of the failure, nil if Valid
+}
+
+// SCTReport is the result of the verification of the SCTs of a certificate.
+type SCTReport struct {
+	SCTs        []SCTStatus
+	Chrome      bool  // Complies with the CT policy of Chrome
+	ChromeError error // The reason of the non compliance, nil if Chrome is true
+	Apple       bool  // Complies with the CT policy of Apple
+	AppleError  error // The reason of the non compliance, nil if Apple is true
+}
+
+// byKeyID returns the log with id from the list.
+// Returns nil if the log is not found.
+func (l *LogList) byKeyID(id [32]byte) *Log {
+
+	for i := range l.Logs {
+		if lid, err := l.Logs[i].ID(); err == nil && lid == id {
+			return &l.Logs[i]
+		}
+	}
+
+	return nil
+}
+
+// VerifySCTs verifies the SCTs of the certificate chain against the logs of the list and checks the compliance with the Chrome and Apple CT policies.
+// chain is the DER encoded chain of the server, the certificate is the first followed by its issuer.
+// The embedded SCTs are parsed from the certificate, tlsSCTs and ocspSCTs are the SCTs delivered in the TLS extension and in the stapled OCSP response.
+//
+// Returns error only if the certificate can not be parsed.
+func (l *LogList) VerifySCTs(chain [][]byte, tlsSCTs, ocspSCTs []*gct.SignedCertificateTimestamp) (*SCTReport, error) {
+
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("empty chain")
+	}
+
+	cert, err := x509.ParseCertificate(chain[0])
+	if x509.IsFatal(err) {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+
+	embedded, err := EmbeddedSCTs(chain[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse embedded SCTs: %w", err)
+	}
+
+	r := new(SCTReport)
+
+	for _, src := range []struct {
+		source SCTSource
+		scts   []*gct.SignedCertificateTimestamp
+	}{{SourceEmbedded, embedded}, {SourceTLS, tlsSCTs}, {SourceOCSP, ocspSCTs}} {
+
+		for _, sct := range src.scts {
+
+			s := SCTStatus{Source: src.source, SCT: sct, Log: l.byKeyID(sct.LogID.KeyID)}
+
+			switch {
+			case s.Log == nil:
+				s.Err = fmt.Errorf("unknown log: %x", sct.LogID.KeyID)
+			case src.source == SourceEmbedded:
+				s.Err = VerifyEmbeddedSCT(*s.Log, sct, chain)
+			default:
+				s.Err = VerifySCT(*s.Log, sct, EntryX509, chain[:1])
+			}
+
+			s.Valid = s.Err == nil
+
+			r.SCTs = append(r.SCTs, s)
+		}
+	}
+
+	r.ChromeError = chromePolicy(r.SCTs, cert.NotAfter.Sub(cert.NotBefore))
+	r.Chrome = r.ChromeError == nil
+
+	r.AppleError = applePolicy(r.SCTs, cert.NotAfter.Sub(cert.NotBefore))
+	r.Apple = r.AppleError == nil
+
+	return r, nil
+}
+
+// requiredSCTs returns the number of the required embedded SCTs for a certificate with lifetime.
+func requiredSCTs(lifetime time.Duration) int {
+
+	if lifetime <= 180*24*time.Hour {
+		return 2
+	}
+
+	return 3
+}
+
+// count returns the number of the distinct logs and operators of the valid SCTs that delivered via the embedded (if embedded is true)
+// or the TLS and OCSP paths and issued by logs in states.
+func count(scts []SCTStatus, embedded bool, states ...LogState) (int, int) {
+
+	logs := make(map[string]struct{})
+	ops := make(map[string]struct{})
+
+	for i := range scts {
+
+		if !scts[i].Valid || (scts[i].Source == SourceEmbedded) != embedded {
+			continue
+		}
+
+		for _, s := range states {
+			if scts[i].Log.State == s {
+				logs[scts[i].Log.URI] = struct{}{}
+				ops[operator(*scts[i].Log)] = struct{}{}
+				break
+			}
+		}
+	}
+
+	return len(logs), len(ops)
+}
+
+// checkPath checks the SCTs of a delivery path.
+func checkPath(scts []SCTStatus, embedded bool, n int, states ...LogState) error {
+
+	path := "TLS/OCSP"
+	if embedded {
+		path = "embedded"
+	}
+
+	logs, ops := count(scts, embedded, states...)
+
+	switch {
+	case logs < n:
+		return fmt.Errorf("%d valid %s SCT(s), %d required", logs, path, n)
+	case ops < 2:
+		return fmt.Errorf("%d operator(s) of the %s SCTs, 2 required", ops, path)
+	default:
+		return nil
+	}
+}
+
+// chromePolicy checks the compliance with the Chrome CT policy (https://googlechrome.github.io/CertificateTransparency/ct_policy.html).
+//
+// Embedded SCTs: 2 (lifetime <= 180 days) or 3 SCTs from Qualified, Usable, ReadOnly or Retired logs of at least 2 operators.
+// TLS and OCSP SCTs: 2 SCTs from Qualified, Usable or ReadOnly logs of at least 2 operators.
+//
+// The time of the retirement is not checked, every SCT of a Retired log is accepted.
+func chromePolicy(scts []SCTStatus, lifetime time.Duration) error {
+
+	embErr := checkPath(scts, true, requiredSCTs(lifetime), StateQualified, StateUsable, StateReadOnly, StateRetired)
+	if embErr == nil {
+		return nil
+	}
+
+	tlsErr := checkPath(scts, false, 2, StateQualified, StateUsable, StateReadOnly)
+	if tlsErr == nil {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s, %s", ErrPolicyNotMet, embErr, tlsErr)
+}
+
+// applePolicy checks the compliance with the Apple CT policy (https://support.apple.com/en-us/103214).
+//
+// Embedded SCTs: 2 (lifetime <= 180 days) or 3 SCTs from once approved logs of at least 2 operators,
+// at least one from a currently approved log.
+// TLS and OCSP SCTs: 2 SCTs from currently approved logs of at least 2 operators.
+func applePolicy(scts []SCTStatus, lifetime time.Duration) error {
+
+	embErr := checkPath(scts, true, requiredSCTs(lifetime), StateQualified, StateUsable, StateReadOnly, StateRetired)
+	if embErr == nil {
+		if n, _ := count(scts, true, StateQualified, StateUsable, StateReadOnly); n == 0 {
+			embErr = fmt.Errorf("no embedded SCT from currently approved log")
+		}
+	}
+
+	if embErr == nil {
+		return nil
+	}
+
+	tlsErr := checkPath(scts, false, 2, StateQualified, StateUsable, StateReadOnly)
+	if tlsErr == nil {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s, %s", ErrPolicyNotMet, embErr, tlsErr)
+}
@@ -0,0 +1,125 @@
+package ctlog
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gct "github.com/google/certificate-transparency-go"
+)
+
+func TestVerifySCTs(t *testing.T) {
+
+	ca, a := newTestServer(t, 0)
+
+	b, bLog := newTestServer(t, 0)
+	b.AddRoot(ca.Root())
+
+	c, cLog := newTestServer(t, 0)
+	c.AddRoot(ca.Root())
+
+	for _, l := range []*Log{&a, &bLog, &cLog} {
+		l.State = StateUsable
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tmpl := ca.Template("elmasy.com")
+
+	precert, err := ca.IssuePrecert(tmpl)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	scts, err := Submit(ctx, []Log{a, bLog}, EntryPrecert, [][]byte{precert, ca.Root().Raw}, 2)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	ext, err := SCTListExtension(scts)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	tmpl.ExtraExtensions = append(tmpl.ExtraExtensions, ext)
+
+	final, err := ca.Sign(tmpl)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	chain := [][]byte{final, ca.Root().Raw}
+
+	list := &LogList{Logs: []Log{a, bLog, cLog}}
+
+	r, err := list.VerifySCTs(chain, nil, nil)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if len(r.SCTs) != 2 || !r.SCTs[0].Valid || !r.SCTs[1].Valid || r.SCTs[0].Source != SourceEmbedded {
+		t.Fatalf("FAIL: invalid embedded SCTs: %#v\n", r.SCTs)
+	}
+
+	if !r.Chrome || !r.Apple {
+		t.Fatalf("FAIL: want compliant, got Chrome: %v, Apple: %v\n", r.ChromeError, r.AppleError)
+	}
+
+	// Embedded SCTs from retired logs only
+	list.Logs[0].State = StateRetired
+	list.Logs[1].State = StateRetired
+
+	if r, err = list.VerifySCTs(chain, nil, nil); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if !r.Chrome {
+		t.Fatalf("FAIL: want Chrome compliant, got %v\n", r.ChromeError)
+	}
+
+	if r.Apple || !errors.Is(r.AppleError, ErrPolicyNotMet) {
+		t.Fatalf("FAIL: want Apple non compliant, got %v\n", r.AppleError)
+	}
+
+	// SCTs delivered in TLS and OCSP from currently approved logs
+	tlsSCT, err := AddChain(ctx, cLog, chain)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	ocspSCT, err := AddChain(ctx, bLog, chain)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	list.Logs[1].State = StateUsable
+
+	if r, err = list.VerifySCTs(chain, []*gct.SignedCertificateTimestamp{tlsSCT}, []*gct.SignedCertificateTimestamp{ocspSCT}); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if len(r.SCTs) != 4 || r.SCTs[2].Source != SourceTLS || !r.SCTs[2].Valid || r.SCTs[3].Source != SourceOCSP || !r.SCTs[3].Valid {
+		t.Fatalf("FAIL: invalid TLS/OCSP SCTs: %#v\n", r.SCTs)
+	}
+
+	if !r.Chrome || !r.Apple {
+		t.Fatalf("FAIL: want compliant, got Chrome: %v, Apple: %v\n", r.ChromeError, r.AppleError)
+	}
+
+	// The log of the first SCT is unknown
+	list.Logs = list.Logs[1:]
+
+	if r, err = list.VerifySCTs(chain, nil, nil); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if r.SCTs[0].Valid || r.SCTs[0].Log != nil || r.SCTs[0].Err == nil {
+		t.Fatalf("FAIL: SCT of unknown log is valid\n")
+	}
+
+	if r.Chrome || r.Apple {
+		t.Fatalf("FAIL: want non compliant\n")
+	}
+}
@@ -0,0 +1,232 @@
+package ctlog
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Appearance is a log entry of an issuance.
+type Appearance struct {
+	Log       string    // URI of the log
+	Index     int64     // Index of the entry in the log
+	Type      EntryType // The precert or the final certificate
+	Timestamp time.Time // Timestamp of the entry
+}
+
+// Issuance is a logical certificate issuance, the precertificate and the final certificate linked by the TBSHash.
+type Issuance struct {
+	TBSHash     string
+	Precert     *Record       // The first logged precertificate, nil if not seen
+	Final       *Record       // The first logged final certificate, nil if not seen
+	Appearances []Appearance  // Every log entry of the issuance, in the order of processing
+	Delay       time.Duration // Time between the first precert and the first final certificate entry, 0 if any of them is not seen
+}
+
+// update adds the record r of log to i.
+// Returns false if r is already added.
+func (i *Issuance) update(log string, r *Record) bool {
+
+	for _, a := range i.Appearances {
+		if a.Log == log && a.Index == r.Index {
+			return false
+		}
+	}
+
+	i.Appearances = append(i.Appearances, Appearance{Log: log, Index: r.Index, Type: r.Type, Timestamp: r.Timestamp})
+
+	switch r.Type {
+	case EntryPrecert:
+		if i.Precert == nil || r.Timestamp.Before(i.Precert.Timestamp) {
+			c := *r
+			i.Precert = &c
+		}
+	case EntryX509:
+		if i.Final == nil || r.Timestamp.Before(i.Final.Timestamp) {
+			c := *r
+			i.Final = &c
+		}
+	}
+
+	if i.Precert != nil && i.Final != nil {
+		i.Delay = i.Final.Timestamp.Sub(i.Precert.Timestamp)
+	}
+
+	return true
+}
+
+// first returns the time of the first appearance.
+func (i *Issuance) first() time.Time {
+
+	var t time.Time
+
+	for _, a := range i.Appearances {
+		if t.IsZero() || a.Timestamp.Before(t) {
+			t = a.Timestamp
+		}
+	}
+
+	return t
+}
+
+// Reconciler links the precertificates to their final certificates and the entries of the same certificate in multiple logs.
+// The entries are grouped by the hash of the TBSCertificate without the poison and the SCT list (Record.TBSHash).
+//
+// An issuance is emitted when the newest processed entry is later than the first appearance of the issuance plus the window (see SetWindow()).
+// The time is based on the timestamps of the entries, not on the wall clock, so processing old entries works too.
+// An appearance processed after the issuance is emitted starts a new issuance.
+//
+// A Reconciler is safe for concurrent use, the entries of multiple logs can be added at the same time.
+type Reconciler struct {
+	pending map[string]*Issuance
+	order   []string // TBSHashes in the order of the first appearance
+	window  time.Duration
+	latest  time.Time // Timestamp of the newest processed entry
+	m       *sync.Mutex
+}
+
+// NewReconciler creates a Reconciler.
+// The default window is 24 hours (the usual Maximum Merge Delay of the logs).
+func NewReconciler() *Reconciler {
+
+	return &Reconciler{pending: make(map[string]*Issuance), window: 24 * time.Hour, m: new(sync.Mutex)}
+}
+
+// SetWindow sets the time to wait for the other appearances of an issuance.
+func (c *Reconciler) SetWindow(d time.Duration) {
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.window = d
+}
+
+// Len returns the number of pending issuances.
+func (c *Reconciler) Len() int {
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return len(c.pending)
+}
+
+// expired removes and returns the pending issuances that are older than the window, in the order of the first appearance.
+// Every pending issuance is checked, because a later added issuance may have an older first appearance (eg.: entries of a slower log).
+func (c *Reconciler) expired() []Issuance {
+
+	var r []Issuance
+
+	order := c.order[:0]
+
+	for _, h := range c.order {
+
+		i := c.pending[h]
+
+		if c.latest.Sub(i.first()) <= c.window {
+			order = append(order, h)
+			continue
+		}
+
+		r = append(r, *i)
+
+		delete(c.pending, h)
+	}
+
+	c.order = order
+
+	return r
+}
+
+// Add adds the record r of log and returns the issuances that are complete (older than the window).
+func (c *Reconciler) Add(log string, r *Record) ([]Issuance, error) {
+
+	if r == nil {
+		return nil, fmt.Errorf("record is nil")
+	}
+
+	if r.TBSHash == "" {
+		return nil, fmt.Errorf("TBSHash of entry %d is empty", r.Index)
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	i, ok := c.pending[r.TBSHash]
+	if !ok {
+		i = &Issuance{TBSHash: r.TBSHash}
+		c.pending[r.TBSHash] = i
+		c.order = append(c.order, r.TBSHash)
+	}
+
+	i.update(log, r)
+
+	if r.Timestamp.After(c.latest) {
+		c.latest = r.Timestamp
+	}
+
+	return c.expired(), nil
+}
+
+// Flush removes and returns every pending issuance, in the order of the first appearance.
+func (c *Reconciler) Flush() []Issuance {
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	r := make([]Issuance, 0, len(c.order))
+
+	for _, h := range c.order {
+		r = append(r, *c.pending[h])
+	}
+
+	c.pending = make(map[string]*Issuance)
+	c.order = nil
+
+	return r
+}
+
+// Run reads the entries of log until the channel is closed and sends the complete issuances to issuances.
+// The invalid entries (eg.: failed to parse the certificate) are skipped.
+//
+// The issuances channel is not closed, so Run can be called for multiple logs with the same Reconciler and channel.
+// The pending issuances are not sent when Run returns, use Flush() to get them.
+//
+// Run returns nil if entries is closed, or ctx.Err() if ctx is done.
+func (c *Reconciler) Run(ctx context.Context, log string, entries <-chan Entry, issuances chan<- Issuance) error {
+
+	for {
+
+		var (
+			e  Entry
+			ok bool
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case e, ok = <-entries:
+			if !ok {
+				return nil
+			}
+		}
+
+		r, err := e.Record()
+		if err != nil {
+			continue
+		}
+
+		done, err := c.Add(log, r)
+		if err != nil {
+			continue
+		}
+
+		for i := range done {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case issuances <- done[i]:
+			}
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package ctlog
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReconciler(t *testing.T) {
+
+	a, aLog := newTestServer(t, 0)
+
+	b, bLog := newTestServer(t, 0)
+	b.AddRoot(a.Root())
+
+	tmpl := a.Template("elmasy.com")
+
+	if _, _, err := a.AddPrecert(tmpl); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	_, final, err := a.AddCert(tmpl)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if _, _, err := a.AddCert(a.Template("example.com")); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := AddChain(ctx, bLog, [][]byte{final, a.Root().Raw}); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	aRecords, _, err := GetRecords(aLog.URI, 0)
+	if err != nil || len(aRecords) != 3 {
+		t.Fatalf("FAIL: want 3 records, got %d: %v\n", len(aRecords), err)
+	}
+
+	bRecords, _, err := GetRecords(bLog.URI, 0)
+	if err != nil || len(bRecords) != 1 {
+		t.Fatalf("FAIL: want 1 record, got %d: %v\n", len(bRecords), err)
+	}
+
+	// Set the timestamps to get a known delay
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	aRecords[0].Timestamp = t0
+	aRecords[1].Timestamp = t0.Add(2 * time.Minute)
+	aRecords[2].Timestamp = t0.Add(time.Minute)
+	bRecords[0].Timestamp = t0.Add(3 * time.Minute)
+
+	c := NewReconciler()
+
+	for _, v := range []struct {
+		log string
+		r   *Record
+	}{
+		{aLog.URI, &aRecords[0]},
+		{aLog.URI, &aRecords[1]},
+		{aLog.URI, &aRecords[2]},
+		{bLog.URI, &bRecords[0]},
+		{bLog.URI, &bRecords[0]},
+	} {
+		if done, err := c.Add(v.log, v.r); err != nil || len(done) != 0 {
+			t.Fatalf("FAIL: issuance emitted within the window: %v\n", err)
+		}
+	}
+
+	r := c.Flush()
+
+	if len(r) != 2 || c.Len() != 0 {
+		t.Fatalf("FAIL: want 2 issuances, got %d\n", len(r))
+	}
+
+	i := r[0]
+
+	if i.Precert == nil || i.Final == nil || i.Precert.Index != 0 || i.Final.Index != 1 || i.Final.Fingerprint != fingerprint(final) {
+		t.Fatalf("FAIL: invalid issuance: %#v\n", i)
+	}
+
+	if len(i.Appearances) != 3 || i.Appearances[2].Log != bLog.URI || i.Appearances[2].Type != EntryX509 {
+		t.Fatalf("FAIL: invalid appearances: %#v\n", i.Appearances)
+	}
+
+	if i.Delay != 2*time.Minute {
+		t.Fatalf("FAIL: want 2m delay, got %s\n", i.Delay)
+	}
+
+	if r[1].Precert != nil || r[1].Final == nil || r[1].Delay != 0 || len(r[1].Appearances) != 1 {
+		t.Fatalf("FAIL: invalid issuance: %#v\n", r[1])
+	}
+
+	if _, err := c.Add(aLog.URI, nil); err == nil {
+		t.Fatalf("FAIL: nil record added\n")
+	}
+
+	if _, err := c.Add(aLog.URI, &Record{Index: 4}); err == nil {
+		t.Fatalf("FAIL: record without TBSHash added\n")
+	}
+}
+
+func TestReconcilerExpired(t *testing.T) {
+
+	const log = "https://ct.elmasy.com/"
+
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	c := NewReconciler()
+	c.SetWindow(time.Hour)
+
+	for _, v := range []struct {
+		r    *Record
+		want []string // TBSHash of the expired issuances
+	}{
+		{&Record{Index: 0, TBSHash: "a", Type: EntryPrecert, Timestamp: t0.Add(30 * time.Minute)}, nil},
+		// Added later, but appeared earlier (eg.: a slower log)
+		{&Record{Index: 1, TBSHash: "b", Type: EntryPrecert, Timestamp: t0}, nil},
+		{&Record{Index: 2, TBSHash: "a", Type: EntryX509, Timestamp: t0.Add(32 * time.Minute)}, nil},
+		{&Record{Index: 3, TBSHash: "c", Type: EntryPrecert, Timestamp: t0.Add(time.Hour + 10*time.Minute)}, []string{"b"}},
+		{&Record{Index: 4, TBSHash: "d", Type: EntryPrecert, Timestamp: t0.Add(2 * time.Hour)}, []string{"a"}},
+		{&Record{Index: 5, TBSHash: "e", Type: EntryPrecert, Timestamp: t0.Add(3*time.Hour + time.Minute)}, []string{"c", "d"}},
+	} {
+
+		done, err := c.Add(log, v.r)
+		if err != nil {
+			t.Fatalf("FAIL: %s\n", err)
+		}
+
+		if len(done) != len(v.want) {
+			t.Fatalf("FAIL: entry %d: want %v expired, got %#v\n", v.r.Index, v.want, done)
+		}
+
+		for i := range done {
+			if done[i].TBSHash != v.want[i] {
+				t.Fatalf("FAIL: entry %d: want %v expired, got %#v\n", v.r.Index, v.want, done)
+			}
+		}
+	}
+
+	if c.Len() != 1 {
+		t.Fatalf("FAIL: want 1 pending issuance, got %d\n", c.Len())
+	}
+
+	r := c.Flush()
+
+	if len(r) != 1 || r[0].TBSHash != "e" {
+		t.Fatalf("FAIL: invalid pending issuances: %#v\n", r)
+	}
+}
+
+func TestReconcilerRun(t *testing.T) {
+
+	a, aLog := newTestServer(t, 0)
+
+	if _, _, err := a.AddCert(a.Template("elmasy.com")); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	f, err := NewFollower(aLog.URI, nil)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	fetched, err := f.fetch(ctx, 0, 1)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	c := NewReconciler()
+
+	// Expires when the entry of the log is processed
+	if _, err := c.Add(aLog.URI, &Record{Index: 10, TBSHash: "old", Type: EntryX509, Timestamp: time.Unix(0, 0)}); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	entries := make(chan Entry, 2)
+	entries <- Entry{Index: 1, Err: context.Canceled} // Invalid, skipped
+	entries <- fetched[0]
+	close(entries)
+
+	issuances := make(chan Issuance, 1)
+
+	if err := c.Run(ctx, aLog.URI, entries, issuances); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if len(issuances) != 1 {
+		t.Fatalf("FAIL: want 1 issuance, got %d\n", len(issuances))
+	}
+
+	if i := <-issuances; i.TBSHash != "old" {
+		t.Fatalf("FAIL: invalid issuance: %#v\n", i)
+	}
+
+	if c.Len() != 1 {
+		t.Fatalf("FAIL: want 1 pending issuance, got %d\n", c.Len())
+	}
+
+	cancel()
+
+	if err := c.Run(ctx, aLog.URI, make(chan Entry), issuances); err != context.Canceled {
+		t.Fatalf("FAIL: want context.Canceled, got %v\n", err)
+	}
+}
@@ -0,0 +1,200 @@
+package ctlog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/elmasy-com/elnet/dns"
+	"github.com/elmasy-com/slices"
+	gct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/client"
+	"github.com/google/certificate-transparency-go/jsonclient"
+	"github.com/google/certificate-transparency-go/x509"
+)
+
+type EntryType string
+
+const (
+	EntryX509    EntryType = "x509"    // Final certificate
+	EntryPrecert EntryType = "precert" // Precertificate
+)
+
+// Record is the parsed certificate of a log entry.
+type Record struct {
+	Index          int64     // Index of the entry in the log
+	Timestamp      time.Time // Timestamp of the entry (the time of the SCT)
+	Type           EntryType // Type of the entry
+	Fingerprint    string    // Hex encoded SHA-256 hash of the logged certificate (the submitted precertificate for precert entries)
+	TBSHash        string    // Hex encoded SHA-256 hash of the TBSCertificate without the poison and the SCT list, equal for the precert and the final certificate
+	Issuer         string    // Distinguished name of the issuer
+	Subject        string    // Distinguished name of the subject
+	CommonName     string    // Common name of the subject
+	DNSNames       []string  // DNS SANs
+	IPAddresses    []string  // IP SANs
+	EmailAddresses []string  // Email SANs
+	URIs           []string  // URI SANs
+	PermittedDNS   []string  // Permitted DNS domains of the name constraints
+	ExcludedDNS    []string  // Excluded DNS domains of the name constraints
+	NotBefore      time.Time
+	NotAfter       time.Time
+	Serial         string   // Hex encoded serial number
+	Chain          []string // Hex encoded SHA-256 hashes of the chain certificates, the issuer is the first
+}
+
+// fingerprint returns the hex encoded SHA-256 hash of data.
+func fingerprint(data []byte) string {
+
+	h := sha256.Sum256(data)
+
+	return hex.EncodeToString(h[:])
+}
+
+// ParseEntry parses the certificate of raw.
+//
+// The non fatal parse errors (eg.: non critical malformed extension) are ignored,
+// returns error only if the certificate can not be parsed.
+func ParseEntry(raw *gct.RawLogEntry) (*Record, error) {
+
+	if raw == nil {
+		return nil, fmt.Errorf("entry is nil")
+	}
+
+	e, err := raw.ToLogEntry()
+	if x509.IsFatal(err) || e == nil {
+		return nil, fmt.Errorf("failed to parse entry %d: %w", raw.Index, err)
+	}
+
+	r := &Record{
+		Index:       raw.Index,
+		Timestamp:   gct.TimestampToTime(raw.Leaf.TimestampedEntry.Timestamp),
+		Fingerprint: fingerprint(raw.Cert.Data),
+	}
+
+	var cert *x509.Certificate
+
+	switch {
+	case e.X509Cert != nil:
+
+		r.Type = EntryX509
+		cert = e.X509Cert
+
+		// The final certificate contains the SCTs, the precert does not
+		tbs, err := x509.RemoveSCTList(cert.RawTBSCertificate)
+		if err != nil {
+			tbs = cert.RawTBSCertificate
+		}
+
+		r.TBSHash = fingerprint(tbs)
+
+	case e.Precert != nil && e.Precert.TBSCertificate != nil:
+
+		r.Type = EntryPrecert
+		cert = e.Precert.TBSCertificate
+
+		// The TBSCertificate of the leaf is without the poison and with the final issuer
+		r.TBSHash = fingerprint(raw.Leaf.TimestampedEntry.PrecertEntry.TBSCertificate)
+
+	default:
+		return nil, fmt.Errorf("failed to parse entry %d: no certificate", raw.Index)
+	}
+
+	r.Issuer = cert.Issuer.String()
+	r.Subject = cert.Subject.String()
+	r.CommonName = cert.Subject.CommonName
+	r.DNSNames = cert.DNSNames
+	r.EmailAddresses = cert.EmailAddresses
+	r.PermittedDNS = cert.PermittedDNSDomains
+	r.ExcludedDNS = cert.ExcludedDNSDomains
+	r.NotBefore = cert.NotBefore
+	r.NotAfter = cert.NotAfter
+
+	for i := range cert.IPAddresses {
+		r.IPAddresses = append(r.IPAddresses, cert.IPAddresses[i].String())
+	}
+
+	for i := range cert.URIs {
+		r.URIs = append(r.URIs, cert.URIs[i].String())
+	}
+
+	if cert.SerialNumber != nil {
+		r.Serial = hex.EncodeToString(cert.SerialNumber.Bytes())
+	}
+
+	for i := range raw.Chain {
+		r.Chain = append(r.Chain, fingerprint(raw.Chain[i].Data))
+	}
+
+	return r, nil
+}
+
+// Record parses the certificate of e.
+func (e Entry) Record() (*Record, error) {
+
+	if e.Raw == nil {
+		return nil, fmt.Errorf("entry %d is invalid: %w", e.Index, e.Err)
+	}
+
+	return ParseEntry(e.Raw)
+}
+
+// Domains returns the unique and valid domains from the common name, the DNS SANs and the name constraints.
+func (r *Record) Domains() []string {
+
+	var d []string
+
+	if dns.IsValid(r.CommonName) {
+		d = slices.AppendUnique(d, r.CommonName)
+	}
+
+	for _, names := range [][]string{r.DNSNames, r.PermittedDNS, r.ExcludedDNS} {
+		for i := range names {
+			if dns.IsValid(names[i]) {
+				d = slices.AppendUnique(d, names[i])
+			}
+		}
+	}
+
+	return d
+}
+
+// GetRecords returns the records parsed from the log's certificates.
+// start is the start index and fetch as many log entries as possible with one query.
+// The returned int64 counts the number of parsed log entries.
+func GetRecords(url string, start int64) ([]Record, int64, error) {
+
+	c, err := client.New(url, http.DefaultClient, jsonclient.Options{})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	resp, err := c.GetRawEntries(context.TODO(), start, start+10000)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get raw entries: %w", err)
+	}
+
+	var n int64
+	r := make([]Record, 0, len(resp.Entries))
+
+	for i := range resp.Entries {
+
+		raw, err := gct.RawLogEntryFromLeaf(start+int64(i), &resp.Entries[i])
+		if err != nil {
+			return r, n, fmt.Errorf("failed to convert leaf entry: %w", err)
+		}
+
+		rec, err := ParseEntry(raw)
+		if err != nil {
+			return r, n, err
+		}
+
+		n++
+
+		r = append(r, *rec)
+	}
+
+	return r, n, nil
+}
@@ -0,0 +1,98 @@
+package ctlog
+
+import (
+	"encoding/hex"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestGetRecords(t *testing.T) {
+
+	l, log := newTestServer(t, 0)
+
+	u, _ := url.Parse("https://elmasy.com/")
+
+	tmpl := l.Template("elmasy.com", "www.elmasy.com", "invalid_domain")
+	tmpl.IPAddresses = []net.IP{net.ParseIP("192.0.2.1")}
+	tmpl.EmailAddresses = []string{"admin@elmasy.com"}
+	tmpl.URIs = []*url.URL{u}
+
+	// Entries before the start index
+	for i := 0; i < 5; i++ {
+		if _, _, err := l.AddCert(l.Template("example.com")); err != nil {
+			t.Fatalf("FAIL: %s\n", err)
+		}
+	}
+
+	_, precertDER, err := l.AddPrecert(tmpl)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	_, finalDER, err := l.AddCert(tmpl)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	records, n, err := GetRecords(log.URI, 5)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if n != 2 || len(records) != 2 {
+		t.Fatalf("FAIL: want 2 records, got %d (%d)\n", len(records), n)
+	}
+
+	pre, final := records[0], records[1]
+
+	if pre.Type != EntryPrecert || final.Type != EntryX509 {
+		t.Fatalf("FAIL: invalid types: %s, %s\n", pre.Type, final.Type)
+	}
+
+	if pre.Index != 5 || final.Index != 6 {
+		t.Fatalf("FAIL: invalid indexes: %d, %d\n", pre.Index, final.Index)
+	}
+
+	if time.Since(pre.Timestamp) > time.Minute {
+		t.Fatalf("FAIL: invalid timestamp: %s\n", pre.Timestamp)
+	}
+
+	if pre.TBSHash != final.TBSHash {
+		t.Fatalf("FAIL: TBSHash of the precert and the final certificate differs\n")
+	}
+
+	if pre.Fingerprint != fingerprint(precertDER) || final.Fingerprint != fingerprint(finalDER) {
+		t.Fatalf("FAIL: invalid fingerprint\n")
+	}
+
+	for _, r := range records {
+
+		if r.Issuer != "CN=cttest Root,O=cttest" || r.Subject != "CN=elmasy.com" || r.CommonName != "elmasy.com" {
+			t.Fatalf("FAIL: invalid names: %s, %s\n", r.Issuer, r.Subject)
+		}
+
+		if len(r.DNSNames) != 3 || len(r.IPAddresses) != 1 || r.IPAddresses[0] != "192.0.2.1" ||
+			len(r.EmailAddresses) != 1 || len(r.URIs) != 1 || r.URIs[0] != "https://elmasy.com/" {
+			t.Fatalf("FAIL: invalid SANs: %#v\n", r)
+		}
+
+		if r.Serial != hex.EncodeToString(tmpl.SerialNumber.Bytes()) || !r.NotAfter.Equal(tmpl.NotAfter) {
+			t.Fatalf("FAIL: invalid serial or validity: %#v\n", r)
+		}
+
+		if len(r.Chain) != 1 || r.Chain[0] != fingerprint(l.Root().Raw) {
+			t.Fatalf("FAIL: invalid chain: %v\n", r.Chain)
+		}
+	}
+
+	domains, n, err := GetDomains(log.URI, 5)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if n != 2 || len(domains) != 2 || domains[0] != "elmasy.com" || domains[1] != "www.elmasy.com" {
+		t.Fatalf("FAIL: invalid domains: %v (%d)\n", domains, n)
+	}
+}
@@ -0,0 +1,281 @@
+package ctlog
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+
+	gct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/tls"
+	"github.com/google/certificate-transparency-go/x509"
+)
+
+var (
+	OIDSCTList     = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2} // X.509 extension of the embedded SCTs
+	OIDOCSPSCTList = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 5} // OCSP single extension of the SCTs
+)
+
+// logEntryType converts t to the LogEntryType.
+func logEntryType(t EntryType) (gct.LogEntryType, error) {
+
+	switch t {
+	case EntryX509:
+		return gct.X509LogEntryType, nil
+	case EntryPrecert:
+		return gct.PrecertLogEntryType, nil
+	default:
+		return 0, fmt.Errorf("invalid entry type: %s", t)
+	}
+}
+
+// asn1Certs converts the DER encoded chain to ASN1Certs.
+func asn1Certs(chain [][]byte) []gct.ASN1Cert {
+
+	r := make([]gct.ASN1Cert, len(chain))
+
+	for i := range chain {
+		r[i] = gct.ASN1Cert{Data: chain[i]}
+	}
+
+	return r
+}
+
+// VerifySCT verifies the signature of sct issued by log l for the chain.
+// chain is the DER encoded chain as submitted, the leaf is the first (the precertificate if typ is EntryPrecert),
+// the issuer is required for precertificates.
+//
+// Returns ErrInvalidSignature if sct is not issued by l or the signature is invalid.
+func VerifySCT(l Log, sct *gct.SignedCertificateTimestamp, typ EntryType, chain [][]byte) error {
+
+	if sct == nil {
+		return fmt.Errorf("%w: SCT is nil", ErrInvalidSignature)
+	}
+
+	t, err := logEntryType(typ)
+	if err != nil {
+		return err
+	}
+
+	id, err := l.ID()
+	if err != nil {
+		return err
+	}
+
+	if sct.LogID.KeyID != id {
+		return fmt.Errorf("%w: SCT is not issued by %s", ErrInvalidSignature, l.Name)
+	}
+
+	key, err := l.PublicKey()
+	if err != nil {
+		return err
+	}
+
+	verifier, err := gct.NewSignatureVerifier(key)
+	if err != nil {
+		return fmt.Errorf("failed to create verifier: %w", err)
+	}
+
+	leaf, err := gct.MerkleTreeLeafFromRawChain(asn1Certs(chain), t, sct.Timestamp)
+	if err != nil {
+		return fmt.Errorf("failed to build leaf: %w", err)
+	}
+
+	if err := verifier.VerifySCTSignature(*sct, gct.LogEntry{Leaf: *leaf}); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidSignature, err)
+	}
+
+	return nil
+}
+
+// VerifyEmbeddedSCT verifies the signature of sct embedded in the final certificate and issued by log l.
+// chain is the DER encoded chain, the certificate is the first followed by its issuer.
+//
+// Returns ErrInvalidSignature if sct is not issued by l or the signature is invalid.
+func VerifyEmbeddedSCT(l Log, sct *gct.SignedCertificateTimestamp, chain [][]byte) error {
+
+	if sct == nil {
+		return fmt.Errorf("%w: SCT is nil", ErrInvalidSignature)
+	}
+
+	if len(chain) < 2 {
+		return fmt.Errorf("issuer is required to verify embedded SCT")
+	}
+
+	id, err := l.ID()
+	if err != nil {
+		return err
+	}
+
+	if sct.LogID.KeyID != id {
+		return fmt.Errorf("%w: SCT is not issued by %s", ErrInvalidSignature, l.Name)
+	}
+
+	certs := make([]*x509.Certificate, 2)
+
+	for i := range certs {
+		certs[i], err = x509.ParseCertificate(chain[i])
+		if x509.IsFatal(err) {
+			return fmt.Errorf("failed to parse certificate: %w", err)
+		}
+	}
+
+	key, err := l.PublicKey()
+	if err != nil {
+		return err
+	}
+
+	verifier, err := gct.NewSignatureVerifier(key)
+	if err != nil {
+		return fmt.Errorf("failed to create verifier: %w", err)
+	}
+
+	leaf, err := gct.MerkleTreeLeafForEmbeddedSCT(certs, sct.Timestamp)
+	if err != nil {
+		return fmt.Errorf("failed to build leaf: %w", err)
+	}
+
+	if err := verifier.VerifySCTSignature(*sct, gct.LogEntry{Leaf: *leaf}); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidSignature, err)
+	}
+
+	return nil
+}
+
+// EmbeddedSCTs returns the SCTs embedded in the DER encoded certificate.
+// Returns nil if the certificate has no embedded SCT.
+func EmbeddedSCTs(cert []byte) ([]*gct.SignedCertificateTimestamp, error) {
+
+	c, err := x509.ParseCertificate(cert)
+	if x509.IsFatal(err) {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+
+	for i := range c.Extensions {
+		if c.Extensions[i].Id.Equal(x509.OIDExtensionCTSCT) {
+			return ParseSCTListExtension(c.Extensions[i].Value)
+		}
+	}
+
+	return nil, nil
+}
+
+// MarshalSCTList returns the TLS encoded SignedCertificateTimestampList of scts (RFC 6962 section 3.3).
+// This is the extension data of the signed_certificate_timestamp TLS extension.
+func MarshalSCTList(scts []*gct.SignedCertificateTimestamp) ([]byte, error) {
+
+	if len(scts) == 0 {
+		return nil, fmt.Errorf("empty SCT list")
+	}
+
+	var list x509.SignedCertificateTimestampList
+
+	for i := range scts {
+
+		v, err := tls.Marshal(*scts[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal SCT: %w", err)
+		}
+
+		list.SCTList = append(list.SCTList, x509.SerializedSCT{Val: v})
+	}
+
+	r, err := tls.Marshal(list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal SCT list: %w", err)
+	}
+
+	return r, nil
+}
+
+// ParseSCT parses a TLS encoded SignedCertificateTimestamp (eg.: an element of the SignedCertificateTimestampList).
+func ParseSCT(data []byte) (*gct.SignedCertificateTimestamp, error) {
+
+	sct := new(gct.SignedCertificateTimestamp)
+
+	rest, err := tls.Unmarshal(data, sct)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal SCT: %w", err)
+	}
+
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("failed to unmarshal SCT: %d bytes trailing data", len(rest))
+	}
+
+	return sct, nil
+}
+
+// ParseSCTList parses the TLS encoded SignedCertificateTimestampList.
+func ParseSCTList(data []byte) ([]*gct.SignedCertificateTimestamp, error) {
+
+	var list x509.SignedCertificateTimestampList
+
+	rest, err := tls.Unmarshal(data, &list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal SCT list: %w", err)
+	}
+
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("failed to unmarshal SCT list: %d bytes trailing data", len(rest))
+	}
+
+	r := make([]*gct.SignedCertificateTimestamp, 0, len(list.SCTList))
+
+	for i := range list.SCTList {
+
+		sct, err := ParseSCT(list.SCTList[i].Val)
+		if err != nil {
+			return nil, err
+		}
+
+		r = append(r, sct)
+	}
+
+	return r, nil
+}
+
+// sctListExtension returns the extension id with the SCT list in an OCTET STRING.
+func sctListExtension(id asn1.ObjectIdentifier, scts []*gct.SignedCertificateTimestamp) (pkix.Extension, error) {
+
+	list, err := MarshalSCTList(scts)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+
+	v, err := asn1.Marshal(list)
+	if err != nil {
+		return pkix.Extension{}, fmt.Errorf("failed to marshal extension: %w", err)
+	}
+
+	return pkix.Extension{Id: id, Value: v}, nil
+}
+
+// SCTListExtension returns the X.509 extension to embed scts into the final certificate (eg.: in x509.Certificate.ExtraExtensions).
+// The SCTs must be issued for the precertificate of the same TBSCertificate.
+func SCTListExtension(scts []*gct.SignedCertificateTimestamp) (pkix.Extension, error) {
+
+	return sctListExtension(OIDSCTList, scts)
+}
+
+// OCSPSCTListExtension returns the OCSP single extension to staple scts (eg.: in ocsp.Response.ExtraExtensions).
+// The SCTs must be issued for the final certificate.
+func OCSPSCTListExtension(scts []*gct.SignedCertificateTimestamp) (pkix.Extension, error) {
+
+	return sctListExtension(OIDOCSPSCTList, scts)
+}
+
+// ParseSCTListExtension parses the SCTs from the value of the X.509 or OCSP SCT list extension.
+func ParseSCTListExtension(value []byte) ([]*gct.SignedCertificateTimestamp, error) {
+
+	var list []byte
+
+	rest, err := asn1.Unmarshal(value, &list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal extension: %w", err)
+	}
+
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("failed to unmarshal extension: %d bytes trailing data", len(rest))
+	}
+
+	return ParseSCTList(list)
+}
@@ -0,0 +1,267 @@
+package ctlog
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	gct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/client"
+	"github.com/google/certificate-transparency-go/jsonclient"
+	"github.com/google/certificate-transparency-go/tls"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrInvalidProof     = errors.New("invalid proof")
+	ErrInconsistentSTH  = errors.New("inconsistent tree head") // The log presented a tree that is not consistent with the trusted one (eg.: split view)
+)
+
+// Auditor verifies the Signed Tree Heads of a log and keeps the last trusted one.
+//
+// A new tree head is trusted only if its signature is valid and the log proves that it is consistent with the last trusted tree head.
+// The first tree head is trusted without proof (trust on first use), use SetTrusted() to start from a known tree head.
+//
+// An Auditor is safe for concurrent use.
+type Auditor struct {
+	uri      string
+	client   *client.LogClient
+	verifier *gct.SignatureVerifier
+	logID    [sha256.Size]byte
+	trusted  *gct.SignedTreeHead
+	m        *sync.Mutex
+}
+
+// NewAuditor creates an Auditor for log l.
+// The public key of the log (PubKey) is required.
+func NewAuditor(l Log) (*Auditor, error) {
+
+	key, err := l.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	verifier, err := gct.NewSignatureVerifier(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create verifier: %w", err)
+	}
+
+	id, err := l.ID()
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := client.New(l.URI, http.DefaultClient, jsonclient.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	return &Auditor{uri: l.URI, client: c, verifier: verifier, logID: id, m: new(sync.Mutex)}, nil
+}
+
+// SetHTTPClient sets the HTTP client used to query the log.
+func (a *Auditor) SetHTTPClient(hc *http.Client) error {
+
+	c, err := client.New(a.uri, hc, jsonclient.Options{})
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	a.client = c
+
+	return nil
+}
+
+// Trusted returns a copy of the last trusted tree head.
+// Returns nil if no tree head is trusted yet.
+func (a *Auditor) Trusted() *gct.SignedTreeHead {
+
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	if a.trusted == nil {
+		return nil
+	}
+
+	sth := *a.trusted
+
+	return &sth
+}
+
+// SetTrusted verifies the signature of sth and sets it as the last trusted tree head (eg.: a tree head saved before a restart).
+func (a *Auditor) SetTrusted(sth *gct.SignedTreeHead) error {
+
+	if err := a.VerifySTH(sth); err != nil {
+		return err
+	}
+
+	v := *sth
+
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	a.trusted = &v
+
+	return nil
+}
+
+// VerifySTH verifies the signature of sth with the public key of the log.
+func (a *Auditor) VerifySTH(sth *gct.SignedTreeHead) error {
+
+	if sth == nil {
+		return fmt.Errorf("%w: tree head is nil", ErrInvalidSignature)
+	}
+
+	if err := a.verifier.VerifySTHSignature(*sth); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidSignature, err)
+	}
+
+	return nil
+}
+
+// GetSTH returns the current tree head of the log with verified signature.
+// The returned tree head is not trusted, use Update() to verify the consistency.
+func (a *Auditor) GetSTH(ctx context.Context) (*gct.SignedTreeHead, error) {
+
+	sth, err := a.client.GetSTH(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SignedTreeHead: %w", err)
+	}
+
+	if err := a.VerifySTH(sth); err != nil {
+		return nil, err
+	}
+
+	sth.LogID = a.logID
+
+	return sth, nil
+}
+
+// VerifyConsistency fetches and verifies the consistency proof between the tree heads first and second.
+// The signatures are not verified.
+func (a *Auditor) VerifyConsistency(ctx context.Context, first, second *gct.SignedTreeHead) error {
+
+	var proof [][]byte
+
+	// The log does not serve proofs for empty and equal trees
+	if first.TreeSize > 0 && first.TreeSize < second.TreeSize {
+
+		p, err := a.client.GetSTHConsistency(ctx, first.TreeSize, second.TreeSize)
+		if err != nil {
+			return fmt.Errorf("failed to get consistency proof: %w", err)
+		}
+
+		proof = p
+	}
+
+	return VerifyConsistency(first.TreeSize, second.TreeSize, first.SHA256RootHash[:], second.SHA256RootHash[:], proof)
+}
+
+// Update fetches the current tree head of the log, verifies its signature and the consistency with the last trusted tree head,
+// and returns the new trusted tree head.
+//
+// If the log returned an older tree head (eg.: from a cache), the consistency is verified and the last trusted tree head is kept.
+// If the trusted tree head is changed by a concurrent call during the verification, the consistency is verified with the new one,
+// so the trusted tree head never goes backwards.
+//
+// Returns ErrInvalidSignature if the signature is invalid and ErrInconsistentSTH if the tree heads are not consistent.
+func (a *Auditor) Update(ctx context.Context) (*gct.SignedTreeHead, error) {
+
+	sth, err := a.GetSTH(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	a.m.Lock()
+	trusted := a.trusted
+	a.m.Unlock()
+
+	for {
+
+		if trusted != nil {
+
+			old, cur := trusted, sth
+			if sth.TreeSize < trusted.TreeSize {
+				old, cur = sth, trusted
+			}
+
+			if err := a.VerifyConsistency(ctx, old, cur); err != nil {
+
+				if errors.Is(err, ErrInvalidProof) {
+					return nil, fmt.Errorf("%w: %d (%x) and %d (%x): %s", ErrInconsistentSTH, old.TreeSize, old.SHA256RootHash, cur.TreeSize, cur.SHA256RootHash, err)
+				}
+
+				return nil, err
+			}
+		}
+
+		a.m.Lock()
+
+		// Changed by a concurrent call, verify again with the new one
+		if a.trusted != trusted {
+			trusted = a.trusted
+			a.m.Unlock()
+			continue
+		}
+
+		if trusted == nil || sth.TreeSize >= trusted.TreeSize {
+			a.trusted = sth
+		}
+
+		r := *a.trusted
+
+		a.m.Unlock()
+
+		return &r, nil
+	}
+}
+
+// VerifyInclusion fetches and verifies the inclusion proof of leaf at index in the last trusted tree.
+// leaf is the TLS encoded MerkleTreeLeaf (the leaf_input of get-entries).
+//
+// Returns ErrInvalidProof if the leaf is not included at index.
+func (a *Auditor) VerifyInclusion(ctx context.Context, index int64, leaf []byte) error {
+
+	trusted := a.Trusted()
+	if trusted == nil {
+		return fmt.Errorf("no trusted tree head")
+	}
+
+	if index < 0 || uint64(index) >= trusted.TreeSize {
+		return fmt.Errorf("%w: index %d is not in the trusted tree with size %d", ErrInvalidProof, index, trusted.TreeSize)
+	}
+
+	hash := LeafHash(leaf)
+
+	resp, err := a.client.GetProofByHash(ctx, hash[:], trusted.TreeSize)
+	if err != nil {
+		return fmt.Errorf("failed to get inclusion proof: %w", err)
+	}
+
+	if resp.LeafIndex != index {
+		return fmt.Errorf("%w: leaf is at index %d, not %d", ErrInvalidProof, resp.LeafIndex, index)
+	}
+
+	return VerifyInclusion(uint64(index), trusted.TreeSize, hash[:], trusted.SHA256RootHash[:], resp.AuditPath)
+}
+
+// VerifyEntry verifies the inclusion of the entry e in the last trusted tree.
+func (a *Auditor) VerifyEntry(ctx context.Context, e Entry) error {
+
+	if e.Raw == nil {
+		return fmt.Errorf("entry %d is invalid: %w", e.Index, e.Err)
+	}
+
+	leaf, err := tls.Marshal(e.Raw.Leaf)
+	if err != nil {
+		return fmt.Errorf("failed to marshal leaf: %w", err)
+	}
+
+	return a.VerifyInclusion(ctx, e.Index, leaf)
+}
@@ -0,0 +1,180 @@
+package ctlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elmasy-com/elnet/ctlog/cttest"
+)
+
+func TestAuditor(t *testing.T) {
+
+	l, log := newTestServer(t, 40)
+	l.SetTreeSize(10)
+
+	a, err := NewAuditor(log)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	ctx := context.Background()
+
+	for _, size := range []int{10, 17, 17, 32, 25, 40} {
+
+		l.SetTreeSize(size)
+
+		sth, err := a.Update(ctx)
+		if err != nil {
+			t.Fatalf("FAIL: update to %d: %s\n", size, err)
+		}
+
+		// The older tree head must not replace the trusted
+		want := size
+		if size == 25 {
+			want = 32
+		}
+
+		if sth.TreeSize != uint64(want) || a.Trusted().TreeSize != uint64(want) {
+			t.Fatalf("FAIL: trusted size want %d, got %d\n", want, sth.TreeSize)
+		}
+	}
+
+	if err := a.VerifyInclusion(ctx, 7, l.Leaf(7)); err != nil {
+		t.Fatalf("FAIL: inclusion: %s\n", err)
+	}
+
+	if err := a.VerifyInclusion(ctx, 8, l.Leaf(7)); !errors.Is(err, ErrInvalidProof) {
+		t.Fatalf("FAIL: inclusion at wrong index: %v\n", err)
+	}
+
+	// Split view: the log forks the tree
+	l.SetSplitView(true)
+
+	if _, err := a.Update(ctx); !errors.Is(err, ErrInconsistentSTH) {
+		t.Fatalf("FAIL: fork not detected: %v\n", err)
+	}
+
+	if a.Trusted().TreeSize != 40 {
+		t.Fatalf("FAIL: trusted tree head changed after fork\n")
+	}
+
+	// Other key must fail
+	other, err := cttest.NewLog()
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	b, err := NewAuditor(Log{Name: "Test", URI: log.URI, PubKey: other.PubKey()})
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if _, err := b.Update(ctx); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("FAIL: invalid signature not detected: %v\n", err)
+	}
+}
+
+func TestAuditorConcurrent(t *testing.T) {
+
+	l, log := newTestServer(t, 40)
+
+	a, err := NewAuditor(log)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		m    sync.Mutex
+		max  uint64
+		errs = make(chan error, 8)
+	)
+
+	for g := 0; g < 8; g++ {
+
+		wg.Add(1)
+
+		go func(g int) {
+
+			defer wg.Done()
+
+			var last uint64
+
+			for j := 0; j < 20; j++ {
+
+				// The log serves older and newer tree heads in random order
+				l.SetTreeSize(1 + (g*7+j*13)%40)
+
+				sth, err := a.Update(context.Background())
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				if sth.TreeSize < last {
+					errs <- fmt.Errorf("trusted size went backwards from %d to %d", last, sth.TreeSize)
+					return
+				}
+
+				last = sth.TreeSize
+
+				m.Lock()
+				if last > max {
+					max = last
+				}
+				m.Unlock()
+			}
+		}(g)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if a.Trusted().TreeSize != max {
+		t.Fatalf("FAIL: want trusted size %d, got %d\n", max, a.Trusted().TreeSize)
+	}
+}
+
+func TestFollowerAuditor(t *testing.T) {
+
+	l, log := newTestServer(t, 20)
+	l.SetSplitView(true)
+
+	a, err := NewAuditor(log)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if _, err := a.Update(context.Background()); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	// The log removes the fork
+	l.SetSplitView(false)
+
+	f, err := NewFollower(log.URI, nil)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	f.SetAuditor(a)
+	f.SetBackoff(time.Millisecond, time.Millisecond)
+
+	entries := make(chan Entry, 100)
+
+	if err := f.Run(context.Background(), entries); !errors.Is(err, ErrInconsistentSTH) {
+		t.Fatalf("FAIL: want ErrInconsistentSTH, got %v\n", err)
+	}
+
+	if len(entries) != 0 {
+		t.Fatalf("FAIL: entries emitted from inconsistent log\n")
+	}
+}
@@ -0,0 +1,162 @@
+package ctlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	gct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/client"
+	"github.com/google/certificate-transparency-go/jsonclient"
+)
+
+// ErrNotEnoughSCTs is returned by Submit() if the SCTs are not issued by enough distinct operators.
+var ErrNotEnoughSCTs = errors.New("not enough SCTs")
+
+// SubmitTimeout is the maximum time of a submission to one log, including the retries of the 429 and 503 responses.
+// A log that is down does not block the others longer than SubmitTimeout.
+var SubmitTimeout = 30 * time.Second
+
+// discardLogger discards the retry messages of jsonclient (the default logs to the standard logger).
+type discardLogger struct{}
+
+func (discardLogger) Printf(string, ...interface{}) {}
+
+// SubmitResult is the result of a submission to a log.
+type SubmitResult struct {
+	Log Log
+	SCT *gct.SignedCertificateTimestamp // nil if Err is not nil
+	Err error
+}
+
+// submit submits the chain to log l and verifies the returned SCT.
+func submit(ctx context.Context, l Log, typ EntryType, chain [][]byte) (*gct.SignedCertificateTimestamp, error) {
+
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("empty chain")
+	}
+
+	c, err := client.New(l.URI, http.DefaultClient, jsonclient.Options{Logger: discardLogger{}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, SubmitTimeout)
+	defer cancel()
+
+	var sct *gct.SignedCertificateTimestamp
+
+	switch typ {
+	case EntryX509:
+		sct, err = c.AddChain(ctx, asn1Certs(chain))
+	case EntryPrecert:
+		sct, err = c.AddPreChain(ctx, asn1Certs(chain))
+	default:
+		return nil, fmt.Errorf("invalid entry type: %s", typ)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit to %s: %w", l.URI, err)
+	}
+
+	if err := VerifySCT(l, sct, typ, chain); err != nil {
+		return nil, err
+	}
+
+	return sct, nil
+}
+
+// AddChain submits the certificate chain to log l (add-chain) and returns the verified SCT.
+// chain is the DER encoded chain, the leaf is the first, the root can be omitted.
+//
+// The 429 and 503 responses are retried until ctx is done or SubmitTimeout is elapsed.
+// Returns ErrInvalidSignature if the signature of the SCT is invalid.
+func AddChain(ctx context.Context, l Log, chain [][]byte) (*gct.SignedCertificateTimestamp, error) {
+
+	return submit(ctx, l, EntryX509, chain)
+}
+
+// AddPreChain submits the precertificate chain to log l (add-pre-chain) and returns the verified SCT.
+// chain is the DER encoded chain, the precertificate is the first followed by its issuer.
+//
+// The 429 and 503 responses are retried until ctx is done or SubmitTimeout is elapsed.
+// Returns ErrInvalidSignature if the signature of the SCT is invalid.
+func AddPreChain(ctx context.Context, l Log, chain [][]byte) (*gct.SignedCertificateTimestamp, error) {
+
+	return submit(ctx, l, EntryPrecert, chain)
+}
+
+// SubmitAll submits the chain to every log in parallel.
+// The results are in the order of logs.
+// Every log has its own SubmitTimeout, so a log that is down does not block the results of the others.
+//
+// Use LogList.ForExpiry() to select the logs that accept the certificate.
+func SubmitAll(ctx context.Context, logs []Log, typ EntryType, chain [][]byte) []SubmitResult {
+
+	r := make([]SubmitResult, len(logs))
+
+	var wg sync.WaitGroup
+
+	for i := range logs {
+
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+			r[i].Log = logs[i]
+			r[i].SCT, r[i].Err = submit(ctx, logs[i], typ, chain)
+		}(i)
+	}
+
+	wg.Wait()
+
+	return r
+}
+
+// operator returns the operator of l, or the URI if the operator is unknown.
+func operator(l Log) string {
+
+	if l.Operator != "" {
+		return l.Operator
+	}
+
+	return l.URI
+}
+
+// Submit submits the chain to every log in parallel and returns the SCTs.
+//
+// Returns the SCTs and ErrNotEnoughSCTs if the SCTs are not issued by at least minOperators distinct operators
+// (eg.: Chrome and Apple require at least 2).
+// The logs without Operator are considered as distinct operators.
+func Submit(ctx context.Context, logs []Log, typ EntryType, chain [][]byte, minOperators int) ([]*gct.SignedCertificateTimestamp, error) {
+
+	var (
+		scts    []*gct.SignedCertificateTimestamp
+		ops     = make(map[string]struct{})
+		lastErr error
+	)
+
+	for _, r := range SubmitAll(ctx, logs, typ, chain) {
+
+		if r.Err != nil {
+			lastErr = r.Err
+			continue
+		}
+
+		scts = append(scts, r.SCT)
+		ops[operator(r.Log)] = struct{}{}
+	}
+
+	if len(ops) < minOperators {
+
+		if lastErr != nil {
+			return scts, fmt.Errorf("%w: %d operator(s) from %d, last error: %s", ErrNotEnoughSCTs, len(ops), minOperators, lastErr)
+		}
+
+		return scts, fmt.Errorf("%w: %d operator(s) from %d", ErrNotEnoughSCTs, len(ops), minOperators)
+	}
+
+	return scts, nil
+}
@@ -0,0 +1,202 @@
+package ctlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	gct "github.com/google/certificate-transparency-go"
+	"github.com/google/certificate-transparency-go/x509"
+)
+
+func TestSubmit(t *testing.T) {
+
+	ca, a := newTestServer(t, 0)
+
+	b, bLog := newTestServer(t, 0)
+	b.AddRoot(ca.Root())
+
+	c, cLog := newTestServer(t, 0)
+	c.AddRoot(ca.Root())
+
+	// Does not accept the root
+	_, dLog := newTestServer(t, 0)
+
+	// c is operated by the operator of a
+	a.Operator, bLog.Operator, cLog.Operator, dLog.Operator = "A", "B", "A", "D"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tmpl := ca.Template("elmasy.com")
+
+	precert, err := ca.IssuePrecert(tmpl)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	chain := [][]byte{precert, ca.Root().Raw}
+
+	if _, err := Submit(ctx, []Log{a, cLog, dLog}, EntryPrecert, chain, 2); !errors.Is(err, ErrNotEnoughSCTs) {
+		t.Fatalf("FAIL: want ErrNotEnoughSCTs, got %v\n", err)
+	}
+
+	scts, err := Submit(ctx, []Log{a, bLog, dLog}, EntryPrecert, chain, 2)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if len(scts) != 2 {
+		t.Fatalf("FAIL: want 2 SCTs, got %d\n", len(scts))
+	}
+
+	// Embed the SCTs into the final certificate
+	ext, err := SCTListExtension(scts)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	tmpl.ExtraExtensions = append(tmpl.ExtraExtensions, ext)
+
+	final, err := ca.Sign(tmpl)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	cert, err := x509.ParseCertificate(final)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	root, err := x509.ParseCertificate(ca.Root().Raw)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	for i, l := range []Log{a, bLog} {
+
+		leaf, err := gct.MerkleTreeLeafForEmbeddedSCT([]*x509.Certificate{cert, root}, scts[i].Timestamp)
+		if err != nil {
+			t.Fatalf("FAIL: %s\n", err)
+		}
+
+		key, _ := l.PublicKey()
+		v, _ := gct.NewSignatureVerifier(key)
+
+		if err := v.VerifySCTSignature(*scts[i], gct.LogEntry{Leaf: *leaf}); err != nil {
+			t.Fatalf("FAIL: embedded SCT of %s is invalid: %s\n", l.Name, err)
+		}
+	}
+
+	// Submit the final certificate
+	sct, err := AddChain(ctx, bLog, [][]byte{final, ca.Root().Raw})
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if err := VerifySCT(a, sct, EntryX509, [][]byte{final}); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("FAIL: SCT verified with other log: %v\n", err)
+	}
+
+	if err := VerifySCT(bLog, sct, EntryPrecert, chain); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("FAIL: SCT verified for other certificate: %v\n", err)
+	}
+
+	// The log signs with other key
+	fake := bLog
+	fake.PubKey = c.PubKey()
+
+	if _, err := AddPreChain(ctx, fake, chain); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("FAIL: want ErrInvalidSignature, got %v\n", err)
+	}
+
+	// Serialize for TLS and OCSP
+	list, err := MarshalSCTList([]*gct.SignedCertificateTimestamp{sct})
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	parsed, err := ParseSCTList(list)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if len(parsed) != 1 || VerifySCT(bLog, parsed[0], EntryX509, [][]byte{final}) != nil {
+		t.Fatalf("FAIL: invalid parsed SCT list\n")
+	}
+
+	ocsp, err := OCSPSCTListExtension([]*gct.SignedCertificateTimestamp{sct})
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if !ocsp.Id.Equal(OIDOCSPSCTList) {
+		t.Fatalf("FAIL: invalid OCSP extension id: %s\n", ocsp.Id)
+	}
+
+	if parsed, err = ParseSCTListExtension(ocsp.Value); err != nil || len(parsed) != 1 {
+		t.Fatalf("FAIL: invalid OCSP extension: %v\n", err)
+	}
+
+	if _, err := MarshalSCTList(nil); err == nil {
+		t.Fatalf("FAIL: empty list serialized\n")
+	}
+}
+
+func TestSubmitTimeout(t *testing.T) {
+
+	ca, a := newTestServer(t, 0)
+
+	b, bLog := newTestServer(t, 0)
+	b.AddRoot(ca.Root())
+
+	// The log is down, every request is answered with 429
+	dead, deadLog := newTestServer(t, 0)
+	dead.AddRoot(ca.Root())
+	dead.SetFailures(1 << 30)
+
+	orig := SubmitTimeout
+	SubmitTimeout = 200 * time.Millisecond
+	defer func() { SubmitTimeout = orig }()
+
+	// The retries must not be logged
+	var buf bytes.Buffer
+
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	precert, err := ca.IssuePrecert(ca.Template("elmasy.com"))
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	start := time.Now()
+
+	// No deadline
+	scts, err := Submit(context.Background(), []Log{a, bLog, deadLog}, EntryPrecert, [][]byte{precert, ca.Root().Raw}, 2)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if len(scts) != 2 {
+		t.Fatalf("FAIL: want 2 SCTs, got %d\n", len(scts))
+	}
+
+	if d := time.Since(start); d > 5*time.Second {
+		t.Fatalf("FAIL: the log that is down blocked the submission for %s\n", d)
+	}
+
+	r := SubmitAll(context.Background(), []Log{deadLog}, EntryPrecert, [][]byte{precert, ca.Root().Raw})
+
+	if !errors.Is(r[0].Err, context.DeadlineExceeded) {
+		t.Fatalf("FAIL: want context.DeadlineExceeded, got %v\n", r[0].Err)
+	}
+
+	if buf.Len() != 0 {
+		t.Fatalf("FAIL: retries logged: %s\n", buf.String())
+	}
+}
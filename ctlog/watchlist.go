@@ -0,0 +1,191 @@
+package ctlog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/elmasy-com/elnet/dns"
+)
+
+type MatchType string
+
+const (
+	MatchExact   MatchType = "exact"   // The name is equal to the pattern
+	MatchDomain  MatchType = "domain"  // The registrable domain of the name is equal to the pattern
+	MatchSuffix  MatchType = "suffix"  // The name ends with the pattern
+	MatchRegex   MatchType = "regex"   // The name matches the regular expression
+	MatchKeyword MatchType = "keyword" // The name contains the pattern
+)
+
+// Match is a name that matched a pattern of a Watchlist.
+type Match struct {
+	Name    string    // The matched name from the certificate
+	Type    MatchType // Type of the pattern
+	Pattern string    // The matched pattern
+}
+
+// Watchlist is a list of patterns to match the names of the certificates.
+//
+// The names and the patterns (except the regular expressions) are cleaned with dns.Clean(),
+// the wildcard prefix ("*.") of the names is removed before the domain match.
+//
+// A Watchlist is safe for concurrent use.
+type Watchlist struct {
+	exact    map[string]struct{}
+	domains  map[string]struct{}
+	suffixes []string
+	regexes  []*regexp.Regexp
+	keywords []string
+	m        *sync.RWMutex
+}
+
+// NewWatchlist creates an empty Watchlist.
+func NewWatchlist() *Watchlist {
+
+	return &Watchlist{exact: make(map[string]struct{}), domains: make(map[string]struct{}), m: new(sync.RWMutex)}
+}
+
+// AddExact adds name to the exact names (eg.: "www.example.com" or "*.example.com").
+func (w *Watchlist) AddExact(name string) {
+
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	w.exact[dns.Clean(name)] = struct{}{}
+}
+
+// AddDomain adds the registrable domain of d (eg.: "www.example.com" -> "example.com").
+// Every name under the registrable domain is matched.
+//
+// Returns error if d has no registrable domain (eg.: "com").
+func (w *Watchlist) AddDomain(d string) error {
+
+	r := dns.GetDomain(dns.Clean(d))
+	if r == "" {
+		return fmt.Errorf("%w: %s has no registrable domain", dns.ErrInvalidDomain, d)
+	}
+
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	w.domains[r] = struct{}{}
+
+	return nil
+}
+
+// AddSuffix adds suffix to the suffix patterns.
+// Use a leading dot to match only the subdomains (eg.: ".example.com").
+func (w *Watchlist) AddSuffix(suffix string) {
+
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	w.suffixes = append(w.suffixes, dns.Clean(suffix))
+}
+
+// AddRegex compiles expr and adds to the regex patterns.
+// The names are lower cased, so expr should be lower case too.
+func (w *Watchlist) AddRegex(expr string) error {
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("failed to compile %s: %w", expr, err)
+	}
+
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	w.regexes = append(w.regexes, re)
+
+	return nil
+}
+
+// AddKeyword adds keyword to the keywords (eg.: "paypal" matches "paypal-login.example.com").
+func (w *Watchlist) AddKeyword(keyword string) {
+
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	w.keywords = append(w.keywords, dns.Clean(keyword))
+}
+
+// Len returns the number of patterns.
+func (w *Watchlist) Len() int {
+
+	w.m.RLock()
+	defer w.m.RUnlock()
+
+	return len(w.exact) + len(w.domains) + len(w.suffixes) + len(w.regexes) + len(w.keywords)
+}
+
+// Match returns the patterns matched by name.
+// Returns nil if name does not match any pattern.
+func (w *Watchlist) Match(name string) []Match {
+
+	name = dns.Clean(name)
+	if name == "" {
+		return nil
+	}
+
+	w.m.RLock()
+	defer w.m.RUnlock()
+
+	var r []Match
+
+	if _, ok := w.exact[name]; ok {
+		r = append(r, Match{Name: name, Type: MatchExact, Pattern: name})
+	}
+
+	if len(w.domains) > 0 {
+		if d := dns.GetDomain(strings.TrimPrefix(name, "*.")); d != "" {
+			if _, ok := w.domains[d]; ok {
+				r = append(r, Match{Name: name, Type: MatchDomain, Pattern: d})
+			}
+		}
+	}
+
+	for i := range w.suffixes {
+		if strings.HasSuffix(name, w.suffixes[i]) {
+			r = append(r, Match{Name: name, Type: MatchSuffix, Pattern: w.suffixes[i]})
+		}
+	}
+
+	for i := range w.regexes {
+		if w.regexes[i].MatchString(name) {
+			r = append(r, Match{Name: name, Type: MatchRegex, Pattern: w.regexes[i].String()})
+		}
+	}
+
+	for i := range w.keywords {
+		if strings.Contains(name, w.keywords[i]) {
+			r = append(r, Match{Name: name, Type: MatchKeyword, Pattern: w.keywords[i]})
+		}
+	}
+
+	return r
+}
+
+// MatchRecord returns the patterns matched by the common name and the DNS SANs of r.
+// Returns nil if r does not match any pattern.
+func (w *Watchlist) MatchRecord(r *Record) []Match {
+
+	var m []Match
+
+	if r.CommonName != "" {
+		m = append(m, w.Match(r.CommonName)...)
+	}
+
+	for i := range r.DNSNames {
+
+		// The common name is usually in the SANs too
+		if dns.Clean(r.DNSNames[i]) == dns.Clean(r.CommonName) {
+			continue
+		}
+
+		m = append(m, w.Match(r.DNSNames[i])...)
+	}
+
+	return m
+}
@@ -310,3 +310,10 @@ func QueryAll(name string) ([]Record, error) {
 
 	return DefaultServers.QueryAll(name)
 }
+
+// RecordFromRR converts rr to a Record.
+// The multiple strings of a TXT record are concatenated.
+func RecordFromRR(rr mdns.RR) Record {
+
+	return Record{Type: rr.Header().Rrtype, Value: rrValue(rr)}
+}
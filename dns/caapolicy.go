@@ -0,0 +1,249 @@
+package dns
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// caaFlagCritical is the Issuer Critical Flag.
+const caaFlagCritical = 128
+
+// CAAIssuer is a parsed "issue" or "issuewild" property (RFC 8659 section 4.2).
+type CAAIssuer struct {
+	Domain     string            // Issuer domain name (eg.: "letsencrypt.org"), empty means no CA is permitted (";")
+	Parameters map[string]string // The parameters with lowercase keys (eg.: "accounturi", "validationmethods")
+	Malformed  bool              // The value is malformed, handled as an empty issuer domain
+}
+
+// AccountURI returns the "accounturi" parameter (RFC 8657), empty if not set.
+func (i CAAIssuer) AccountURI() string {
+	return i.Parameters["accounturi"]
+}
+
+// ValidationMethods returns the "validationmethods" parameter (RFC 8657), nil if not set.
+func (i CAAIssuer) ValidationMethods() []string {
+
+	v, ok := i.Parameters["validationmethods"]
+	if !ok {
+		return nil
+	}
+
+	return strings.Split(v, ",")
+}
+
+// isLabel returns whether s is a valid label or tag (alphanumeric and hyphen).
+func isLabel(s string) bool {
+
+	if s == "" {
+		return false
+	}
+
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') && c != '-' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseCAAIssuer parses the value of an "issue" or "issuewild" property.
+//
+// Format:
+//
+//	[issuer-domain-name] *(";" key=value)
+//
+// If the value is malformed, returns the issuer with Malformed set and an error.
+func ParseCAAIssuer(value string) (CAAIssuer, error) {
+
+	i := CAAIssuer{Parameters: make(map[string]string)}
+
+	parts := strings.Split(value, ";")
+
+	i.Domain = strings.ToLower(strings.TrimSpace(parts[0]))
+
+	if i.Domain != "" {
+		for _, l := range strings.Split(i.Domain, ".") {
+			if !isLabel(l) {
+				i.Malformed = true
+				return i, fmt.Errorf("invalid issuer domain name: %s", i.Domain)
+			}
+		}
+	}
+
+	for _, p := range parts[1:] {
+
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		k, v, ok := strings.Cut(p, "=")
+		if !ok || !isLabel(strings.TrimSpace(k)) {
+			i.Malformed = true
+			return i, fmt.Errorf("invalid parameter: %s", p)
+		}
+
+		i.Parameters[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+	}
+
+	return i, nil
+}
+
+// CAAPolicy is the CAA policy of a name.
+type CAAPolicy struct {
+	Name            string      // The evaluated name, without the wildcard label
+	Owner           string      // Owner name of the relevant record set, empty if no CAA record found
+	Records         []CAA       // The relevant record set
+	Issue           []CAAIssuer // "issue" properties
+	IssueWild       []CAAIssuer // "issuewild" properties
+	IODEF           []string    // "iodef" URLs (eg.: "mailto:security@example.com")
+	UnknownCritical []string    // Unknown tags with the critical flag, if not empty no CA is permitted
+}
+
+// NewCAAPolicy creates the policy of name from the relevant record set found at owner.
+func NewCAAPolicy(name string, owner string, records []CAA) *CAAPolicy {
+
+	p := &CAAPolicy{Name: name, Owner: owner, Records: records}
+
+	for i := range records {
+
+		switch strings.ToLower(records[i].Tag) {
+		case "issue":
+			v, _ := ParseCAAIssuer(records[i].Value)
+			p.Issue = append(p.Issue, v)
+		case "issuewild":
+			v, _ := ParseCAAIssuer(records[i].Value)
+			p.IssueWild = append(p.IssueWild, v)
+		case "iodef":
+			p.IODEF = append(p.IODEF, records[i].Value)
+		default:
+			if records[i].Flag&caaFlagCritical != 0 {
+				p.UnknownCritical = append(p.UnknownCritical, records[i].Tag)
+			}
+		}
+	}
+
+	return p
+}
+
+// issuers returns the relevant issuers for wildcard or non-wildcard certificates.
+// Returns nil if any CA is permitted.
+func (p *CAAPolicy) issuers(wildcard bool) []CAAIssuer {
+
+	if wildcard && len(p.IssueWild) > 0 {
+		return p.IssueWild
+	}
+
+	return p.Issue
+}
+
+// Permits returns whether the CA identified with ca (the issuer domain name, eg.: "letsencrypt.org") may issue a certificate.
+// If wildcard is true, the "issuewild" properties are used if present.
+func (p *CAAPolicy) Permits(ca string, wildcard bool) bool {
+
+	return p.PermitsAccount(ca, wildcard, "", "")
+}
+
+// PermitsAccount returns whether the CA identified with ca may issue a certificate to the account accountURI with the validation method.
+// Empty accountURI or method is not checked.
+// If wildcard is true, the "issuewild" properties are used if present.
+func (p *CAAPolicy) PermitsAccount(ca string, wildcard bool, accountURI string, method string) bool {
+
+	if len(p.UnknownCritical) > 0 {
+		return false
+	}
+
+	is := p.issuers(wildcard)
+
+	if len(is) == 0 {
+		return true
+	}
+
+	ca = Clean(ca)
+
+	for i := range is {
+
+		if is[i].Malformed || is[i].Domain == "" || is[i].Domain != ca {
+			continue
+		}
+
+		if accountURI != "" {
+			if v, ok := is[i].Parameters["accounturi"]; ok && v != accountURI {
+				continue
+			}
+		}
+
+		if method != "" {
+			if ms := is[i].ValidationMethods(); ms != nil && !containsFold(ms, method) {
+				continue
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// containsFold returns whether ss contains s case-insensitively.
+func containsFold(ss []string, s string) bool {
+
+	for i := range ss {
+		if strings.EqualFold(strings.TrimSpace(ss[i]), s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetCAAPolicy returns the CAA policy of name with tree climbing (RFC 8659 section 3).
+// The climbing starts at name and stops at the registrable domain (see GetDomain()).
+// The wildcard label is removed from name (eg.: "*.example.com" -> "example.com").
+//
+// If no CAA record found, returns a policy with empty Owner that permits any CA.
+// Any error except NXDOMAIN stops the climbing and returned, in this case the CA must not issue.
+func (s *Servers) GetCAAPolicy(name string) (*CAAPolicy, error) {
+
+	name = strings.TrimPrefix(Clean(name), "*.")
+
+	domain := GetDomain(name)
+	if domain == "" {
+		domain = name
+	}
+
+	for current := name; ; {
+
+		rs, err := s.TryQueryCAA(current)
+		if err != nil && !errors.Is(err, ErrName) {
+			return nil, fmt.Errorf("failed to query %s: %w", current, err)
+		}
+
+		if len(rs) > 0 {
+			return NewCAAPolicy(name, current, rs), nil
+		}
+
+		if current == domain {
+			break
+		}
+
+		i := strings.IndexByte(current, '.')
+		if i == -1 {
+			break
+		}
+
+		current = current[i+1:]
+	}
+
+	return NewCAAPolicy(name, "", nil), nil
+}
+
+// GetCAAPolicy returns the CAA policy of name with tree climbing using the DefaultServers.
+//
+// See Servers.GetCAAPolicy() for more.
+func GetCAAPolicy(name string) (*CAAPolicy, error) {
+
+	return DefaultServers.GetCAAPolicy(name)
+}
@@ -0,0 +1,139 @@
+package dns
+
+import (
+	"testing"
+	"time"
+
+	mdns "github.com/miekg/dns"
+)
+
+func TestParseCAAIssuer(t *testing.T) {
+
+	i, err := ParseCAAIssuer("LetsEncrypt.org; accounturi=https://acme-v02.api.letsencrypt.org/acme/acct/1 ; validationmethods=dns-01,http-01")
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if i.Domain != "letsencrypt.org" {
+		t.Fatalf("FAIL: invalid domain: %s\n", i.Domain)
+	}
+
+	if i.AccountURI() != "https://acme-v02.api.letsencrypt.org/acme/acct/1" {
+		t.Fatalf("FAIL: invalid accounturi: %s\n", i.AccountURI())
+	}
+
+	if ms := i.ValidationMethods(); len(ms) != 2 || ms[0] != "dns-01" || ms[1] != "http-01" {
+		t.Fatalf("FAIL: invalid validationmethods: %v\n", ms)
+	}
+
+	if i, err := ParseCAAIssuer(";"); err != nil || i.Domain != "" {
+		t.Fatalf("FAIL: \";\" must be an empty issuer: %#v, %v\n", i, err)
+	}
+
+	if i, err := ParseCAAIssuer("bad_domain.com"); err == nil || !i.Malformed {
+		t.Fatalf("FAIL: malformed value is accepted\n")
+	}
+}
+
+func TestCAAPolicyPermits(t *testing.T) {
+
+	p := NewCAAPolicy("example.com", "example.com", []CAA{
+		{Tag: "issue", Value: "letsencrypt.org; validationmethods=dns-01"},
+		{Tag: "issue", Value: "pki.goog"},
+		{Tag: "issuewild", Value: ";"},
+		{Tag: "iodef", Value: "mailto:security@example.com"},
+	})
+
+	cases := []struct {
+		ca       string
+		wildcard bool
+		method   string
+		want     bool
+	}{
+		{"letsencrypt.org", false, "", true},
+		{"LetsEncrypt.org.", false, "dns-01", true},
+		{"letsencrypt.org", false, "http-01", false},
+		{"pki.goog", false, "http-01", true},
+		{"digicert.com", false, "", false},
+		{"letsencrypt.org", true, "", false},
+	}
+
+	for i := range cases {
+		if got := p.PermitsAccount(cases[i].ca, cases[i].wildcard, "", cases[i].method); got != cases[i].want {
+			t.Fatalf("FAIL: case %d: %s (wildcard: %v, method: %s): want %v, got %v\n", i, cases[i].ca, cases[i].wildcard, cases[i].method, cases[i].want, got)
+		}
+	}
+
+	if len(p.IODEF) != 1 {
+		t.Fatalf("FAIL: iodef is missing\n")
+	}
+
+	// Only issue: wildcard follows the issue properties
+	p = NewCAAPolicy("example.com", "example.com", []CAA{{Tag: "issue", Value: "letsencrypt.org"}})
+	if !p.Permits("letsencrypt.org", true) {
+		t.Fatalf("FAIL: wildcard must fall back to issue\n")
+	}
+
+	// Unknown critical tag denies everything
+	p = NewCAAPolicy("example.com", "example.com", []CAA{{Flag: 128, Tag: "tbs", Value: "x"}})
+	if p.Permits("letsencrypt.org", false) {
+		t.Fatalf("FAIL: unknown critical tag must deny\n")
+	}
+
+	// Only iodef permits any CA
+	p = NewCAAPolicy("example.com", "example.com", []CAA{{Tag: "iodef", Value: "mailto:a@example.com"}})
+	if !p.Permits("anyca.com", false) {
+		t.Fatalf("FAIL: policy without issue must permit\n")
+	}
+}
+
+func TestGetCAAPolicy(t *testing.T) {
+
+	addr := startTestServer(t, func(w mdns.ResponseWriter, r *mdns.Msg) {
+
+		m := new(mdns.Msg)
+		m.SetReply(r)
+
+		switch r.Question[0].Name {
+		case "example.com.":
+			m.Answer = append(m.Answer, &mdns.CAA{
+				Hdr:   mdns.RR_Header{Name: "example.com.", Rrtype: mdns.TypeCAA, Class: mdns.ClassINET, Ttl: 60},
+				Tag:   "issue",
+				Value: "letsencrypt.org",
+			})
+		case "www.example.com.":
+		default:
+			m.Rcode = mdns.RcodeNameError
+		}
+
+		w.WriteMsg(m)
+	})
+
+	servers, err := NewServersStr(1, time.Second, addr)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	p, err := servers.GetCAAPolicy("*.a.www.example.com")
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if p.Name != "a.www.example.com" || p.Owner != "example.com" {
+		t.Fatalf("FAIL: invalid policy: name=%s owner=%s\n", p.Name, p.Owner)
+	}
+
+	if !p.Permits("letsencrypt.org", true) || p.Permits("pki.goog", false) {
+		t.Fatalf("FAIL: invalid permissions\n")
+	}
+
+	// The climbing stops at the registrable domain
+	p, err = servers.GetCAAPolicy("www.example.org")
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if p.Owner != "" || !p.Permits("pki.goog", false) {
+		t.Fatalf("FAIL: missing CAA must permit any CA\n")
+	}
+}
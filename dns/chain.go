@@ -0,0 +1,170 @@
+package dns
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	mdns "github.com/miekg/dns"
+)
+
+var (
+	ErrChainLoop    = errors.New("alias chain loop")
+	ErrChainTooLong = errors.New("alias chain too long")
+)
+
+// MaxChainLength is the maximum number of CNAME/DNAME hops followed by ResolveChain.
+var MaxChainLength = 16
+
+// ChainHop is an alias in a chain.
+type ChainHop struct {
+	Name   string // Owner name of the alias (eg.: "www.example.com")
+	Type   uint16 // TypeCNAME or TypeDNAME
+	Target string // The name where the alias points. In case of DNAME, this is the synthesized name.
+	TTL    uint32 // TTL of the CNAME/DNAME record
+}
+
+// Chain is the result of an alias chain resolution.
+type Chain struct {
+	Name     string      // The queried name
+	Type     uint16      // The queried type
+	Hops     []ChainHop  // The aliases in order
+	Target   string      // The last name in the chain (Name if there is no alias)
+	Answers  []RecordTTL // The records of Target with type Type
+	Dangling bool        // The chain points to a non-existing name (NXDOMAIN)
+}
+
+// follow follows the chain in the answer section from c.Target and appends the hops to c.
+// visited contains the already seen names.
+// Returns whether the chain is progressed.
+func (c *Chain) follow(answer []mdns.RR, visited map[string]bool) (bool, error) {
+
+	progressed := false
+
+	for {
+
+		var hop *ChainHop
+
+		// Check DNAME first, so the synthesized CNAME is skipped
+		for i := range answer {
+
+			v, ok := answer[i].(*mdns.DNAME)
+			if !ok {
+				continue
+			}
+
+			owner := Clean(v.Hdr.Name)
+
+			if strings.HasSuffix(c.Target, "."+owner) {
+				target := strings.TrimSuffix(c.Target, owner) + Clean(v.Target)
+				hop = &ChainHop{Name: owner, Type: TypeDNAME, Target: target, TTL: v.Hdr.Ttl}
+				break
+			}
+		}
+
+		if hop == nil {
+
+			for i := range answer {
+
+				v, ok := answer[i].(*mdns.CNAME)
+				if !ok {
+					continue
+				}
+
+				if Clean(v.Hdr.Name) == c.Target {
+					hop = &ChainHop{Name: c.Target, Type: TypeCNAME, Target: Clean(v.Target), TTL: v.Hdr.Ttl}
+					break
+				}
+			}
+		}
+
+		if hop == nil {
+			return progressed, nil
+		}
+
+		if visited[hop.Target] {
+			c.Hops = append(c.Hops, *hop)
+			return progressed, fmt.Errorf("%w: %s", ErrChainLoop, hop.Target)
+		}
+
+		if len(c.Hops) >= MaxChainLength {
+			return progressed, ErrChainTooLong
+		}
+
+		visited[hop.Target] = true
+
+		c.Hops = append(c.Hops, *hop)
+		c.Target = hop.Target
+
+		progressed = true
+	}
+}
+
+// ResolveChain resolves name with type t and follows the CNAME and DNAME aliases.
+// If the resolver returns a partial chain, the last name in the chain is queried again.
+//
+// If the chain points to a non-existing name, Dangling is set and the error is nil.
+// If name does not exist, returns ErrName.
+// If the chain contains a loop or longer than MaxChainLength, returns the chain so far and ErrChainLoop or ErrChainTooLong.
+//
+// The type t can not be TypeCNAME or TypeDNAME.
+func (s *Servers) ResolveChain(name string, t uint16) (*Chain, error) {
+
+	if t == TypeCNAME || t == TypeDNAME {
+		return nil, fmt.Errorf("invalid type: %s", TypeToString(t))
+	}
+
+	c := &Chain{Name: Clean(name), Type: t}
+	c.Target = c.Name
+
+	visited := map[string]bool{c.Name: true}
+
+	for {
+
+		r, err := s.TryQueryResponse(c.Target, t)
+		if r == nil {
+			return c, err
+		}
+
+		progressed, ferr := c.follow(r.Answer, visited)
+		if ferr != nil {
+			return c, ferr
+		}
+
+		if errors.Is(err, ErrName) {
+
+			if len(c.Hops) == 0 {
+				return c, err
+			}
+
+			c.Dangling = true
+
+			return c, nil
+		}
+
+		if err != nil {
+			return c, err
+		}
+
+		for _, a := range r.Answers(t) {
+			if a.Name == c.Target {
+				c.Answers = append(c.Answers, a)
+			}
+		}
+
+		// Partial chain: the response contains aliases, but not the final records
+		if len(c.Answers) == 0 && progressed {
+			continue
+		}
+
+		return c, nil
+	}
+}
+
+// ResolveChain resolves name with type t using the DefaultServers and follows the CNAME and DNAME aliases.
+//
+// See Servers.ResolveChain() for more.
+func ResolveChain(name string, t uint16) (*Chain, error) {
+
+	return DefaultServers.ResolveChain(name, t)
+}
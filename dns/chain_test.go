@@ -0,0 +1,157 @@
+package dns
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	mdns "github.com/miekg/dns"
+)
+
+// chainHandler answers from a small zone.
+// If partial is true, only the first alias is returned.
+func chainHandler(partial bool) mdns.HandlerFunc {
+
+	cnames := map[string]string{
+		"www.example.com.":      "cdn.example.com.",
+		"cdn.example.com.":      "edge.example.net.",
+		"loop1.example.com.":    "loop2.example.com.",
+		"loop2.example.com.":    "loop1.example.com.",
+		"dangling.example.com.": "deleted.example.net.",
+	}
+
+	as := map[string]net.IP{
+		"edge.example.net.": {192, 0, 2, 1},
+		"www.example.org.":  {192, 0, 2, 2},
+	}
+
+	return func(w mdns.ResponseWriter, r *mdns.Msg) {
+
+		m := new(mdns.Msg)
+		m.SetReply(r)
+
+		name := r.Question[0].Name
+
+		// DNAME: example.info. -> example.org.
+		if mdns.IsSubDomain("example.info.", name) && name != "example.info." {
+			m.Answer = append(m.Answer, &mdns.DNAME{Hdr: mdns.RR_Header{Name: "example.info.", Rrtype: mdns.TypeDNAME, Class: mdns.ClassINET, Ttl: 100}, Target: "example.org."})
+			target := name[:len(name)-len("example.info.")] + "example.org."
+			m.Answer = append(m.Answer, &mdns.CNAME{Hdr: mdns.RR_Header{Name: name, Rrtype: mdns.TypeCNAME, Class: mdns.ClassINET, Ttl: 100}, Target: target})
+			name = target
+		}
+
+		seen := make(map[string]bool)
+
+		for i := 0; !seen[name]; i++ {
+
+			target, ok := cnames[name]
+			if !ok {
+				break
+			}
+
+			seen[name] = true
+
+			m.Answer = append(m.Answer, &mdns.CNAME{Hdr: mdns.RR_Header{Name: name, Rrtype: mdns.TypeCNAME, Class: mdns.ClassINET, Ttl: uint32(60 + i)}, Target: target})
+			name = target
+
+			if partial {
+				w.WriteMsg(m)
+				return
+			}
+		}
+
+		if ip, ok := as[name]; ok {
+			m.Answer = append(m.Answer, &mdns.A{Hdr: mdns.RR_Header{Name: name, Rrtype: mdns.TypeA, Class: mdns.ClassINET, Ttl: 300}, A: ip})
+		} else if _, ok := cnames[name]; !ok {
+			m.Rcode = mdns.RcodeNameError
+		}
+
+		w.WriteMsg(m)
+	}
+}
+
+func TestResolveChain(t *testing.T) {
+
+	for _, partial := range []bool{false, true} {
+
+		srvs, err := NewServersStr(1, time.Second, startTestServer(t, chainHandler(partial)))
+		if err != nil {
+			t.Fatalf("FAIL: %s\n", err)
+		}
+
+		c, err := srvs.ResolveChain("www.example.com", TypeA)
+		if err != nil {
+			t.Fatalf("FAIL: partial=%v: %s\n", partial, err)
+		}
+
+		if len(c.Hops) != 2 || c.Hops[0].Target != "cdn.example.com" || c.Hops[1].Target != "edge.example.net" || c.Hops[0].TTL != 60 {
+			t.Fatalf("FAIL: partial=%v: invalid hops: %#v\n", partial, c.Hops)
+		}
+
+		if c.Target != "edge.example.net" || len(c.Answers) != 1 || c.Answers[0].Value != "192.0.2.1" || c.Dangling {
+			t.Fatalf("FAIL: partial=%v: invalid chain: %#v\n", partial, c)
+		}
+	}
+}
+
+func TestResolveChainDNAME(t *testing.T) {
+
+	srvs, err := NewServersStr(1, time.Second, startTestServer(t, chainHandler(false)))
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	c, err := srvs.ResolveChain("www.example.info", TypeA)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if len(c.Hops) != 1 || c.Hops[0].Type != TypeDNAME || c.Hops[0].Name != "example.info" || c.Hops[0].Target != "www.example.org" {
+		t.Fatalf("FAIL: invalid hops: %#v\n", c.Hops)
+	}
+
+	if len(c.Answers) != 1 || c.Answers[0].Value != "192.0.2.2" {
+		t.Fatalf("FAIL: invalid answers: %#v\n", c.Answers)
+	}
+}
+
+func TestResolveChainErrors(t *testing.T) {
+
+	srvs, err := NewServersStr(1, time.Second, startTestServer(t, chainHandler(false)))
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	c, err := srvs.ResolveChain("loop1.example.com", TypeA)
+	if !errors.Is(err, ErrChainLoop) {
+		t.Fatalf("FAIL: error got: %v, want: %s\n", err, ErrChainLoop)
+	}
+
+	if len(c.Hops) != 2 {
+		t.Fatalf("FAIL: invalid hops: %#v\n", c.Hops)
+	}
+
+	c, err = srvs.ResolveChain("dangling.example.com", TypeA)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if !c.Dangling || c.Target != "deleted.example.net" {
+		t.Fatalf("FAIL: dangling CNAME not detected: %#v\n", c)
+	}
+
+	_, err = srvs.ResolveChain("notexist.example.com", TypeA)
+	if !errors.Is(err, ErrName) {
+		t.Fatalf("FAIL: error got: %v, want: %s\n", err, ErrName)
+	}
+
+	orig := MaxChainLength
+	MaxChainLength = 1
+	defer func() { MaxChainLength = orig }()
+
+	_, err = srvs.ResolveChain("www.example.com", TypeA)
+	if !errors.Is(err, ErrChainTooLong) {
+		t.Fatalf("FAIL: error got: %v, want: %s\n", err, ErrChainTooLong)
+	}
+}
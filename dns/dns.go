@@ -35,10 +35,10 @@ func init() {
 // NXDOMAIN is not an error here, because it means "not found".
 //
 // If found a setted record, this function returns without trying for the other types.
-func IsExists(name string) (bool, error) {
+func (s *Servers) IsExists(name string) (bool, error) {
 
 	// A
-	setA, err := IsSetA(name)
+	setA, err := s.IsSetA(name)
 	if err != nil {
 		return false, fmt.Errorf("check A failed: %w", err)
 	}
@@ -47,7 +47,7 @@ func IsExists(name string) (bool, error) {
 	}
 
 	// AAAA
-	setAAAA, err := IsSetAAAA(name)
+	setAAAA, err := s.IsSetAAAA(name)
 	if err != nil {
 		return false, fmt.Errorf("check AAAA failed: %w", err)
 	}
@@ -56,7 +56,7 @@ func IsExists(name string) (bool, error) {
 	}
 
 	// TXT
-	setTXT, err := IsSetTXT(name)
+	setTXT, err := s.IsSetTXT(name)
 	if err != nil {
 		return false, fmt.Errorf("check TXT failed: %w", err)
 	}
@@ -65,7 +65,7 @@ func IsExists(name string) (bool, error) {
 	}
 
 	// CNAME
-	setCNAME, err := IsSetCNAME(name)
+	setCNAME, err := s.IsSetCNAME(name)
 	if err != nil {
 		return false, fmt.Errorf("check CNAME failed: %w", err)
 	}
@@ -74,7 +74,7 @@ func IsExists(name string) (bool, error) {
 	}
 
 	// MX
-	setMX, err := IsSetMX(name)
+	setMX, err := s.IsSetMX(name)
 	if err != nil {
 		return false, fmt.Errorf("check MX failed: %w", err)
 	}
@@ -83,7 +83,7 @@ func IsExists(name string) (bool, error) {
 	}
 
 	// NS
-	setNS, err := IsSetNS(name)
+	setNS, err := s.IsSetNS(name)
 	if err != nil {
 		return false, fmt.Errorf("check NS failed: %w", err)
 	}
@@ -92,7 +92,7 @@ func IsExists(name string) (bool, error) {
 	}
 
 	// CAA
-	setCAA, err := IsSetCAA(name)
+	setCAA, err := s.IsSetCAA(name)
 	if err != nil {
 		return false, fmt.Errorf("chack CAA failed: %w", err)
 	}
@@ -101,7 +101,7 @@ func IsExists(name string) (bool, error) {
 	}
 
 	// SRV
-	setSRV, err := IsSetSRV(name)
+	setSRV, err := s.IsSetSRV(name)
 	if err != nil {
 		return false, fmt.Errorf("check SRV failed: %w", err)
 	}
@@ -109,6 +109,15 @@ func IsExists(name string) (bool, error) {
 	return setSRV, nil
 }
 
+// IsExists checks whether a record with type A, AAAA, TXT, CNAME, MX, NS, CAA or SRV is set for name using the DefaultServers.
+// NXDOMAIN is not an error here, because it means "not found".
+//
+// If found a setted record, this function returns without trying for the other types.
+func IsExists(name string) (bool, error) {
+
+	return DefaultServers.IsExists(name)
+}
+
 func TypeToString(t uint16) string {
 
 	switch t {
@@ -130,6 +139,8 @@ func TypeToString(t uint16) string {
 		return "SOA"
 	case TypeSRV:
 		return "SRV"
+	case TypeTLSA:
+		return "TLSA"
 	case TypeTXT:
 		return "TXT"
 	default:
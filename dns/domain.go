@@ -1,10 +1,11 @@
 package dns
 
 import (
+	"fmt"
 	"strings"
 
+	"github.com/elmasy-com/elnet/idn"
 	"github.com/elmasy-com/elnet/validator"
-	"golang.org/x/net/publicsuffix"
 )
 
 type Parts struct {
@@ -34,91 +35,379 @@ func IsValidSLD(d string) bool {
 }
 
 // Clean removes the trailing dot and returns a lower cased version of d.
+// Returns an empty string ("") if d is empty.
+//
+// Internationalized domain names are converted to the ASCII form (eg.: "Bücher.example" -> "xn--bcher-kva.example").
+// If the conversion failed, d is only lower cased.
 func Clean(d string) string {
 
 	// Remove the trailing dot.
-	if d[len(d)-1] == '.' {
+	if d != "" && d[len(d)-1] == '.' {
 		d = d[:len(d)-1]
 	}
 
+	if !idn.IsASCII(d) {
+		if a, err := idn.ToASCII(d); err == nil {
+			return a
+		}
+	}
+
 	return strings.ToLower(d)
 }
 
-// GetParts returns the parts of d.
-//
-// If d is just a TLD, returns a struct with empty Domain (eg.: "com" -> &Result{Sub: "", Domain: "", TLD: "com"}).
-//
-// If d does not contains subdomain, than Sub will be empty (eg.: "example.com" -> &Result{Sub: "", Domain: "example", TLD: "com"}).
+// suffixList returns the first list in l, or DefaultSuffixList if l is empty.
+func suffixList(l []*SuffixList) *SuffixList {
+
+	if len(l) > 0 && l[0] != nil {
+		return l[0]
+	}
+
+	return DefaultSuffixList
+}
+
+// Domain is a parsed domain name.
+// The labels, the TLD (the public suffix), the registrable domain and the subdomain are computed once by ParseDomain().
 //
-// Returns nil if d is empty, a dot (".") or starts with a dot (eg.: ".example.com").
+// The zero value is an empty domain.
+// Use Equal() to compare domains, the == operator does not work.
+type Domain struct {
+	name   string      // Without the trailing dot
+	labels []int       // Start index of the labels in name
+	tld    int         // Start index of the TLD in name
+	domain int         // Start index of the registrable domain in name, -1 if name is a TLD
+	icann  bool        // Whether the TLD is ICANN managed
+	list   *SuffixList // The list used to parse the domain, needed to parse the parents
+}
+
+// split splits d into a Domain.
+// d is not validated and not Clean()ed, the parts are in the form of d.
 //
-// NOTE: This function does not validate and Clean() the given domain d. It is recommended to use IsValid() and Clean() before this function.
-func GetParts(d string) *Parts {
+// Returns false if d is empty, a dot (".") or starts with a dot (eg.: ".example.com").
+func (l *SuffixList) split(d string) (Domain, bool) {
 
 	if d == "" || d == "." || d[0] == '.' {
-		return nil
+		return Domain{}, false
 	}
 
 	if d[len(d)-1] == '.' {
 		d = d[:len(d)-1]
 	}
 
-	tldIndex := GetTLDIndex(d)
-	if tldIndex <= 0 {
-		return &Parts{TLD: d}
+	tld, icann := l.PublicSuffix(d)
+	if tld == "" || len(tld) > len(d) {
+		return Domain{}, false
 	}
 
-	tldIndex -= 1
-	domIndex := 0
+	r := Domain{name: d, labels: []int{0}, tld: len(d) - len(tld), domain: -1, icann: icann, list: l}
 
-	for i := 0; i < tldIndex; i++ {
+	for i := 0; i < len(d); i++ {
 		if d[i] == '.' {
-			domIndex = i
+			r.labels = append(r.labels, i+1)
 		}
 	}
 
-	if domIndex == 0 {
-		return &Parts{Sub: "", Domain: d[0:tldIndex], TLD: d[tldIndex+1:]}
+	if r.tld > 0 {
+		// Skip the dot before the TLD
+		r.domain = strings.LastIndexByte(d[:r.tld-1], '.') + 1
 	}
 
-	return &Parts{Sub: d[0:domIndex], Domain: d[domIndex+1 : tldIndex], TLD: d[tldIndex+1:]}
+	return r, true
 }
 
-// GetTLD returns the Top Level Domain of d (eg.: sub.exmaple.com -> com).
+// ParseDomain validates, normalizes and parses d.
 //
-// Returns an empty string ("") if d is empty, a dot (".") or starts with a dot (eg.: ".example.com").
-func GetTLD(d string) string {
+// The domain is Clean()ed (the trailing dot is removed, lower cased and the internationalized domain names are converted to the ASCII form)
+// and validated with github.com/elmasy-com/elnet/validator.Domain.
+// A single label is a TLD (eg.: "com") and validated with github.com/elmasy-com/elnet/validator.DomainPart,
+// so every parent of a parsed domain (see Parent()) can be parsed too.
+//
+// Returns ErrInvalidDomain if d is invalid.
+func (l *SuffixList) ParseDomain(d string) (Domain, error) {
 
-	if d == "" || d == "." || d[0] == '.' {
+	name := Clean(d)
+
+	valid := validator.Domain(name)
+	if !valid && !strings.Contains(name, ".") {
+		valid = validator.DomainPart(name)
+	}
+
+	if !valid {
+		return Domain{}, fmt.Errorf("%w: %s", ErrInvalidDomain, d)
+	}
+
+	r, ok := l.split(name)
+	if !ok {
+		return Domain{}, fmt.Errorf("%w: %s", ErrInvalidDomain, d)
+	}
+
+	return r, nil
+}
+
+// ParseDomain parses d using the first list in l or the DefaultSuffixList.
+//
+// See SuffixList.ParseDomain() for more.
+func ParseDomain(d string, l ...*SuffixList) (Domain, error) {
+
+	return suffixList(l).ParseDomain(d)
+}
+
+// String returns the domain in relative form (without the trailing dot, eg.: "www.example.com").
+func (d Domain) String() string {
+
+	return d.name
+}
+
+// Relative returns the domain without the trailing dot (eg.: "www.example.com").
+func (d Domain) Relative() string {
+
+	return d.name
+}
+
+// Absolute returns the fully qualified domain with the trailing dot (eg.: "www.example.com.").
+// Returns an empty string ("") if d is empty.
+func (d Domain) Absolute() string {
+
+	if d.name == "" {
 		return ""
 	}
 
-	if d[len(d)-1] == '.' {
-		d = d[:len(d)-1]
+	return d.name + "."
+}
+
+// Unicode returns the domain in Unicode form for display (eg.: "xn--bcher-kva.example" -> "bücher.example").
+// If the conversion failed, returns the domain as is.
+func (d Domain) Unicode() string {
+
+	if !idn.HasPunycode(d.name) {
+		return d.name
 	}
 
-	tld := d
-	icann := false
+	u, err := idn.ToUnicode(d.name)
+	if err != nil {
+		return d.name
+	}
 
-	for {
-		tld, icann = publicsuffix.PublicSuffix(tld)
-		// Break if ICANN managed
-		if icann {
-			break
-		}
+	return u
+}
+
+// IsZero returns whether d is the zero value.
+func (d Domain) IsZero() bool {
+
+	return d.name == ""
+}
+
+// NumLabels returns the number of labels (eg.: "www.example.com" -> 3).
+func (d Domain) NumLabels() int {
+
+	return len(d.labels)
+}
+
+// Label returns the i. label from the left (eg.: 0 -> "www" in "www.example.com").
+// Returns an empty string ("") if i is out of range.
+func (d Domain) Label(i int) string {
+
+	if i < 0 || i >= len(d.labels) {
+		return ""
+	}
+
+	if i == len(d.labels)-1 {
+		return d.name[d.labels[i]:]
+	}
+
+	return d.name[d.labels[i] : d.labels[i+1]-1]
+}
+
+// Labels returns the labels from the left (eg.: "www.example.com" -> ["www", "example", "com"]).
+func (d Domain) Labels() []string {
+
+	r := make([]string, len(d.labels))
+
+	for i := range d.labels {
+		r[i] = d.Label(i)
+	}
+
+	return r
+}
+
+// TLD returns the Top Level Domain (the public suffix) (eg.: "www.example.co.uk" -> "co.uk").
+func (d Domain) TLD() string {
+
+	if d.name == "" {
+		return ""
+	}
+
+	return d.name[d.tld:]
+}
+
+// ICANN returns whether the TLD is managed by ICANN (see PublicSuffix()).
+func (d Domain) ICANN() bool {
+
+	return d.icann
+}
+
+// IsTLD returns whether the domain is a Top Level Domain (the public suffix) (eg.: "co.uk").
+func (d Domain) IsTLD() bool {
+
+	return d.name != "" && d.domain == -1
+}
+
+// Registrable returns the registrable domain (eg.: "www.example.co.uk" -> "example.co.uk").
+// Returns an empty string ("") if d is a TLD.
+func (d Domain) Registrable() string {
+
+	if d.domain < 0 {
+		return ""
+	}
+
+	return d.name[d.domain:]
+}
+
+// Sub returns the subdomain (eg.: "a.b.example.com" -> "a.b").
+// Returns an empty string ("") if d has no subdomain.
+func (d Domain) Sub() string {
+
+	if d.domain <= 0 {
+		return ""
+	}
+
+	return d.name[:d.domain-1]
+}
+
+// HasSub returns whether the domain has a subdomain.
+func (d Domain) HasSub() bool {
+
+	return d.domain > 0
+}
+
+// Parts returns the parts of the domain.
+// See GetParts() for more.
+func (d Domain) Parts() *Parts {
 
-		dot := strings.IndexByte(tld, '.')
+	if d.name == "" {
+		return nil
+	}
+
+	if d.domain < 0 {
+		return &Parts{TLD: d.name}
+	}
+
+	return &Parts{Sub: d.Sub(), Domain: d.name[d.domain : d.tld-1], TLD: d.name[d.tld:]}
+}
+
+// Parent returns the parent of the domain by removing the leftmost label (eg.: "www.example.com" -> "example.com").
+// Returns false if d has only one label.
+func (d Domain) Parent() (Domain, bool) {
+
+	if len(d.labels) < 2 {
+		return Domain{}, false
+	}
+
+	off := d.labels[1]
+
+	// The parent is a TLD or shorter, the suffix must be found again (eg.: "co.uk" -> "uk")
+	if d.tld <= off {
+		return d.list.split(d.name[off:])
+	}
+
+	p := Domain{name: d.name[off:], labels: make([]int, len(d.labels)-1), tld: d.tld - off, domain: d.domain - off, icann: d.icann, list: d.list}
 
-		// No dot means the TLD (eg.: "com")
-		if dot == -1 {
-			break
+	for i := range p.labels {
+		p.labels[i] = d.labels[i+1] - off
+	}
+
+	return p, true
+}
+
+// Parents returns the parents of the domain up to the last label (eg.: "www.example.com" -> ["example.com", "com"]).
+func (d Domain) Parents() []Domain {
+
+	var r []Domain
+
+	for p, ok := d.Parent(); ok; p, ok = p.Parent() {
+		r = append(r, p)
+	}
+
+	return r
+}
+
+// Equal returns whether d and o are the same domain.
+func (d Domain) Equal(o Domain) bool {
+
+	return d.name == o.name
+}
+
+// IsSubdomainOf returns whether d is a subdomain of o (eg.: "www.example.com" is a subdomain of "example.com").
+// A domain is not a subdomain of itself.
+func (d Domain) IsSubdomainOf(o Domain) bool {
+
+	return o.name != "" && len(d.name) > len(o.name) && strings.HasSuffix(d.name, "."+o.name)
+}
+
+// Compare compares d and o in the canonical DNS order (RFC 4034 section 6.1).
+// The labels are compared from right to left (eg.: "example.com" < "a.example.com" < "b.example.com" < "example.net").
+// Returns -1 if d is less than o, 0 if equal and +1 if d is greater than o.
+func (d Domain) Compare(o Domain) int {
+
+	i, j := len(d.labels)-1, len(o.labels)-1
+
+	for ; i >= 0 && j >= 0; i, j = i-1, j-1 {
+		if c := strings.Compare(d.Label(i), o.Label(j)); c != 0 {
+			return c
 		}
+	}
 
-		// Get the next part of the domain
-		tld = tld[dot+1:]
+	switch {
+	case i == j:
+		return 0
+	case i < j:
+		return -1
+	default:
+		return 1
 	}
+}
+
+// GetParts returns the parts of d.
+//
+// If d is just a TLD, returns a struct with empty Domain (eg.: "com" -> &Result{Sub: "", Domain: "", TLD: "com"}).
+//
+// If d does not contains subdomain, than Sub will be empty (eg.: "example.com" -> &Result{Sub: "", Domain: "example", TLD: "com"}).
+//
+// Internationalized domain names are accepted, the parts are returned in the form of d (eg.: "www.bücher.example" -> &Result{Sub: "www", Domain: "bücher", TLD: "example"}).
+//
+// Returns nil if d is empty, a dot (".") or starts with a dot (eg.: ".example.com").
+//
+// NOTE: This function does not validate and Clean() the given domain d. Use ParseDomain() to validate and normalize.
+func (l *SuffixList) GetParts(d string) *Parts {
+
+	r, _ := l.split(d)
+
+	return r.Parts()
+}
+
+// GetParts returns the parts of d using the first list in l or the DefaultSuffixList.
+//
+// See SuffixList.GetParts() for more.
+func GetParts(d string, l ...*SuffixList) *Parts {
 
-	return tld
+	return suffixList(l).GetParts(d)
+}
+
+// GetTLD returns the Top Level Domain (the public suffix) of d (eg.: sub.exmaple.com -> com).
+//
+// Returns an empty string ("") if d is empty, a dot (".") or starts with a dot (eg.: ".example.com").
+func (l *SuffixList) GetTLD(d string) string {
+
+	r, _ := l.split(d)
+
+	return r.TLD()
+}
+
+// GetTLD returns the Top Level Domain of d using the first list in l or the DefaultSuffixList (eg.: sub.exmaple.com -> com).
+//
+// Returns an empty string ("") if d is empty, a dot (".") or starts with a dot (eg.: ".example.com").
+func GetTLD(d string, l ...*SuffixList) string {
+
+	return suffixList(l).GetTLD(d)
 }
 
 // GetTLDIndex returns the index of the Top Level Domain in d (eg.: sub.example.com -> 12).
@@ -126,90 +415,90 @@ func GetTLD(d string) string {
 // Returns 0 if d is a TLD.
 //
 // Returns -1 if d is empty, a dot (".") or starts with a dot (eg.: ".example.com").
-func GetTLDIndex(d string) int {
+func (l *SuffixList) GetTLDIndex(d string) int {
 
-	if d == "" || d == "." || d[0] == '.' {
+	r, ok := l.split(d)
+	if !ok {
 		return -1
 	}
 
-	if d[len(d)-1] == '.' {
-		d = d[:len(d)-1]
-	}
+	return r.tld
+}
 
-	//return strings.LastIndex(d, GetTLD(d))
-	return len(d) - len(GetTLD(d))
+// GetTLDIndex returns the index of the Top Level Domain in d using the first list in l or the DefaultSuffixList.
+//
+// See SuffixList.GetTLDIndex() for more.
+func GetTLDIndex(d string, l ...*SuffixList) int {
+
+	return suffixList(l).GetTLDIndex(d)
 }
 
 // GetDomain returns the domain of d (eg.: sub.example.com -> example.com).
 //
 // Returns an empty string ("") if d is empty, a dot ("."), starts with a dot (eg.: ".example.com") or d is just a TLD.
-func GetDomain(d string) string {
-
-	if d == "" || d == "." || d[0] == '.' {
-		return ""
-	}
+func (l *SuffixList) GetDomain(d string) string {
 
-	if d[len(d)-1] == '.' {
-		d = d[:len(d)-1]
-	}
+	r, _ := l.split(d)
 
-	tld := GetTLD(d)
-
-	if tld == "" || tld == d {
-		return ""
-	}
+	return r.Registrable()
+}
 
-	// Get the index of the TLD -1 to skip the dot
-	i := len(d) - len(tld) - 1
+// GetDomain returns the domain of d using the first list in l or the DefaultSuffixList (eg.: sub.example.com -> example.com).
+//
+// See SuffixList.GetDomain() for more.
+func GetDomain(d string, l ...*SuffixList) string {
 
-	return d[1+strings.LastIndex(d[:i], "."):]
+	return suffixList(l).GetDomain(d)
 }
 
 // GetDomainIndex returns the index of the domain of d (eg.: sub.example.com -> 4).
 //
 // Returns -1 if d is empty, a dot ("."), starts with a dot (eg.: ".example.com") or d is just a TLD.
-func GetDomainIndex(d string) int {
+func (l *SuffixList) GetDomainIndex(d string) int {
 
-	if d == "" || d == "." || d[0] == '.' {
+	r, ok := l.split(d)
+	if !ok {
 		return -1
 	}
 
-	if d[len(d)-1] == '.' {
-		d = d[:len(d)-1]
-	}
+	return r.domain
+}
 
-	dom := GetDomain(d)
-	if dom == "" {
-		return -1
-	}
+// GetDomainIndex returns the index of the domain of d using the first list in l or the DefaultSuffixList.
+//
+// See SuffixList.GetDomainIndex() for more.
+func GetDomainIndex(d string, l ...*SuffixList) int {
 
-	return len(d) - len(dom)
+	return suffixList(l).GetDomainIndex(d)
 }
 
-// GetSub returns the Subdomain of the given domain d (eg.: eg.: sub.example.com -> example.com).
+// GetSub returns the Subdomain of the given domain d (eg.: eg.: sub.example.com -> sub).
 // If d is invalid or cant get the subdomain returns an empty string ("").
-func GetSub(d string) string {
+func (l *SuffixList) GetSub(d string) string {
 
-	s := GetDomain(d)
-	if s == "" {
-		return ""
-	}
+	r, _ := l.split(d)
 
-	// Not error, but no subdomain
-	i := strings.LastIndex(d, s)
-	if i <= 0 {
-		return ""
-	}
+	return r.Sub()
+}
 
-	return d[:i-1]
+// GetSub returns the Subdomain of d using the first list in l or the DefaultSuffixList.
+//
+// See SuffixList.GetSub() for more.
+func GetSub(d string, l ...*SuffixList) string {
+
+	return suffixList(l).GetSub(d)
 }
 
 // HasSub returns whether domain d has a subdomain.
-func HasSub(d string) bool {
+func (l *SuffixList) HasSub(d string) bool {
 
-	if strings.Count(d, ".") < 2 {
-		return false
-	}
+	r, _ := l.split(d)
+
+	return r.HasSub()
+}
+
+// HasSub returns whether domain d has a subdomain using the first list in l or the DefaultSuffixList.
+func HasSub(d string, l ...*SuffixList) bool {
 
-	return GetSub(d) != ""
+	return suffixList(l).HasSub(d)
 }
@@ -1,6 +1,8 @@
 package dns
 
 import (
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -391,3 +393,201 @@ func BenchmarkHasSub(b *testing.B) {
 		HasSub("test.s3.dualstack.ap-northeast-2.amazonaws.com.")
 	}
 }
+
+func TestClean(t *testing.T) {
+
+	cases := [][2]string{
+		{"Example.COM.", "example.com"},
+		{"Bücher.example", "xn--bcher-kva.example"},
+		{"例え.テスト.", "xn--r8jz45g.xn--zckzah"},
+		{"", ""},
+		{".", ""},
+	}
+
+	for i := range cases {
+		if r := Clean(cases[i][0]); r != cases[i][1] {
+			t.Fatalf("FAIL: %s: want %s, got %s\n", cases[i][0], cases[i][1], r)
+		}
+	}
+}
+
+func TestGetPartsIDN(t *testing.T) {
+
+	// 0. element = test domain
+	// 1. element = tld
+	// 2. element = domain
+	// 3. element = sub
+	cases := [][4]string{
+		{"www.bücher.example", "example", "bücher", "www"},
+		{"a.пример.рф", "рф", "пример", "a"},
+		{"www.example.xn--p1ai", "xn--p1ai", "example", "www"},
+		{"shop.例え.公司.cn", "公司.cn", "例え", "shop"},
+	}
+
+	for i := range cases {
+
+		parts := GetParts(cases[i][0])
+
+		switch {
+		case parts == nil:
+			t.Fatalf("FAIL: %s: parts is nil\n", cases[i][0])
+		case parts.TLD != cases[i][1]:
+			t.Fatalf("FAIL: TLD failed with %s, want=%s get=%s\n", cases[i][0], cases[i][1], parts.TLD)
+		case parts.Domain != cases[i][2]:
+			t.Fatalf("FAIL: Domain failed with %s, want=%s get=%s\n", cases[i][0], cases[i][2], parts.Domain)
+		case parts.Sub != cases[i][3]:
+			t.Fatalf("FAIL: Sub failed with %s, want=%s get=%s\n", cases[i][0], cases[i][3], parts.Sub)
+		}
+	}
+}
+
+func TestParseDomain(t *testing.T) {
+
+	cases := []struct {
+		Value       string
+		Name        string
+		TLD         string
+		Registrable string
+		Sub         string
+	}{
+		{"WWW.Example.COM.", "www.example.com", "com", "example.com", "www"},
+		{"a.b.example.co.uk", "a.b.example.co.uk", "co.uk", "example.co.uk", "a.b"},
+		{"example.com", "example.com", "com", "example.com", ""},
+		{"co.uk", "co.uk", "co.uk", "", ""},
+		{"COM.", "com", "com", "", ""},
+		{"www.Bücher.example", "www.xn--bcher-kva.example", "example", "xn--bcher-kva.example", "www"},
+		{"_dmarc.example.com", "_dmarc.example.com", "com", "example.com", "_dmarc"},
+	}
+
+	for i := range cases {
+
+		d, err := ParseDomain(cases[i].Value)
+		if err != nil {
+			t.Fatalf("FAIL: %s: %s\n", cases[i].Value, err)
+		}
+
+		switch {
+		case d.String() != cases[i].Name:
+			t.Fatalf("FAIL: %s: name want %s, got %s\n", cases[i].Value, cases[i].Name, d.String())
+		case d.Absolute() != cases[i].Name+".":
+			t.Fatalf("FAIL: %s: absolute want %s., got %s\n", cases[i].Value, cases[i].Name, d.Absolute())
+		case d.TLD() != cases[i].TLD:
+			t.Fatalf("FAIL: %s: TLD want %s, got %s\n", cases[i].Value, cases[i].TLD, d.TLD())
+		case d.Registrable() != cases[i].Registrable:
+			t.Fatalf("FAIL: %s: registrable want %s, got %s\n", cases[i].Value, cases[i].Registrable, d.Registrable())
+		case d.Sub() != cases[i].Sub:
+			t.Fatalf("FAIL: %s: sub want %s, got %s\n", cases[i].Value, cases[i].Sub, d.Sub())
+		case d.HasSub() != (cases[i].Sub != ""):
+			t.Fatalf("FAIL: %s: HasSub is %v\n", cases[i].Value, d.HasSub())
+		case d.IsTLD() != (cases[i].Registrable == ""):
+			t.Fatalf("FAIL: %s: IsTLD is %v\n", cases[i].Value, d.IsTLD())
+		case strings.Join(d.Labels(), ".") != cases[i].Name || d.NumLabels() != strings.Count(cases[i].Name, ".")+1:
+			t.Fatalf("FAIL: %s: invalid labels: %v\n", cases[i].Value, d.Labels())
+		}
+	}
+
+	for _, v := range []string{"", ".", ".example.com", "example..com", "-example.com", "-com", "com-", "xn--invalid-.com"} {
+		if _, err := ParseDomain(v); !errors.Is(err, ErrInvalidDomain) {
+			t.Fatalf("FAIL: %s: want ErrInvalidDomain, got %v\n", v, err)
+		}
+	}
+
+	d, err := ParseDomain("www.xn--bcher-kva.example")
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if d.Unicode() != "www.bücher.example" {
+		t.Fatalf("FAIL: Unicode want www.bücher.example, got %s\n", d.Unicode())
+	}
+}
+
+func TestDomainParents(t *testing.T) {
+
+	d, err := ParseDomain("a.b.example.co.uk")
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	want := []struct {
+		Name        string
+		TLD         string
+		Registrable string
+	}{
+		{"b.example.co.uk", "co.uk", "example.co.uk"},
+		{"example.co.uk", "co.uk", "example.co.uk"},
+		{"co.uk", "co.uk", ""},
+		{"uk", "uk", ""},
+	}
+
+	ps := d.Parents()
+
+	if len(ps) != len(want) {
+		t.Fatalf("FAIL: want %d parents, got %d: %v\n", len(want), len(ps), ps)
+	}
+
+	for i := range ps {
+
+		if ps[i].String() != want[i].Name || ps[i].TLD() != want[i].TLD || ps[i].Registrable() != want[i].Registrable {
+			t.Fatalf("FAIL: parent %d: want %v, got %s/%s/%s\n", i, want[i], ps[i], ps[i].TLD(), ps[i].Registrable())
+		}
+
+		if ps[i].Label(0) != strings.Split(want[i].Name, ".")[0] || !d.IsSubdomainOf(ps[i]) || ps[i].IsSubdomainOf(d) {
+			t.Fatalf("FAIL: parent %d: invalid relation: %s\n", i, ps[i])
+		}
+	}
+
+	if _, ok := ps[len(ps)-1].Parent(); ok {
+		t.Fatalf("FAIL: the TLD must not have a parent\n")
+	}
+
+	// Every parent can be parsed again
+	for i := range ps {
+
+		p, err := ParseDomain(ps[i].String())
+		if err != nil {
+			t.Fatalf("FAIL: parent %d: %s\n", i, err)
+		}
+
+		if !p.Equal(ps[i]) || p.TLD() != ps[i].TLD() || p.Registrable() != ps[i].Registrable() {
+			t.Fatalf("FAIL: parent %d: want %s, got %s\n", i, ps[i], p)
+		}
+	}
+}
+
+func TestDomainCompare(t *testing.T) {
+
+	// The canonical order from RFC 4034 section 6.1
+	names := []string{"example.com", "a.example.com", "yljkjljk.a.example.com", "z.a.example.com", "zabc.a.example.com", "z.example.com", "example.net"}
+
+	for i := range names {
+
+		a, err := ParseDomain(names[i])
+		if err != nil {
+			t.Fatalf("FAIL: %s\n", err)
+		}
+
+		if a.Compare(a) != 0 || !a.Equal(a) {
+			t.Fatalf("FAIL: %s must be equal to itself\n", a)
+		}
+
+		for j := i + 1; j < len(names); j++ {
+
+			b, err := ParseDomain(names[j])
+			if err != nil {
+				t.Fatalf("FAIL: %s\n", err)
+			}
+
+			if a.Compare(b) != -1 || b.Compare(a) != 1 || a.Equal(b) {
+				t.Fatalf("FAIL: %s must be less than %s\n", a, b)
+			}
+		}
+	}
+}
+
+func BenchmarkParseDomain(b *testing.B) {
+
+	for i := 0; i < b.N; i++ {
+		ParseDomain("test.s3.dualstack.ap-northeast-2.amazonaws.com.")
+	}
+}
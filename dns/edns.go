@@ -0,0 +1,220 @@
+package dns
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	mdns "github.com/miekg/dns"
+)
+
+// DefaultUDPSize is the default advertised UDP payload size.
+// See more: https://www.dnsflagday.net/2020/
+var DefaultUDPSize uint16 = 1232
+
+// paddingBlockSize is the block size for the padded queries.
+// See more: https://www.rfc-editor.org/rfc/rfc8467#section-4.1
+const paddingBlockSize = 128
+
+// EDNS is the EDNS(0) configuration of the queries.
+type EDNS struct {
+	UDPSize      uint16     // Advertised UDP payload size, 0 means DefaultUDPSize
+	DO           bool       // Set the DNSSEC OK bit
+	ClientSubnet *net.IPNet // EDNS Client Subnet (RFC 7871), nil means disabled
+	Cookie       bool       // Send DNS cookies (RFC 7873)
+	NSID         bool       // Request the Name Server Identifier (RFC 5001)
+	Padding      bool       // Pad the queries to a multiple of 128 bytes (RFC 7830), used only with "tcp-tls"
+}
+
+// ExtendedError is an Extended DNS Error (RFC 8914).
+type ExtendedError struct {
+	Code uint16 // Info code
+	Text string // Extra text, can be empty
+}
+
+func (e ExtendedError) String() string {
+
+	s, ok := mdns.ExtendedErrorCodeToString[e.Code]
+	if !ok {
+		s = fmt.Sprintf("%d", e.Code)
+	}
+
+	if e.Text != "" {
+		return s + ": " + e.Text
+	}
+
+	return s
+}
+
+// OPT is the EDNS(0) data returned by the server.
+type OPT struct {
+	UDPSize           uint16          // Server's UDP payload size
+	DO                bool            // DNSSEC OK bit
+	NSID              string          // Name Server Identifier
+	ClientSubnet      *net.IPNet      // The client subnet echoed by the server
+	ClientSubnetScope uint8           // Scope prefix length of the answer
+	ServerCookie      string          // Hex encoded server cookie
+	ExtendedErrors    []ExtendedError // Extended DNS Errors
+}
+
+// ednsState is the EDNS configuration and the cookies of a Server.
+type ednsState struct {
+	conf         EDNS
+	clientCookie string // Hex encoded 8 byte client cookie
+	serverCookie string // Hex encoded server cookie, the last received
+	m            *sync.Mutex
+}
+
+func newEDNSState(e EDNS) *ednsState {
+
+	s := &ednsState{conf: e, m: new(sync.Mutex)}
+
+	if e.Cookie {
+
+		b := make([]byte, 8)
+		rand.Read(b)
+
+		s.clientCookie = hex.EncodeToString(b)
+	}
+
+	return s
+}
+
+// SetEDNS sets the EDNS(0) options of the queries. If e is nil, EDNS is disabled.
+func (s *Server) SetEDNS(e *EDNS) {
+
+	if e == nil {
+		s.edns = nil
+		return
+	}
+
+	s.edns = newEDNSState(*e)
+}
+
+// SetEDNS sets the EDNS(0) options of every server. If e is nil, EDNS is disabled.
+// Every server gets a different client cookie.
+func (s *Servers) SetEDNS(e *EDNS) {
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	for i := range s.srvs {
+		s.srvs[i].SetEDNS(e)
+	}
+}
+
+// setOPT adds the OPT record to msg based on the configuration.
+func (e *ednsState) setOPT(msg *mdns.Msg, protocol string) {
+
+	size := e.conf.UDPSize
+	if size == 0 {
+		size = DefaultUDPSize
+	}
+
+	msg.SetEdns0(size, e.conf.DO)
+
+	opt := msg.IsEdns0()
+
+	if e.conf.NSID {
+		opt.Option = append(opt.Option, &mdns.EDNS0_NSID{Code: mdns.EDNS0NSID})
+	}
+
+	if e.conf.ClientSubnet != nil {
+
+		ones, _ := e.conf.ClientSubnet.Mask.Size()
+
+		subnet := &mdns.EDNS0_SUBNET{Code: mdns.EDNS0SUBNET, SourceNetmask: uint8(ones)}
+
+		if ip := e.conf.ClientSubnet.IP.To4(); ip != nil {
+			subnet.Family = 1
+			subnet.Address = ip
+		} else {
+			subnet.Family = 2
+			subnet.Address = e.conf.ClientSubnet.IP
+		}
+
+		opt.Option = append(opt.Option, subnet)
+	}
+
+	if e.conf.Cookie {
+
+		e.m.Lock()
+		cookie := e.clientCookie + e.serverCookie
+		e.m.Unlock()
+
+		opt.Option = append(opt.Option, &mdns.EDNS0_COOKIE{Code: mdns.EDNS0COOKIE, Cookie: cookie})
+	}
+
+	if e.conf.Padding && protocol == "tcp-tls" {
+
+		padding := &mdns.EDNS0_PADDING{}
+		opt.Option = append(opt.Option, padding)
+
+		if l := msg.Len(); l%paddingBlockSize != 0 {
+			padding.Padding = make([]byte, paddingBlockSize-l%paddingBlockSize)
+		}
+	}
+}
+
+// parseOPT returns the EDNS(0) data from msg and stores the server cookie.
+// Returns nil if msg has no OPT record.
+func (e *ednsState) parseOPT(msg *mdns.Msg) *OPT {
+
+	opt := msg.IsEdns0()
+	if opt == nil {
+		return nil
+	}
+
+	r := &OPT{UDPSize: opt.UDPSize(), DO: opt.Do()}
+
+	for i := range opt.Option {
+
+		switch v := opt.Option[i].(type) {
+		case *mdns.EDNS0_NSID:
+
+			b, err := hex.DecodeString(v.Nsid)
+			if err != nil {
+				r.NSID = v.Nsid
+			} else {
+				r.NSID = string(b)
+			}
+
+		case *mdns.EDNS0_SUBNET:
+
+			bits := 32
+			if v.Family == 2 {
+				bits = 128
+			}
+
+			r.ClientSubnet = &net.IPNet{IP: v.Address, Mask: net.CIDRMask(int(v.SourceNetmask), bits)}
+			r.ClientSubnetScope = v.SourceScope
+
+		case *mdns.EDNS0_COOKIE:
+
+			// The first 16 hex character is the client cookie
+			if len(v.Cookie) > 16 && e != nil && strings.EqualFold(v.Cookie[:16], e.clientCookie) {
+
+				r.ServerCookie = v.Cookie[16:]
+
+				e.m.Lock()
+				e.serverCookie = r.ServerCookie
+				e.m.Unlock()
+			}
+
+		case *mdns.EDNS0_EDE:
+			r.ExtendedErrors = append(r.ExtendedErrors, ExtendedError{Code: v.InfoCode, Text: v.ExtraText})
+		}
+	}
+
+	return r
+}
+
+// QueryEDNS asks a random server from servers for type t and returns the Answer section and the returned EDNS(0) data.
+// The OPT is nil if the server did not return an OPT record.
+func (s *Servers) QueryEDNS(name string, t uint16) ([]mdns.RR, *OPT, error) {
+
+	return s.Get(-1).QueryEDNS(name, t)
+}
@@ -0,0 +1,124 @@
+package dns
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	mdns "github.com/miekg/dns"
+)
+
+// ednsHandler echoes the EDNS options: returns NSID "test-instance", the client subnet with scope 16,
+// a server cookie and an extended error.
+func ednsHandler(t *testing.T) mdns.HandlerFunc {
+
+	return func(w mdns.ResponseWriter, r *mdns.Msg) {
+
+		m := new(mdns.Msg)
+		m.SetReply(r)
+
+		opt := r.IsEdns0()
+		if opt == nil {
+			w.WriteMsg(m)
+			return
+		}
+
+		m.SetEdns0(4096, opt.Do())
+		ropt := m.IsEdns0()
+
+		for i := range opt.Option {
+
+			switch v := opt.Option[i].(type) {
+			case *mdns.EDNS0_NSID:
+				ropt.Option = append(ropt.Option, &mdns.EDNS0_NSID{Code: mdns.EDNS0NSID, Nsid: "746573742d696e7374616e6365"})
+			case *mdns.EDNS0_SUBNET:
+				v.SourceScope = 16
+				ropt.Option = append(ropt.Option, v)
+			case *mdns.EDNS0_COOKIE:
+				if len(v.Cookie) != 16 && len(v.Cookie) != 32 {
+					t.Errorf("FAIL: invalid cookie length: %s\n", v.Cookie)
+				}
+				ropt.Option = append(ropt.Option, &mdns.EDNS0_COOKIE{Code: mdns.EDNS0COOKIE, Cookie: v.Cookie[:16] + "0102030405060708"})
+			}
+		}
+
+		ropt.Option = append(ropt.Option, &mdns.EDNS0_EDE{InfoCode: mdns.ExtendedErrorCodeStaleAnswer, ExtraText: "stale"})
+
+		w.WriteMsg(m)
+	}
+}
+
+func TestQueryEDNS(t *testing.T) {
+
+	addr := startTestServer(t, ednsHandler(t))
+
+	s, err := NewServerStr(addr, time.Second)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	_, subnet, _ := net.ParseCIDR("192.0.2.0/24")
+
+	s.SetEDNS(&EDNS{NSID: true, Cookie: true, ClientSubnet: subnet})
+
+	for i := 0; i < 2; i++ {
+
+		_, opt, err := s.QueryEDNS("example.com", TypeA)
+		if err != nil {
+			t.Fatalf("FAIL: %s\n", err)
+		}
+
+		if opt == nil {
+			t.Fatalf("FAIL: OPT is nil\n")
+		}
+
+		if opt.UDPSize != 4096 || opt.NSID != "test-instance" || opt.ServerCookie != "0102030405060708" {
+			t.Fatalf("FAIL: invalid OPT: %#v\n", opt)
+		}
+
+		if opt.ClientSubnet.String() != "192.0.2.0/24" || opt.ClientSubnetScope != 16 {
+			t.Fatalf("FAIL: invalid client subnet: %s/%d\n", opt.ClientSubnet, opt.ClientSubnetScope)
+		}
+
+		if len(opt.ExtendedErrors) != 1 || opt.ExtendedErrors[0].String() != "Stale Answer: stale" {
+			t.Fatalf("FAIL: invalid extended errors: %v\n", opt.ExtendedErrors)
+		}
+	}
+
+	// Without EDNS
+	s.SetEDNS(nil)
+
+	_, opt, err := s.QueryEDNS("example.com", TypeA)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if opt != nil {
+		t.Fatalf("FAIL: OPT returned without EDNS: %#v\n", opt)
+	}
+}
+
+func TestEDNSPadding(t *testing.T) {
+
+	for _, name := range []string{"a.com", "example.com", "a.very.long.subdomain.of.example.com"} {
+
+		msg := new(mdns.Msg)
+		msg.SetQuestion(mdns.Fqdn(name), TypeA)
+
+		newEDNSState(EDNS{Padding: true, NSID: true}).setOPT(msg, "tcp-tls")
+
+		if l := msg.Len(); l%paddingBlockSize != 0 {
+			t.Fatalf("FAIL: %s: length is not padded: %d\n", name, l)
+		}
+	}
+
+	// Padding is used only with tcp-tls
+	msg := new(mdns.Msg)
+	msg.SetQuestion("example.com.", TypeA)
+
+	newEDNSState(EDNS{Padding: true}).setOPT(msg, "udp")
+
+	if len(msg.IsEdns0().Option) != 0 {
+		t.Fatalf("FAIL: padding used with udp\n")
+	}
+}
@@ -8,6 +8,8 @@ import (
 var (
 	ErrInvalidMaxRetries = errors.New("invalid MaxRetries")
 	ErrTruncated         = errors.New("message is truncated")
+	ErrNotConverged      = errors.New("not converged")
+	ErrInvalidDomain     = errors.New("invalid domain")
 )
 
 var (
@@ -40,3 +42,27 @@ func RcodeToError(rcode int) error {
 		return fmt.Errorf("%d", rcode)
 	}
 }
+
+// ErrorToRcode returns the rcode associated with err.
+// If err is nil, returns 0 (NOERROR). If err is unknown, returns 2 (SERVFAIL).
+func ErrorToRcode(err error) int {
+
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, ErrNoError):
+		return 0
+	case errors.Is(err, ErrFormat):
+		return 1
+	case errors.Is(err, ErrServerFailure):
+		return 2
+	case errors.Is(err, ErrName):
+		return 3
+	case errors.Is(err, ErrNotImplemented):
+		return 4
+	case errors.Is(err, ErrRefused):
+		return 5
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,172 @@
+package forwarder
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	mdns "github.com/miekg/dns"
+)
+
+// cacheKey identifies a question.
+type cacheKey struct {
+	name  string
+	qtype uint16
+}
+
+type cacheEntry struct {
+	msg     *mdns.Msg
+	stored  time.Time
+	expires time.Time
+}
+
+// cache stores the upstream responses until the lowest TTL expires.
+type cache struct {
+	entries map[cacheKey]cacheEntry
+	maxLen  int           // Maximum length of the map, after exceeding this number, the expired entries are removed
+	negTTL  time.Duration // TTL of the negative answers (NXDOMAIN and NOERROR without answer) without SOA
+	m       *sync.RWMutex
+}
+
+func newCache(maxLen int, negTTL time.Duration) *cache {
+
+	return &cache{entries: make(map[cacheKey]cacheEntry), maxLen: maxLen, negTTL: negTTL, m: new(sync.RWMutex)}
+}
+
+func newCacheKey(name string, qtype uint16) cacheKey {
+	return cacheKey{name: strings.ToLower(mdns.Fqdn(name)), qtype: qtype}
+}
+
+// decrementTTL decrements the TTL of the records in rrs by elapsed seconds.
+// The OPT record is skipped, its TTL field holds the extended rcode and the flags.
+func decrementTTL(rrs []mdns.RR, elapsed uint32) {
+
+	for i := range rrs {
+
+		h := rrs[i].Header()
+
+		if h.Rrtype == mdns.TypeOPT {
+			continue
+		}
+
+		if h.Ttl > elapsed {
+			h.Ttl -= elapsed
+		} else {
+			h.Ttl = 0
+		}
+	}
+}
+
+// get returns a copy of the response with decremented TTLs.
+// The returned bool is false if the question is not cached or expired.
+func (c *cache) get(name string, qtype uint16) (*mdns.Msg, bool) {
+
+	c.m.RLock()
+	e, ok := c.entries[newCacheKey(name, qtype)]
+	c.m.RUnlock()
+
+	now := time.Now()
+
+	if !ok || now.After(e.expires) {
+		return nil, false
+	}
+
+	elapsed := uint32(now.Sub(e.stored).Seconds())
+
+	r := e.msg.Copy()
+
+	decrementTTL(r.Answer, elapsed)
+	decrementTTL(r.Ns, elapsed)
+	decrementTTL(r.Extra, elapsed)
+
+	return r, true
+}
+
+// ttl returns the caching time of msg.
+// The positive answers are cached until the lowest TTL in the Answer and Authority sections.
+// The negative answers (NXDOMAIN and NODATA) are cached for the minimum of the SOA TTL and the MINIMUM field (RFC 2308 section 5),
+// or for negTTL if the Authority section has no SOA.
+func (c *cache) ttl(msg *mdns.Msg) time.Duration {
+
+	var ttl uint32
+
+	if len(msg.Answer) == 0 {
+
+		for i := range msg.Ns {
+
+			if v, ok := msg.Ns[i].(*mdns.SOA); ok {
+
+				ttl = v.Hdr.Ttl
+
+				if v.Minttl < ttl {
+					ttl = v.Minttl
+				}
+
+				return time.Duration(ttl) * time.Second
+			}
+		}
+
+		return c.negTTL
+	}
+
+	for i, rr := range append(append([]mdns.RR{}, msg.Answer...), msg.Ns...) {
+		if i == 0 || rr.Header().Ttl < ttl {
+			ttl = rr.Header().Ttl
+		}
+	}
+
+	return time.Duration(ttl) * time.Second
+}
+
+// set stores the response msg (see ttl()).
+func (c *cache) set(name string, qtype uint16, msg *mdns.Msg) {
+
+	ttl := c.ttl(msg)
+
+	if ttl <= 0 {
+		return
+	}
+
+	c.gc()
+
+	now := time.Now()
+
+	c.m.Lock()
+	c.entries[newCacheKey(name, qtype)] = cacheEntry{msg: msg.Copy(), stored: now, expires: now.Add(ttl)}
+	c.m.Unlock()
+}
+
+// len returns the number of entries.
+func (c *cache) len() int {
+
+	c.m.RLock()
+	l := len(c.entries)
+	c.m.RUnlock()
+
+	return l
+}
+
+// gc removes the expired entries if the size of the cache exceed the limit.
+// If the cache is still full, removes every entry.
+func (c *cache) gc() {
+
+	if c.maxLen == 0 || c.len() < c.maxLen {
+		return
+	}
+
+	now := time.Now()
+
+	c.m.Lock()
+
+	for k, v := range c.entries {
+		if now.After(v.expires) {
+			delete(c.entries, k)
+		}
+	}
+
+	if len(c.entries) >= c.maxLen {
+		c.entries = make(map[cacheKey]cacheEntry)
+	}
+
+	c.m.Unlock()
+}
@@ -0,0 +1,377 @@
+/*
+Package forwarder implements a caching DNS forwarder built on dns.Servers.
+
+The Forwarder listens on UDP and TCP, forwards the questions to the upstream servers with TryQueryResponse(),
+caches the responses, refuses the blocked names and bans the clients that exceed the rate limit.
+
+The Answer, Authority and Additional sections, the AD flag and the EDNS(0) data of the upstream response are forwarded to the client.
+*/
+package forwarder
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elmasy-com/elnet/blocklist"
+	"github.com/elmasy-com/elnet/dns"
+	mdns "github.com/miekg/dns"
+)
+
+// QueryLog is a log entry of a single query.
+type QueryLog struct {
+	Time        time.Time     // Time of the query
+	Client      string        // Client IP address
+	Protocol    string        // "udp" or "tcp"
+	Name        string        // Queried name
+	Type        uint16        // Queried type
+	Rcode       int           // Returned rcode
+	Answers     int           // Number of records in the answer
+	Cached      bool          // The answer is from the cache
+	Blocked     bool          // The name is blocked
+	RateLimited bool          // The client is banned for exceeding the rate limit
+	Duration    time.Duration // Time to answer
+	Err         error         // Error returned by the upstream servers
+}
+
+// Forwarder is a caching DNS forwarder.
+// Forwarder implements the github.com/miekg/dns.Handler interface.
+type Forwarder struct {
+	servers *dns.Servers
+	cache   *cache // nil if caching is disabled
+
+	blocked []string // Blocked names, the subdomains are blocked too
+
+	rateLimit int                  // Maximum number of queries per second per client, 0 means unlimited
+	bans      *blocklist.Blocklist // Banned clients
+	counters  map[string]int       // Number of queries per client in the current second
+	window    int64                // The current second in unix time
+
+	logger func(QueryLog)
+
+	udp *mdns.Server
+	tcp *mdns.Server
+
+	m *sync.Mutex
+}
+
+// NewForwarder creates a new Forwarder that forwards the queries to servers.
+// The cache is enabled with the limit of 10000 entries.
+func NewForwarder(servers *dns.Servers) *Forwarder {
+
+	return &Forwarder{
+		servers:  servers,
+		cache:    newCache(10000, time.Minute),
+		counters: make(map[string]int),
+		m:        new(sync.Mutex),
+	}
+}
+
+// SetCache sets the maximum number of cached answers and the TTL of the negative answers (NXDOMAIN and empty answer).
+// If size is 0, the cache is disabled.
+func (f *Forwarder) SetCache(size int, negTTL time.Duration) {
+
+	if size == 0 {
+		f.cache = nil
+		return
+	}
+
+	f.cache = newCache(size, negTTL)
+}
+
+// Block blocks names and the subdomains of names. The blocked names are answered with NXDOMAIN.
+func (f *Forwarder) Block(names ...string) {
+
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	for i := range names {
+		f.blocked = append(f.blocked, dns.Clean(names[i]))
+	}
+}
+
+// SetRateLimit limits the clients to qps queries per second.
+// The clients that exceed the limit are banned for ban and answered with REFUSED.
+// If qps is 0, the rate limit is disabled.
+func (f *Forwarder) SetRateLimit(qps int, ban time.Duration) {
+
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	f.rateLimit = qps
+
+	if qps > 0 {
+		f.bans = blocklist.NewBlocklist(ban, 10000)
+	} else {
+		f.bans = nil
+	}
+}
+
+// SetLogger sets the function called after every query.
+// The function is called synchronously, so it must not block.
+func (f *Forwarder) SetLogger(fn func(QueryLog)) {
+	f.logger = fn
+}
+
+// isBlocked returns whether name or the parent of name is blocked.
+func (f *Forwarder) isBlocked(name string) bool {
+
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	for i := range f.blocked {
+		if name == f.blocked[i] || strings.HasSuffix(name, "."+f.blocked[i]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isRateLimited counts the query of client and returns whether the client is banned.
+func (f *Forwarder) isRateLimited(client string) bool {
+
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	if f.rateLimit == 0 {
+		return false
+	}
+
+	if f.bans.IsBlocked(client) {
+		return true
+	}
+
+	now := time.Now().Unix()
+
+	if now != f.window {
+		f.window = now
+		f.counters = make(map[string]int)
+	}
+
+	f.counters[client]++
+
+	if f.counters[client] > f.rateLimit {
+		f.bans.Block(client)
+		return true
+	}
+
+	return false
+}
+
+// clientIP returns the IP address of the remote address a.
+func clientIP(a net.Addr) string {
+
+	switch v := a.(type) {
+	case *net.UDPAddr:
+		return v.IP.String()
+	case *net.TCPAddr:
+		return v.IP.String()
+	default:
+		host, _, err := net.SplitHostPort(a.String())
+		if err != nil {
+			return a.String()
+		}
+		return host
+	}
+}
+
+// resolve returns the response for question q from the cache or from the upstream servers.
+// Returns nil if no upstream server responded.
+func (f *Forwarder) resolve(q mdns.Question, l *QueryLog) *mdns.Msg {
+
+	if f.cache != nil {
+		if msg, ok := f.cache.get(q.Name, q.Qtype); ok {
+			l.Cached = true
+			return msg
+		}
+	}
+
+	r, err := f.servers.TryQueryResponse(q.Name, q.Qtype)
+	if r == nil {
+		l.Err = err
+		return nil
+	}
+
+	// Cache only the final answers, NXDOMAIN and NOERROR (including NODATA)
+	if r.Rcode == mdns.RcodeSuccess || r.Rcode == mdns.RcodeNameError {
+		if f.cache != nil {
+			f.cache.set(q.Name, q.Qtype, r.Msg)
+		}
+	} else {
+		l.Err = err
+	}
+
+	return r.Msg
+}
+
+// setOPT adds the OPT record to the reply m of the client's query r, if r has an OPT record.
+// The options of the upstream OPT (eg.: the Extended DNS Errors) are forwarded, except the cookie, that is between the Forwarder and the upstream.
+func setOPT(m *mdns.Msg, r *mdns.Msg, upstream *mdns.Msg) {
+
+	opt := r.IsEdns0()
+	if opt == nil {
+		return
+	}
+
+	m.SetEdns0(dns.DefaultUDPSize, opt.Do())
+
+	if upstream == nil {
+		return
+	}
+
+	if up := upstream.IsEdns0(); up != nil {
+
+		reply := m.IsEdns0()
+
+		for i := range up.Option {
+			if up.Option[i].Option() != mdns.EDNS0COOKIE {
+				reply.Option = append(reply.Option, up.Option[i])
+			}
+		}
+	}
+}
+
+// ServeDNS answers the query r.
+func (f *Forwarder) ServeDNS(w mdns.ResponseWriter, r *mdns.Msg) {
+
+	start := time.Now()
+
+	l := QueryLog{Time: start, Client: clientIP(w.RemoteAddr()), Protocol: w.RemoteAddr().Network()}
+
+	m := new(mdns.Msg)
+	m.SetReply(r)
+	m.RecursionAvailable = true
+	m.CheckingDisabled = r.CheckingDisabled
+
+	var upstream *mdns.Msg
+
+	switch {
+	case len(r.Question) != 1:
+		m.Rcode = mdns.RcodeFormatError
+	case f.isRateLimited(l.Client):
+		l.RateLimited = true
+		m.Rcode = mdns.RcodeRefused
+	default:
+
+		l.Name = dns.Clean(r.Question[0].Name)
+		l.Type = r.Question[0].Qtype
+
+		if f.isBlocked(l.Name) {
+			l.Blocked = true
+			m.Rcode = mdns.RcodeNameError
+			break
+		}
+
+		upstream = f.resolve(r.Question[0], &l)
+		if upstream == nil {
+			m.Rcode = mdns.RcodeServerFailure
+			break
+		}
+
+		m.Rcode = upstream.Rcode
+		m.Answer = upstream.Answer
+		m.Ns = upstream.Ns
+
+		for i := range upstream.Extra {
+			if upstream.Extra[i].Header().Rrtype != mdns.TypeOPT {
+				m.Extra = append(m.Extra, upstream.Extra[i])
+			}
+		}
+
+		// The AD flag is set only for the clients that understand it (RFC 6840 section 5.8)
+		if opt := r.IsEdns0(); r.AuthenticatedData || (opt != nil && opt.Do()) {
+			m.AuthenticatedData = upstream.AuthenticatedData
+		}
+	}
+
+	setOPT(m, r, upstream)
+
+	if l.Protocol == "udp" {
+
+		size := mdns.MinMsgSize
+
+		if opt := r.IsEdns0(); opt != nil {
+			size = int(opt.UDPSize())
+		}
+
+		m.Truncate(size)
+	}
+
+	l.Rcode = m.Rcode
+	l.Answers = len(m.Answer)
+	l.Duration = time.Since(start)
+
+	w.WriteMsg(m)
+
+	if f.logger != nil {
+		f.logger(l)
+	}
+}
+
+// ListenAndServe listens on addr with UDP and TCP and serves the queries.
+// Blocks until Shutdown() is called or an error occurred.
+func (f *Forwarder) ListenAndServe(addr string) error {
+
+	pc, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on udp: %w", err)
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		pc.Close()
+		return fmt.Errorf("failed to listen on tcp: %w", err)
+	}
+
+	return f.Serve(pc, l)
+}
+
+// Serve serves the queries on pc and l.
+// Blocks until Shutdown() is called or an error occurred.
+func (f *Forwarder) Serve(pc net.PacketConn, l net.Listener) error {
+
+	udp := &mdns.Server{PacketConn: pc, Handler: f}
+	tcp := &mdns.Server{Listener: l, Handler: f}
+
+	f.m.Lock()
+	f.udp = udp
+	f.tcp = tcp
+	f.m.Unlock()
+
+	errCh := make(chan error, 2)
+
+	go func() { errCh <- udp.ActivateAndServe() }()
+	go func() { errCh <- tcp.ActivateAndServe() }()
+
+	err := <-errCh
+
+	f.Shutdown()
+
+	return err
+}
+
+// Shutdown stops the servers.
+func (f *Forwarder) Shutdown() error {
+
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	var err error
+
+	if f.udp != nil {
+		err = f.udp.Shutdown()
+		f.udp = nil
+	}
+
+	if f.tcp != nil {
+		if e := f.tcp.Shutdown(); e != nil && err == nil {
+			err = e
+		}
+		f.tcp = nil
+	}
+
+	return err
+}
@@ -0,0 +1,272 @@
+package forwarder
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/elmasy-com/elnet/dns"
+	mdns "github.com/miekg/dns"
+)
+
+// testWriter is a mdns.ResponseWriter that stores the written message.
+type testWriter struct {
+	mdns.ResponseWriter
+	remote net.Addr
+	msg    *mdns.Msg
+}
+
+func (w *testWriter) RemoteAddr() net.Addr { return w.remote }
+
+func (w *testWriter) WriteMsg(m *mdns.Msg) error {
+	w.msg = m
+	return nil
+}
+
+// startUpstream starts a local DNS server that answers A queries for "example.com." (with AD flag) and NXDOMAIN with SOA for the others.
+// The returned counter counts the received queries.
+func startUpstream(t *testing.T) (*dns.Servers, *atomic.Int32) {
+
+	n := new(atomic.Int32)
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("FAIL: failed to listen: %s\n", err)
+	}
+
+	srv := &mdns.Server{PacketConn: pc, Handler: mdns.HandlerFunc(func(w mdns.ResponseWriter, r *mdns.Msg) {
+
+		n.Add(1)
+
+		m := new(mdns.Msg)
+		m.SetReply(r)
+
+		if r.Question[0].Name == "example.com." {
+			m.AuthenticatedData = true
+			m.Answer = append(m.Answer, &mdns.A{
+				Hdr: mdns.RR_Header{Name: "example.com.", Rrtype: mdns.TypeA, Class: mdns.ClassINET, Ttl: 300},
+				A:   net.IP{93, 184, 216, 34},
+			})
+		} else {
+			m.Rcode = mdns.RcodeNameError
+			m.Ns = append(m.Ns, &mdns.SOA{
+				Hdr:    mdns.RR_Header{Name: "example.com.", Rrtype: mdns.TypeSOA, Class: mdns.ClassINET, Ttl: 3600},
+				Ns:     "ns.example.com.",
+				Mbox:   "admin.example.com.",
+				Serial: 1,
+				Minttl: 30,
+			})
+		}
+
+		w.WriteMsg(m)
+	})}
+
+	started := make(chan struct{})
+	srv.NotifyStartedFunc = func() { close(started) }
+
+	go srv.ActivateAndServe()
+
+	<-started
+
+	t.Cleanup(func() { srv.Shutdown() })
+
+	srvs, err := dns.NewServersStr(1, time.Second, "udp://"+pc.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("FAIL: failed to create servers: %s\n", err)
+	}
+
+	return &srvs, n
+}
+
+// query sends a query for name to f from client and returns the response.
+func query(f *Forwarder, client string, name string) *mdns.Msg {
+
+	r := new(mdns.Msg)
+	r.SetQuestion(name, mdns.TypeA)
+
+	w := &testWriter{remote: &net.UDPAddr{IP: net.ParseIP(client), Port: 40000}}
+
+	f.ServeDNS(w, r)
+
+	return w.msg
+}
+
+func TestForwarderCache(t *testing.T) {
+
+	srvs, n := startUpstream(t)
+
+	f := NewForwarder(srvs)
+
+	var logs []QueryLog
+	f.SetLogger(func(l QueryLog) { logs = append(logs, l) })
+
+	for i := 0; i < 3; i++ {
+
+		m := query(f, "127.0.0.1", "example.com.")
+
+		if m.Rcode != mdns.RcodeSuccess || len(m.Answer) != 1 {
+			t.Fatalf("FAIL: invalid answer: %s\n", m)
+		}
+	}
+
+	if n.Load() != 1 {
+		t.Fatalf("FAIL: upstream queried %d times, want 1\n", n.Load())
+	}
+
+	if len(logs) != 3 || logs[0].Cached || !logs[1].Cached || logs[2].Name != "example.com" {
+		t.Fatalf("FAIL: invalid logs: %#v\n", logs)
+	}
+
+	m := query(f, "127.0.0.1", "notexist.example.com.")
+	if m.Rcode != mdns.RcodeNameError {
+		t.Fatalf("FAIL: rcode got: %d, want: NXDOMAIN\n", m.Rcode)
+	}
+}
+
+func TestForwarderNegative(t *testing.T) {
+
+	srvs, n := startUpstream(t)
+
+	f := NewForwarder(srvs)
+
+	for i := 0; i < 2; i++ {
+
+		m := query(f, "127.0.0.1", "notexist.example.com.")
+
+		if m.Rcode != mdns.RcodeNameError || len(m.Ns) != 1 {
+			t.Fatalf("FAIL: invalid negative answer: %s\n", m)
+		}
+
+		if soa, ok := m.Ns[0].(*mdns.SOA); !ok || soa.Minttl != 30 {
+			t.Fatalf("FAIL: invalid SOA: %s\n", m.Ns[0])
+		}
+	}
+
+	if n.Load() != 1 {
+		t.Fatalf("FAIL: negative answer is not cached, upstream queried %d times\n", n.Load())
+	}
+
+	// Cached for the minimum of the SOA TTL and MINIMUM
+	e := f.cache.entries[newCacheKey("notexist.example.com.", mdns.TypeA)]
+
+	if d := e.expires.Sub(e.stored); d != 30*time.Second {
+		t.Fatalf("FAIL: negative TTL got: %s, want: 30s\n", d)
+	}
+}
+
+func TestForwarderAD(t *testing.T) {
+
+	srvs, _ := startUpstream(t)
+
+	f := NewForwarder(srvs)
+
+	if m := query(f, "127.0.0.1", "example.com."); m.AuthenticatedData || m.IsEdns0() != nil {
+		t.Fatalf("FAIL: AD or OPT is set without DO: %s\n", m)
+	}
+
+	r := new(mdns.Msg)
+	r.SetQuestion("example.com.", mdns.TypeA)
+	r.SetEdns0(4096, true)
+
+	w := &testWriter{remote: &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 40000}}
+
+	f.ServeDNS(w, r)
+
+	if !w.msg.AuthenticatedData || w.msg.IsEdns0() == nil || !w.msg.IsEdns0().Do() {
+		t.Fatalf("FAIL: AD or OPT is not forwarded: %s\n", w.msg)
+	}
+}
+
+func TestForwarderBlock(t *testing.T) {
+
+	srvs, n := startUpstream(t)
+
+	f := NewForwarder(srvs)
+	f.Block("Example.com.")
+
+	for _, name := range []string{"example.com.", "www.example.com."} {
+
+		m := query(f, "127.0.0.1", name)
+
+		if m.Rcode != mdns.RcodeNameError {
+			t.Fatalf("FAIL: %s is not blocked\n", name)
+		}
+	}
+
+	if n.Load() != 0 {
+		t.Fatalf("FAIL: blocked name forwarded\n")
+	}
+}
+
+func TestForwarderRateLimit(t *testing.T) {
+
+	srvs, _ := startUpstream(t)
+
+	f := NewForwarder(srvs)
+	f.SetRateLimit(2, time.Minute)
+
+	var refused int
+
+	for i := 0; i < 4; i++ {
+		if query(f, "127.0.0.1", "example.com.").Rcode == mdns.RcodeRefused {
+			refused++
+		}
+	}
+
+	if refused < 2 {
+		t.Fatalf("FAIL: refused %d queries, want at least 2\n", refused)
+	}
+
+	// Other clients are not affected
+	if m := query(f, "127.0.0.2", "example.com."); m.Rcode != mdns.RcodeSuccess {
+		t.Fatalf("FAIL: other client refused\n")
+	}
+}
+
+func TestForwarderServe(t *testing.T) {
+
+	srvs, _ := startUpstream(t)
+
+	f := NewForwarder(srvs)
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	go f.Serve(pc, l)
+	defer f.Shutdown()
+
+	for _, proto := range []string{"udp", "tcp"} {
+
+		addr := pc.LocalAddr().String()
+		if proto == "tcp" {
+			addr = l.Addr().String()
+		}
+
+		s, err := dns.NewServerStr(proto+"://"+addr, time.Second)
+		if err != nil {
+			t.Fatalf("FAIL: %s\n", err)
+		}
+
+		var ips []net.IP
+
+		// The servers may not be started yet
+		for i := 0; i < 10; i++ {
+			if ips, err = s.QueryA("example.com"); err == nil {
+				break
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		if err != nil || len(ips) != 1 {
+			t.Fatalf("FAIL: %s: answer: %v, error: %v\n", proto, ips, err)
+		}
+	}
+}
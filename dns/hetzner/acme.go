@@ -0,0 +1,264 @@
+package hetzner
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elmasy-com/elnet/dns"
+)
+
+// Solver is the interface used by ACME libraries to solve a DNS-01 challenge.
+// It matches the challenge.Provider interface of github.com/go-acme/lego.
+type Solver interface {
+	Present(domain, token, keyAuth string) error
+	CleanUp(domain, token, keyAuth string) error
+}
+
+// DNS01 solves ACME DNS-01 challenges by creating the "_acme-challenge" TXT record with the Hetzner DNS API.
+//
+// DNS01 implements Solver.
+type DNS01 struct {
+	TTL                int           // TTL of the created TXT record
+	PropagationTimeout time.Duration // Maximum time to wait for the record to appear on every authoritative nameserver
+	PollInterval       time.Duration // Time between two propagation checks
+	QueryTimeout       time.Duration // Timeout of a single DNS query
+
+	client  *Client
+	records map[string]string // Created record IDs, the key is "<fqdn> <value>" (eg.: "_acme-challenge.example.com abc")
+	servers *dns.Servers      // Resolvers to query the nameservers, nil uses dns.DefaultServers
+	port    string            // Port of the authoritative nameservers
+	m       *sync.Mutex
+}
+
+var (
+	ErrPropagationTimeout = errors.New("propagation timeout")
+)
+
+// NewDNS01 returns a new DNS01 that uses client c.
+func NewDNS01(c *Client) *DNS01 {
+
+	return &DNS01{
+		TTL:                60,
+		PropagationTimeout: 2 * time.Minute,
+		PollInterval:       5 * time.Second,
+		QueryTimeout:       2 * time.Second,
+		client:             c,
+		records:            make(map[string]string),
+		port:               "53",
+		m:                  new(sync.Mutex),
+	}
+}
+
+// DNS01Value returns the value of the TXT record for key authorization keyAuth (base64url encoded SHA256 hash without padding).
+func DNS01Value(keyAuth string) string {
+
+	h := sha256.Sum256([]byte(keyAuth))
+
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
+
+// ChallengeName returns the name of the challenge record for domain (eg.: "*.example.com" -> "_acme-challenge.example.com").
+func ChallengeName(domain string) string {
+
+	domain = strings.TrimPrefix(dns.Clean(domain), "*.")
+
+	return "_acme-challenge." + domain
+}
+
+// relativeName returns name relative to zone (eg.: "_acme-challenge.www.example.com" in "example.com" -> "_acme-challenge.www").
+func relativeName(name string, zone string) string {
+
+	return strings.TrimSuffix(strings.TrimSuffix(name, zone), ".")
+}
+
+// findZone returns the most specific zone that contains name.
+func (d *DNS01) findZone(name string) (Zone, error) {
+
+	for {
+
+		dot := strings.IndexByte(name, '.')
+		if dot == -1 {
+			return Zone{}, ErrZoneNotFound
+		}
+
+		name = name[dot+1:]
+
+		zone, err := d.client.GetZoneByName(name)
+		if err == nil {
+			return zone, nil
+		}
+
+		if !errors.Is(err, ErrZoneNotFound) {
+			return Zone{}, err
+		}
+	}
+}
+
+// nameservers returns the authoritative nameservers of zone as dns.Server.
+// If the zone returned by the API does not contain the nameservers, query them.
+// Every IPv4 and IPv6 address of the nameservers is used.
+func (d *DNS01) nameservers(zone Zone) ([]dns.Server, error) {
+
+	var err error
+
+	resolvers := d.servers
+	if resolvers == nil {
+		resolvers = &dns.DefaultServers
+	}
+
+	names := zone.NS
+
+	if len(names) == 0 {
+		names, err = resolvers.TryQueryNS(zone.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query NS: %w", err)
+		}
+	}
+
+	srvs := make([]dns.Server, 0, len(names))
+
+	for i := range names {
+
+		ips, err := resolvers.TryQueryA(names[i])
+		if err != nil && !errors.Is(err, dns.ErrName) {
+			return nil, fmt.Errorf("failed to query A for %s: %w", names[i], err)
+		}
+
+		ips6, err := resolvers.TryQueryAAAA(names[i])
+		if err != nil && !errors.Is(err, dns.ErrName) {
+			return nil, fmt.Errorf("failed to query AAAA for %s: %w", names[i], err)
+		}
+
+		ips = append(ips, ips6...)
+
+		for ii := range ips {
+
+			srv, err := dns.NewServer("udp", ips[ii].String(), d.port, d.QueryTimeout)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create server for %s: %w", names[i], err)
+			}
+
+			srvs = append(srvs, srv)
+		}
+	}
+
+	if len(srvs) == 0 {
+		return nil, fmt.Errorf("no nameserver found for %s", zone.Name)
+	}
+
+	return srvs, nil
+}
+
+// isPropagated returns whether every server in srvs returns value for the TXT record name.
+func isPropagated(srvs []dns.Server, name string, value string) bool {
+
+	for i := range srvs {
+
+		txts, err := srvs[i].QueryTXT(name)
+		if err != nil {
+			return false
+		}
+
+		found := false
+
+		for ii := range txts {
+			if txts[ii] == value {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Wait polls the authoritative nameservers of zone until every server returns value for the TXT record name.
+// Returns ErrPropagationTimeout if the record is not propagated within PropagationTimeout.
+func (d *DNS01) Wait(zone Zone, name string, value string) error {
+
+	srvs, err := d.nameservers(zone)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(d.PropagationTimeout)
+
+	for {
+
+		if isPropagated(srvs, name, value) {
+			return nil
+		}
+
+		if time.Now().Add(d.PollInterval).After(deadline) {
+			return ErrPropagationTimeout
+		}
+
+		time.Sleep(d.PollInterval)
+	}
+}
+
+// Present creates the TXT record for the challenge and waits until the record is propagated to every authoritative nameserver.
+func (d *DNS01) Present(domain, token, keyAuth string) error {
+
+	name := ChallengeName(domain)
+	value := DNS01Value(keyAuth)
+
+	zone, err := d.findZone(name)
+	if err != nil {
+		return fmt.Errorf("failed to find zone for %s: %w", name, err)
+	}
+
+	r, err := d.client.CreateRecord(relativeName(name, zone.Name), d.TTL, "TXT", value, zone.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create record: %w", err)
+	}
+
+	d.m.Lock()
+	d.records[name+" "+value] = r.ID
+	d.m.Unlock()
+
+	err = d.Wait(zone, name, value)
+	if err != nil {
+		return fmt.Errorf("failed to wait for %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// CleanUp deletes the TXT record created by Present.
+func (d *DNS01) CleanUp(domain, token, keyAuth string) error {
+
+	key := ChallengeName(domain) + " " + DNS01Value(keyAuth)
+
+	d.m.Lock()
+	id, ok := d.records[key]
+	delete(d.records, key)
+	d.m.Unlock()
+
+	if !ok {
+		return fmt.Errorf("unknown challenge for %s", domain)
+	}
+
+	err := d.client.DeleteRecord(id)
+	if err != nil {
+		return fmt.Errorf("failed to delete record: %w", err)
+	}
+
+	return nil
+}
+
+// Timeout returns the propagation timeout and the poll interval.
+// This is used by github.com/go-acme/lego to override its default timeout.
+func (d *DNS01) Timeout() (timeout, interval time.Duration) {
+
+	return d.PropagationTimeout, d.PollInterval
+}
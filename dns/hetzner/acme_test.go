@@ -0,0 +1,317 @@
+package hetzner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elmasy-com/elnet/dns"
+	mdns "github.com/miekg/dns"
+)
+
+var _ Solver = (*DNS01)(nil)
+
+func TestDNS01Value(t *testing.T) {
+
+	// echo -n "token.thumbprint" | openssl dgst -sha256 -binary | base64 | tr '+/' '-_' | tr -d '='
+	v := DNS01Value("token.thumbprint")
+	if v != "61rBZ_4knHblO0MNoxFsXZ_eTFUHum0B6IVRbhvUn5I" {
+		t.Fatalf("FAIL: unexpected value: %s\n", v)
+	}
+}
+
+func TestChallengeName(t *testing.T) {
+
+	cases := [][2]string{
+		{"example.com", "_acme-challenge.example.com"},
+		{"Example.COM.", "_acme-challenge.example.com"},
+		{"*.example.com", "_acme-challenge.example.com"},
+		{"www.example.com", "_acme-challenge.www.example.com"},
+	}
+
+	for i := range cases {
+		if r := ChallengeName(cases[i][0]); r != cases[i][1] {
+			t.Fatalf("FAIL: %s: want %s, got %s\n", cases[i][0], cases[i][1], r)
+		}
+	}
+}
+
+func TestRelativeName(t *testing.T) {
+
+	cases := [][3]string{
+		{"_acme-challenge.example.com", "example.com", "_acme-challenge"},
+		{"_acme-challenge.www.example.com", "example.com", "_acme-challenge.www"},
+		{"_acme-challenge.www.example.com", "www.example.com", "_acme-challenge"},
+	}
+
+	for i := range cases {
+		if r := relativeName(cases[i][0], cases[i][1]); r != cases[i][2] {
+			t.Fatalf("FAIL: %s in %s: want %s, got %s\n", cases[i][0], cases[i][1], cases[i][2], r)
+		}
+	}
+}
+
+func TestDNS01FindZone(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if r.URL.Query().Get("name") != "example.com" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"zones":[],"error":{"message":"zone not found","code":404}}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"zones":[{"id":"zoneid","name":"example.com","ns":["ns1.example.com"]}]}`)
+	}))
+	defer srv.Close()
+
+	orig := BaseURL
+	BaseURL = srv.URL
+	defer func() { BaseURL = orig }()
+
+	d := NewDNS01(NewClient("key"))
+
+	zone, err := d.findZone("_acme-challenge.a.b.example.com")
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if zone.ID != "zoneid" {
+		t.Fatalf("FAIL: unexpected zone: %#v\n", zone)
+	}
+
+	_, err = d.findZone("_acme-challenge.example.org")
+	if !errors.Is(err, ErrZoneNotFound) {
+		t.Fatalf("FAIL: error got: %v, want: %s\n", err, ErrZoneNotFound)
+	}
+}
+
+// testACME is a Hetzner DNS API stub and an authoritative DNS server of example.com.
+// A created TXT record becomes visible on the DNS server after delay queries.
+type testACME struct {
+	records map[string]Record // Created records by ID
+	queries int               // Number of the TXT queries
+	delay   int
+	n       int
+	m       sync.Mutex
+}
+
+func (a *testACME) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/zones":
+
+		if r.URL.Query().Get("name") != "example.com" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"zones":[],"error":{"message":"zone not found","code":404}}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"zones":[{"id":"zoneid","name":"example.com","ns":["ns1.example.com"]}]}`)
+
+	case r.Method == http.MethodPost && r.URL.Path == "/records":
+
+		var rec Record
+
+		if err := json.NewDecoder(r.Body).Decode(&rec); err != nil || rec.ZoneID != "zoneid" || rec.Type != "TXT" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			fmt.Fprint(w, `{"error":{"message":"invalid record","code":422}}`)
+			return
+		}
+
+		a.n++
+		rec.ID = fmt.Sprintf("record-%d", a.n)
+		a.records[rec.ID] = rec
+
+		json.NewEncoder(w).Encode(map[string]Record{"record": rec})
+
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/records/"):
+
+		id := strings.TrimPrefix(r.URL.Path, "/records/")
+
+		if _, ok := a.records[id]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"message":"record not found","code":404}}`)
+			return
+		}
+
+		delete(a.records, id)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (a *testACME) ServeDNS(w mdns.ResponseWriter, r *mdns.Msg) {
+
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	m := new(mdns.Msg)
+	m.SetReply(r)
+
+	q := r.Question[0]
+	hdr := mdns.RR_Header{Name: q.Name, Rrtype: q.Qtype, Class: mdns.ClassINET, Ttl: 60}
+
+	switch {
+	case q.Name == "ns1.example.com." && q.Qtype == mdns.TypeA:
+
+		m.Answer = append(m.Answer, &mdns.A{Hdr: hdr, A: net.ParseIP("127.0.0.1")})
+
+	case q.Name == "ns6.example.com." && q.Qtype == mdns.TypeAAAA:
+
+		// IPv6 only nameserver
+		m.Answer = append(m.Answer, &mdns.AAAA{Hdr: hdr, AAAA: net.ParseIP("::1")})
+
+	case q.Name == "nx.example.com.":
+
+		m.Rcode = mdns.RcodeNameError
+
+	case q.Qtype == mdns.TypeTXT:
+
+		a.queries++
+
+		if a.queries <= a.delay {
+			break
+		}
+
+		for _, rec := range a.records {
+			if rec.Name+".example.com." == q.Name {
+				m.Answer = append(m.Answer, &mdns.TXT{Hdr: hdr, Txt: []string{rec.Value}})
+			}
+		}
+	}
+
+	w.WriteMsg(m)
+}
+
+// newTestDNS01 starts the Hetzner stub and the DNS server of a and returns a DNS01 that uses them.
+func newTestDNS01(t *testing.T, a *testACME) *DNS01 {
+
+	testServer(t, a.ServeHTTP)
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("FAIL: failed to listen: %s\n", err)
+	}
+
+	srv := &mdns.Server{PacketConn: pc, Handler: a}
+
+	started := make(chan struct{})
+	srv.NotifyStartedFunc = func() { close(started) }
+
+	go srv.ActivateAndServe()
+
+	<-started
+
+	t.Cleanup(func() { srv.Shutdown() })
+
+	servers, err := dns.NewServersStr(1, time.Second, "udp://"+pc.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	_, port, _ := net.SplitHostPort(pc.LocalAddr().String())
+
+	d := NewDNS01(NewClient("key"))
+	d.servers = &servers
+	d.port = port
+	d.PollInterval = 10 * time.Millisecond
+	d.PropagationTimeout = time.Second
+
+	return d
+}
+
+func TestDNS01(t *testing.T) {
+
+	a := &testACME{records: make(map[string]Record), delay: 3}
+
+	d := newTestDNS01(t, a)
+
+	if err := d.Present("*.www.example.com", "token", "token.thumbprint"); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	a.m.Lock()
+	rec, queries := a.records["record-1"], a.queries
+	a.m.Unlock()
+
+	switch {
+	case rec.Name != "_acme-challenge.www" || rec.Value != DNS01Value("token.thumbprint") || rec.TTL != d.TTL:
+		t.Fatalf("FAIL: invalid record: %#v\n", rec)
+	case queries != a.delay+1:
+		t.Fatalf("FAIL: want %d TXT queries, got %d\n", a.delay+1, queries)
+	}
+
+	if err := d.CleanUp("*.www.example.com", "token", "token.thumbprint"); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	a.m.Lock()
+	n := len(a.records)
+	a.m.Unlock()
+
+	if n != 0 {
+		t.Fatalf("FAIL: record not deleted\n")
+	}
+
+	// Already cleaned up
+	if err := d.CleanUp("*.www.example.com", "token", "token.thumbprint"); err == nil || !strings.Contains(err.Error(), "unknown challenge") {
+		t.Fatalf("FAIL: want unknown challenge, got %v\n", err)
+	}
+}
+
+func TestDNS01Wait(t *testing.T) {
+
+	a := &testACME{records: make(map[string]Record)}
+
+	d := newTestDNS01(t, a)
+	d.PropagationTimeout = 50 * time.Millisecond
+
+	zone := Zone{ID: "zoneid", Name: "example.com", NS: []string{"ns1.example.com"}}
+
+	if err := d.Wait(zone, "_acme-challenge.example.com", "value"); !errors.Is(err, ErrPropagationTimeout) {
+		t.Fatalf("FAIL: want ErrPropagationTimeout, got %v\n", err)
+	}
+
+	a.m.Lock()
+	queries := a.queries
+	a.m.Unlock()
+
+	if queries < 2 {
+		t.Fatalf("FAIL: want polling, got %d TXT queries\n", queries)
+	}
+
+	// The record is not created
+	if err := d.Present("example.org", "token", "token.thumbprint"); !errors.Is(err, ErrZoneNotFound) {
+		t.Fatalf("FAIL: want ErrZoneNotFound, got %v\n", err)
+	}
+}
+
+func TestDNS01Nameservers(t *testing.T) {
+
+	d := newTestDNS01(t, &testACME{records: make(map[string]Record)})
+
+	srvs, err := d.nameservers(Zone{Name: "example.com", NS: []string{"ns1.example.com", "ns6.example.com"}})
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if len(srvs) != 2 || srvs[0].IP != "127.0.0.1" || srvs[1].IP != "::1" || srvs[1].Port != d.port {
+		t.Fatalf("FAIL: invalid nameservers: %v\n", srvs)
+	}
+
+	if _, err := d.nameservers(Zone{Name: "example.com", NS: []string{"nx.example.com"}}); err == nil {
+		t.Fatalf("FAIL: want error without nameserver address\n")
+	}
+}
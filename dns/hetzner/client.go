@@ -6,21 +6,65 @@ import (
 )
 
 type Client struct {
-	key string
-	hc  *http.Client
+	key        string
+	hc         *http.Client
+	userAgent  string
+	maxRetries int           // Maximum number of retries on 429, 5xx and network errors
+	minBackoff time.Duration // First backoff time, doubled after every retry
+	maxBackoff time.Duration // Upper limit of the backoff time
+	limiter    *bucket       // Client side rate limiter, nil means no limit
 }
 
 var (
 	BaseURL = "https://dns.hetzner.com/api/v1"
+
+	// DefaultUserAgent is the User-Agent header sent by the Client.
+	DefaultUserAgent = "elnet-hetzner"
+
+	// DefaultMaxRetries is the default number of retries on 429, 5xx and network errors.
+	DefaultMaxRetries = 3
 )
 
 // Return a new Client with specified timeout.
 func NewClientWithTimeout(key string, timeout time.Duration) *Client {
 
-	return &Client{key: key, hc: &http.Client{Timeout: timeout}}
+	return &Client{
+		key:        key,
+		hc:         &http.Client{Timeout: timeout},
+		userAgent:  DefaultUserAgent,
+		maxRetries: DefaultMaxRetries,
+		minBackoff: 500 * time.Millisecond,
+		maxBackoff: 30 * time.Second,
+	}
 }
 
 // Return a new Client without timeout.
 func NewClient(key string) *Client {
 	return NewClientWithTimeout(key, 0)
 }
+
+// SetUserAgent sets the User-Agent header of the requests.
+func (c *Client) SetUserAgent(ua string) {
+	c.userAgent = ua
+}
+
+// SetRetries sets the maximum number of retries and the backoff limits.
+// The backoff starts at min and doubled after every retry up to max.
+// If n is 0, the failed requests are not retried.
+func (c *Client) SetRetries(n int, min, max time.Duration) {
+	c.maxRetries = n
+	c.minBackoff = min
+	c.maxBackoff = max
+}
+
+// SetRateLimit limits the client to rate requests per second with bursts of burst requests.
+// If rate is 0, the limit is removed.
+func (c *Client) SetRateLimit(rate float64, burst int) {
+
+	if rate <= 0 {
+		c.limiter = nil
+		return
+	}
+
+	c.limiter = newBucket(rate, burst)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 var (
@@ -16,38 +17,68 @@ var (
 	ErrInvalidAAAARecord = errors.New("invalid AAAA record")
 )
 
-func parseError(code int, body []byte) error {
+// NotFoundError is returned when the API responds with 404 Not Found.
+type NotFoundError struct {
+	Message string // Message returned by the API
+	Err     error  // The known error (eg.: ErrZoneNotFound), can be nil
+}
 
-	// 401 has a different returned body.
-	// eg.: {"message":"Invalid authentication credentials"}
-	if code == http.StatusUnauthorized {
+func (e *NotFoundError) Error() string {
 
-		v := struct {
-			Message string `json:"message"`
-		}{}
+	if e.Err != nil {
+		return e.Err.Error()
+	}
 
-		err := json.Unmarshal(body, &v)
-		if err != nil {
-			return fmt.Errorf("failed to unmarshal error body: %w", err)
-		}
+	return fmt.Sprintf("%s (%d)", e.Message, http.StatusNotFound)
+}
 
-		switch v.Message {
-		case "No API key found in request":
-			return ErrNoAPIKey
-		case "Invalid authentication credentials":
-			return ErrInvalidAPIKey
-		default:
-			return fmt.Errorf("%s (%d)", v.Message, code)
-		}
+func (e *NotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// UnauthorizedError is returned when the API responds with 401 Unauthorized or 403 Forbidden.
+type UnauthorizedError struct {
+	StatusCode int    // 401 or 403
+	Message    string // Message returned by the API
+	Err        error  // The known error (eg.: ErrInvalidAPIKey), can be nil
+}
+
+func (e *UnauthorizedError) Error() string {
 
+	if e.Err != nil {
+		return e.Err.Error()
 	}
 
-	// If error occured, the error struct is inside the returned struct
-	// eg.: {"zones": ..., "meta": ..., "error": ...}
+	return fmt.Sprintf("%s (%d)", e.Message, e.StatusCode)
+}
+
+func (e *UnauthorizedError) Unwrap() error {
+	return e.Err
+}
+
+// RateLimitError is returned when the API responds with 429 Too Many Requests and the retries are exhausted.
+type RateLimitError struct {
+	Message    string        // Message returned by the API
+	RetryAfter time.Duration // Time to wait before the next request, 0 if unknown
+}
+
+func (e *RateLimitError) Error() string {
+
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited, retry after %s", e.RetryAfter)
+	}
+
+	return "rate limited"
+}
+
+// errorMessage returns the error message from the returned body.
+// The 401 has a different returned body (eg.: {"message":"Invalid authentication credentials"}),
+// the other errors are inside the returned struct (eg.: {"zones": ..., "meta": ..., "error": ...}).
+func errorMessage(body []byte) (string, error) {
 
-	// This strict is unmarshal the error field only
 	v := struct {
-		Error struct {
+		Message string `json:"message"`
+		Error   struct {
 			Message string `json:"message"`
 			Code    int    `json:"code"`
 		} `json:"error"`
@@ -55,18 +86,63 @@ func parseError(code int, body []byte) error {
 
 	err := json.Unmarshal(body, &v)
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal error body: %w, body: %s", err, body)
+		return "", fmt.Errorf("failed to unmarshal error body: %w, body: %s", err, body)
+	}
+
+	if v.Message != "" {
+		return v.Message, nil
+	}
+
+	return v.Error.Message, nil
+}
+
+// parseError returns the error of the response based on the status code.
+// The message parsed from the body is used only to enrich the error,
+// if the body is not JSON (eg.: empty or a HTML page from the gateway), the status text is used.
+func parseError(resp *http.Response, body []byte) error {
+
+	code := resp.StatusCode
+
+	msg, err := errorMessage(body)
+	if err != nil || msg == "" {
+		msg = http.StatusText(code)
+	}
+
+	switch code {
+	case http.StatusUnauthorized, http.StatusForbidden:
+
+		e := &UnauthorizedError{StatusCode: code, Message: msg}
+
+		switch msg {
+		case "No API key found in request":
+			e.Err = ErrNoAPIKey
+		case "Invalid authentication credentials":
+			e.Err = ErrInvalidAPIKey
+		}
+
+		return e
+
+	case http.StatusTooManyRequests:
+		return &RateLimitError{Message: msg, RetryAfter: retryAfter(resp.Header, time.Now())}
+
+	case http.StatusNotFound:
+
+		if msg == "zone not found" {
+			return &NotFoundError{Message: msg, Err: ErrZoneNotFound}
+		}
+
+		return &NotFoundError{Message: msg}
 	}
 
 	// Compare errors to known errors
-	switch v.Error.Message {
+	switch msg {
 	case "zone not found":
-		return ErrZoneNotFound
+		return &NotFoundError{Message: msg, Err: ErrZoneNotFound}
 	case "invalid A record":
 		return ErrInvalidARecord
 	case "invalid AAAA record":
 		return ErrInvalidAAAARecord
-	default:
-		return fmt.Errorf("%s (%d)", v.Error.Message, code)
 	}
+
+	return fmt.Errorf("%s (%d)", msg, code)
 }
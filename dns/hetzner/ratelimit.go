@@ -0,0 +1,90 @@
+package hetzner
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// bucket is a token bucket rate limiter.
+type bucket struct {
+	rate   float64   // Tokens added per second
+	burst  float64   // Maximum number of tokens
+	tokens float64   // Available tokens
+	last   time.Time // Last time when the tokens were refilled
+	until  time.Time // Do not allow any request until this time (set by the rate limit headers)
+	m      *sync.Mutex
+}
+
+func newBucket(rate float64, burst int) *bucket {
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &bucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now(), m: new(sync.Mutex)}
+}
+
+// reserve takes a token and returns the time to wait before the request can be sent.
+func (b *bucket) reserve() time.Duration {
+
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	now := time.Now()
+
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	b.tokens--
+
+	var wait time.Duration
+
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+
+	if d := b.until.Sub(now); d > wait {
+		wait = d
+	}
+
+	return wait
+}
+
+// pause blocks every request until t.
+func (b *bucket) pause(t time.Time) {
+
+	b.m.Lock()
+	if t.After(b.until) {
+		b.until = t
+	}
+	b.m.Unlock()
+}
+
+// wait blocks until the request can be sent or ctx is done.
+func (b *bucket) wait(ctx context.Context) error {
+
+	d := b.reserve()
+	if d <= 0 {
+		return nil
+	}
+
+	return sleep(ctx, d)
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -1,11 +1,11 @@
 package hetzner
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"strings"
+	"net/url"
 )
 
 type Record struct {
@@ -25,30 +25,15 @@ type Records struct {
 
 // GetAllRecords returns all records associated with user.
 func (c *Client) GetAllRecords() ([]Record, error) {
+	return c.GetAllRecordsContext(context.Background())
+}
 
-	req, err := http.NewRequest("GET", BaseURL+"/records", nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Add("Auth-API-Token", c.key)
+// GetAllRecordsContext returns all records associated with user.
+func (c *Client) GetAllRecordsContext(ctx context.Context) ([]Record, error) {
 
-	resp, err := http.DefaultClient.Do(req)
+	respBody, err := c.do(ctx, http.MethodGet, "/records", nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read Response Body
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	// Error
-	if resp.StatusCode != http.StatusOK {
-
-		return nil, parseError(resp.StatusCode, respBody)
+		return nil, err
 	}
 
 	v := new(Records)
@@ -63,30 +48,15 @@ func (c *Client) GetAllRecords() ([]Record, error) {
 
 // GetAllRecordsByZone returns all records associated with user from zone zone.
 func (c *Client) GetAllRecordsByZone(zone string) ([]Record, error) {
+	return c.GetAllRecordsByZoneContext(context.Background(), zone)
+}
 
-	req, err := http.NewRequest("GET", BaseURL+"/records?zone_id="+zone, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Add("Auth-API-Token", c.key)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed request: %w", err)
-	}
-	defer resp.Body.Close()
+// GetAllRecordsByZoneContext returns all records associated with user from zone zone.
+func (c *Client) GetAllRecordsByZoneContext(ctx context.Context, zone string) ([]Record, error) {
 
-	// Read Response Body
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := c.do(ctx, http.MethodGet, "/records?zone_id="+url.QueryEscape(zone), nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	// Error
-	if resp.StatusCode != http.StatusOK {
-
-		return nil, parseError(resp.StatusCode, respBody)
+		return nil, err
 	}
 
 	v := new(Records)
@@ -102,33 +72,24 @@ func (c *Client) GetAllRecordsByZone(zone string) ([]Record, error) {
 // CreateRecord creates a new record.
 // Valid t types are: "A", "AAAA", "NS", "MX", "CNAME", "RP", "TXT", "SOA", "HINFO", "SRV", "DANE", "TLSA", "DS" and "CAA".
 func (c *Client) CreateRecord(name string, ttl int, t string, value string, zone string) (Record, error) {
+	return c.CreateRecordContext(context.Background(), name, ttl, t, value, zone)
+}
 
-	body := fmt.Sprintf("{\"name\":\"%s\",\"ttl\":%d,\"type\":\"%s\",\"value\":\"%s\",\"zone_id\":\"%s\"}", name, ttl, t, value, zone)
-
-	req, err := http.NewRequest("POST", BaseURL+"/records", strings.NewReader(body))
-	if err != nil {
-		return Record{}, fmt.Errorf("failed to create request: %w", err)
-	}
+// CreateRecordContext creates a new record.
+// Valid t types are: "A", "AAAA", "NS", "MX", "CNAME", "RP", "TXT", "SOA", "HINFO", "SRV", "DANE", "TLSA", "DS" and "CAA".
+func (c *Client) CreateRecordContext(ctx context.Context, name string, ttl int, t string, value string, zone string) (Record, error) {
 
-	req.Header.Add("Auth-API-Token", c.key)
-	req.Header.Add("Content-Type", "application/json")
+	body := struct {
+		Name   string `json:"name"`
+		TTL    int    `json:"ttl"`
+		Type   string `json:"type"`
+		Value  string `json:"value"`
+		ZoneID string `json:"zone_id"`
+	}{Name: name, TTL: ttl, Type: t, Value: value, ZoneID: zone}
 
-	resp, err := http.DefaultClient.Do(req)
+	respBody, err := c.do(ctx, http.MethodPost, "/records", body)
 	if err != nil {
-		return Record{}, fmt.Errorf("failed request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read Response Body
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return Record{}, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	// Error
-	if resp.StatusCode != http.StatusOK {
-
-		return Record{}, parseError(resp.StatusCode, respBody)
+		return Record{}, err
 	}
 
 	v := struct {
@@ -145,29 +106,13 @@ func (c *Client) CreateRecord(name string, ttl int, t string, value string, zone
 
 // DeleteRecord deletes a record with id id.
 func (c *Client) DeleteRecord(id string) error {
+	return c.DeleteRecordContext(context.Background(), id)
+}
 
-	req, err := http.NewRequest("DELETE", BaseURL+"/records/"+id, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Add("Auth-API-Token", c.key)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusOK {
-		return nil
-	}
+// DeleteRecordContext deletes a record with id id.
+func (c *Client) DeleteRecordContext(ctx context.Context, id string) error {
 
-	// Read Response Body
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
-	}
+	_, err := c.do(ctx, http.MethodDelete, "/records/"+url.PathEscape(id), nil)
 
-	return parseError(resp.StatusCode, respBody)
+	return err
 }
@@ -0,0 +1,193 @@
+package hetzner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// retryAfter returns the time to wait based on the Retry-After header.
+// Retry-After can be a number of seconds or a HTTP date.
+// Returns 0 if the header is missing or invalid.
+func retryAfter(h http.Header, now time.Time) time.Duration {
+
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+
+	if sec, err := strconv.Atoi(v); err == nil {
+		if sec < 0 {
+			return 0
+		}
+		return time.Duration(sec) * time.Second
+	}
+
+	t, err := http.ParseTime(v)
+	if err != nil || t.Before(now) {
+		return 0
+	}
+
+	return t.Sub(now)
+}
+
+// rateLimitReset returns the time when the rate limit resets if the remaining requests is 0 (Ratelimit-Remaining and Ratelimit-Reset headers).
+// Ratelimit-Reset can be a unix timestamp or a number of seconds.
+// Returns the zero time if the limit is not reached or the headers are missing.
+func rateLimitReset(h http.Header, now time.Time) time.Time {
+
+	if h.Get("Ratelimit-Remaining") != "0" {
+		return time.Time{}
+	}
+
+	reset, err := strconv.ParseInt(h.Get("Ratelimit-Reset"), 10, 64)
+	if err != nil || reset <= 0 {
+		return time.Time{}
+	}
+
+	// Values greater than a year are unix timestamps
+	if reset > 365*24*60*60 {
+		return time.Unix(reset, 0)
+	}
+
+	return now.Add(time.Duration(reset) * time.Second)
+}
+
+// backoff returns the wait time before the n-th retry (starting from 0).
+func (c *Client) backoff(n int) time.Duration {
+
+	d := c.minBackoff
+
+	for i := 0; i < n && d < c.maxBackoff; i++ {
+		d *= 2
+	}
+
+	if d > c.maxBackoff {
+		d = c.maxBackoff
+	}
+
+	return d
+}
+
+// isIdempotent returns whether the request with method can be sent again without side effects.
+func isIdempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodPut || method == http.MethodDelete
+}
+
+// isRetryable returns whether the request with method and status code code should be retried.
+// The non idempotent requests (eg.: POST) are retried only on 429, because a 5xx can be returned after the request is processed.
+func isRetryable(method string, code int) bool {
+
+	if code == http.StatusTooManyRequests {
+		return true
+	}
+
+	return code >= 500 && isIdempotent(method)
+}
+
+// do sends a request with method to BaseURL+path and returns the response body.
+// If v is not nil, it is encoded as JSON and used as the request body.
+//
+// The request is retried on 429 with exponential backoff.
+// The idempotent requests (GET, PUT and DELETE) are retried on 5xx and network errors too.
+// If the Retry-After header is set, waits at least the returned time.
+// If the status code is not 200, returns the error parsed from the body.
+func (c *Client) do(ctx context.Context, method string, path string, v any) ([]byte, error) {
+
+	var body []byte
+
+	if v != nil {
+
+		var err error
+
+		body, err = json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal body: %w", err)
+		}
+	}
+
+	for i := 0; ; i++ {
+
+		if c.limiter != nil {
+			if err := c.limiter.wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		respBody, resp, err := c.send(ctx, method, path, body)
+
+		if err == nil && resp.StatusCode == http.StatusOK {
+			return respBody, nil
+		}
+
+		var wait time.Duration
+
+		if err == nil {
+
+			if c.limiter != nil {
+				if reset := rateLimitReset(resp.Header, time.Now()); !reset.IsZero() {
+					c.limiter.pause(reset)
+				}
+			}
+
+			if !isRetryable(method, resp.StatusCode) || i >= c.maxRetries {
+				return nil, parseError(resp, respBody)
+			}
+
+			wait = retryAfter(resp.Header, time.Now())
+
+		} else if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || !isIdempotent(method) || i >= c.maxRetries {
+			return nil, err
+		}
+
+		if b := c.backoff(i); b > wait {
+			wait = b
+		}
+
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// send does a single request and returns the response body and the response.
+func (c *Client) send(ctx context.Context, method string, path string, body []byte) ([]byte, *http.Response, error) {
+
+	var r io.Reader
+
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, BaseURL+path, r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Add("Auth-API-Token", c.key)
+	req.Header.Add("User-Agent", c.userAgent)
+
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Read Response Body
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	return respBody, resp, nil
+}
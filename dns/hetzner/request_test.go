@@ -0,0 +1,288 @@
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testServer starts a server with handler h and sets BaseURL to it.
+func testServer(t *testing.T, h http.HandlerFunc) {
+
+	srv := httptest.NewServer(h)
+
+	orig := BaseURL
+	BaseURL = srv.URL
+
+	t.Cleanup(func() {
+		BaseURL = orig
+		srv.Close()
+	})
+}
+
+func TestDoRetry(t *testing.T) {
+
+	n := new(atomic.Int32)
+
+	testServer(t, func(w http.ResponseWriter, r *http.Request) {
+
+		if r.Header.Get("User-Agent") != DefaultUserAgent {
+			t.Errorf("FAIL: invalid User-Agent: %s\n", r.Header.Get("User-Agent"))
+		}
+
+		if n.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			fmt.Fprint(w, `{"error":{"message":"bad gateway","code":502}}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"zones":[{"id":"1","name":"example.com"}]}`)
+	})
+
+	c := NewClient("key")
+	c.SetRetries(3, time.Millisecond, 10*time.Millisecond)
+
+	zs, err := c.GetAllZones()
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if len(zs) != 1 || n.Load() != 3 {
+		t.Fatalf("FAIL: zones: %d, requests: %d\n", len(zs), n.Load())
+	}
+}
+
+func TestDoNoRetryPOST(t *testing.T) {
+
+	n := new(atomic.Int32)
+
+	testServer(t, func(w http.ResponseWriter, r *http.Request) {
+
+		// The record is created, but the gateway fails
+		if n.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			fmt.Fprint(w, `<html><body>502 Bad Gateway</body></html>`)
+			return
+		}
+
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	c := NewClient("key")
+	c.SetRetries(3, time.Millisecond, 10*time.Millisecond)
+
+	if _, err := c.CreateRecord("_acme-challenge", 60, "TXT", "value", "1"); err == nil {
+		t.Fatalf("FAIL: want error\n")
+	}
+
+	if n.Load() != 1 {
+		t.Fatalf("FAIL: POST retried on 5xx, requests: %d\n", n.Load())
+	}
+
+	// 429 means the request is not processed, POST is retried
+	n.Store(1)
+
+	var e *RateLimitError
+
+	if _, err := c.CreateRecord("_acme-challenge", 60, "TXT", "value", "1"); !errors.As(err, &e) {
+		t.Fatalf("FAIL: error got: %v, want: RateLimitError\n", err)
+	}
+
+	if n.Load() != 5 {
+		t.Fatalf("FAIL: POST not retried on 429, requests: %d\n", n.Load()-1)
+	}
+}
+
+func TestDoRateLimited(t *testing.T) {
+
+	n := new(atomic.Int32)
+
+	testServer(t, func(w http.ResponseWriter, r *http.Request) {
+
+		n.Add(1)
+
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	c := NewClient("key")
+	c.SetRetries(1, time.Millisecond, time.Millisecond)
+
+	start := time.Now()
+
+	_, err := c.GetAllZones()
+
+	var e *RateLimitError
+
+	if !errors.As(err, &e) {
+		t.Fatalf("FAIL: error got: %v, want: RateLimitError\n", err)
+	}
+
+	if e.RetryAfter != time.Second {
+		t.Fatalf("FAIL: RetryAfter got: %s, want: 1s\n", e.RetryAfter)
+	}
+
+	if n.Load() != 2 || time.Since(start) < time.Second {
+		t.Fatalf("FAIL: requests: %d, elapsed: %s\n", n.Load(), time.Since(start))
+	}
+}
+
+func TestDoContext(t *testing.T) {
+
+	testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `{"error":{"message":"unavailable","code":503}}`)
+	})
+
+	c := NewClient("key")
+	c.SetRetries(10, time.Second, time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := c.GetAllZonesContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("FAIL: error got: %v, want: %s\n", err, context.DeadlineExceeded)
+	}
+}
+
+func TestDoTypedErrors(t *testing.T) {
+
+	testServer(t, func(w http.ResponseWriter, r *http.Request) {
+
+		switch r.Header.Get("Auth-API-Token") {
+		case "":
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message":"No API key found in request"}`)
+		case "invalid":
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message":"Invalid authentication credentials"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"records":null,"error":{"message":"zone not found","code":404}}`)
+		}
+	})
+
+	var unauth *UnauthorizedError
+
+	_, err := NewClient("").GetAllRecords()
+	if !errors.As(err, &unauth) || !errors.Is(err, ErrNoAPIKey) {
+		t.Fatalf("FAIL: error got: %v, want: %s\n", err, ErrNoAPIKey)
+	}
+
+	_, err = NewClient("invalid").GetAllRecords()
+	if !errors.As(err, &unauth) || !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("FAIL: error got: %v, want: %s\n", err, ErrInvalidAPIKey)
+	}
+
+	var notFound *NotFoundError
+
+	_, err = NewClient("key").GetAllRecordsByZone("notexists")
+	if !errors.As(err, &notFound) || !errors.Is(err, ErrZoneNotFound) {
+		t.Fatalf("FAIL: error got: %v, want: %s\n", err, ErrZoneNotFound)
+	}
+}
+
+func TestDoTypedErrorsNoJSON(t *testing.T) {
+
+	testServer(t, func(w http.ResponseWriter, r *http.Request) {
+
+		switch r.Header.Get("Auth-API-Token") {
+		case "unauthorized":
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `<html><body><h1>401 Unauthorized</h1></body></html>`)
+		case "ratelimited":
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "gateway":
+			w.WriteHeader(http.StatusBadGateway)
+			fmt.Fprint(w, `<html><body><h1>502 Bad Gateway</h1></body></html>`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	var unauth *UnauthorizedError
+
+	_, err := NewClient("unauthorized").GetAllRecords()
+	if !errors.As(err, &unauth) || unauth.StatusCode != http.StatusUnauthorized || unauth.Message != "Unauthorized" {
+		t.Fatalf("FAIL: error got: %v, want: UnauthorizedError\n", err)
+	}
+
+	c := NewClient("ratelimited")
+	c.SetRetries(0, time.Millisecond, time.Millisecond)
+
+	var rate *RateLimitError
+
+	_, err = c.GetAllRecords()
+	if !errors.As(err, &rate) || rate.RetryAfter != 2*time.Second {
+		t.Fatalf("FAIL: error got: %v, want: RateLimitError\n", err)
+	}
+
+	c = NewClient("gateway")
+	c.SetRetries(0, time.Millisecond, time.Millisecond)
+
+	_, err = c.GetAllRecords()
+	if err == nil || err.Error() != "Bad Gateway (502)" {
+		t.Fatalf("FAIL: error got: %v, want: Bad Gateway (502)\n", err)
+	}
+
+	var notFound *NotFoundError
+
+	_, err = NewClient("key").GetAllRecordsByZone("notexists")
+	if !errors.As(err, &notFound) || notFound.Message != "Not Found" {
+		t.Fatalf("FAIL: error got: %v, want: NotFoundError\n", err)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		Value string
+		Want  time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"-1", 0},
+		{"invalid", 0},
+		{now.Add(time.Minute).Format(http.TimeFormat), time.Minute},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+
+	for i := range cases {
+
+		h := http.Header{}
+		h.Set("Retry-After", cases[i].Value)
+
+		if r := retryAfter(h, now); r != cases[i].Want {
+			t.Fatalf("FAIL: %s: want %s, got %s\n", cases[i].Value, cases[i].Want, r)
+		}
+	}
+}
+
+func TestBucket(t *testing.T) {
+
+	b := newBucket(10, 2)
+
+	// The burst is available immediately
+	if b.reserve() != 0 || b.reserve() != 0 {
+		t.Fatalf("FAIL: burst is not available\n")
+	}
+
+	if d := b.reserve(); d <= 0 || d > 100*time.Millisecond {
+		t.Fatalf("FAIL: unexpected wait: %s\n", d)
+	}
+
+	b.pause(time.Now().Add(time.Second))
+
+	if d := b.reserve(); d < 900*time.Millisecond {
+		t.Fatalf("FAIL: pause is not respected: %s\n", d)
+	}
+}
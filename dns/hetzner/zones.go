@@ -1,10 +1,11 @@
 package hetzner
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
 )
 
 type TXTVerification struct {
@@ -51,30 +52,15 @@ type Zones struct {
 
 // GetAllZones returns every zones associated with the user.
 func (c *Client) GetAllZones() ([]Zone, error) {
+	return c.GetAllZonesContext(context.Background())
+}
 
-	req, err := http.NewRequest("GET", BaseURL+"/zones", nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Add("Auth-API-Token", c.key)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed request: %w", err)
-	}
-	defer resp.Body.Close()
+// GetAllZonesContext returns every zones associated with the user.
+func (c *Client) GetAllZonesContext(ctx context.Context) ([]Zone, error) {
 
-	// Read Response Body
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := c.do(ctx, http.MethodGet, "/zones", nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	// Error
-	if resp.StatusCode != http.StatusOK {
-
-		return nil, parseError(resp.StatusCode, respBody)
+		return nil, err
 	}
 
 	zones := new(Zones)
@@ -89,30 +75,15 @@ func (c *Client) GetAllZones() ([]Zone, error) {
 
 // GetZoneByName returns the zone associated with the user with name name.
 func (c *Client) GetZoneByName(name string) (Zone, error) {
+	return c.GetZoneByNameContext(context.Background(), name)
+}
 
-	req, err := http.NewRequest("GET", BaseURL+"/zones?name="+name, nil)
-	if err != nil {
-		return Zone{}, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Add("Auth-API-Token", c.key)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return Zone{}, fmt.Errorf("failed request: %w", err)
-	}
-	defer resp.Body.Close()
+// GetZoneByNameContext returns the zone associated with the user with name name.
+func (c *Client) GetZoneByNameContext(ctx context.Context, name string) (Zone, error) {
 
-	// Read Response Body
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := c.do(ctx, http.MethodGet, "/zones?name="+url.QueryEscape(name), nil)
 	if err != nil {
-		return Zone{}, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	// Error
-	if resp.StatusCode != http.StatusOK {
-
-		return Zone{}, parseError(resp.StatusCode, respBody)
+		return Zone{}, err
 	}
 
 	zones := new(Zones)
@@ -123,7 +94,7 @@ func (c *Client) GetZoneByName(name string) (Zone, error) {
 	}
 
 	if len(zones.Zones) < 1 {
-		return Zone{}, ErrZoneNotFound
+		return Zone{}, &NotFoundError{Message: "zone not found", Err: ErrZoneNotFound}
 	}
 
 	if len(zones.Zones) > 1 {
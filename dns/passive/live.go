@@ -0,0 +1,47 @@
+package passive
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/afpacket"
+	"github.com/google/gopacket/layers"
+)
+
+// liveSource wraps the TPacket to return ErrTimeout on poll timeout.
+type liveSource struct {
+	tp *afpacket.TPacket
+}
+
+// Implement gopacket.PacketDataSource
+func (s *liveSource) ReadPacketData() ([]byte, gopacket.CaptureInfo, error) {
+
+	data, ci, err := s.tp.ReadPacketData()
+	if errors.Is(err, afpacket.ErrTimeout) {
+		err = ErrTimeout
+	}
+
+	return data, ci, err
+}
+
+// OpenLive creates a new Capture that reads from a live AF_PACKET socket (requires CAP_NET_RAW).
+// If iface is empty, captures on every interface.
+//
+// Next() returns ErrTimeout if no packet arrived within timeout.
+func OpenLive(iface string, timeout time.Duration) (*Capture, error) {
+
+	opts := []interface{}{afpacket.OptPollTimeout(timeout)}
+
+	if iface != "" {
+		opts = append(opts, afpacket.OptInterface(iface))
+	}
+
+	tp, err := afpacket.NewTPacket(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tpacket: %w", err)
+	}
+
+	return newCapture(&liveSource{tp: tp}, layers.LinkTypeEthernet, tp.Close), nil
+}
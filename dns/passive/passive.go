@@ -0,0 +1,223 @@
+/*
+Package passive extracts DNS queries and responses from captured network traffic.
+
+The traffic can be read from pcap/pcapng files with OpenFile() or from a live AF_PACKET socket with OpenLive().
+DNS over UDP and TCP is decoded, the TCP streams are reassembled.
+*/
+package passive
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"time"
+
+	"github.com/elmasy-com/elnet/dns"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	mdns "github.com/miekg/dns"
+)
+
+var (
+	// ErrTimeout is returned by Next() if no packet arrived within the poll timeout of a live capture.
+	ErrTimeout = errors.New("timeout")
+)
+
+// Event is a DNS message seen on the network.
+type Event struct {
+	Time     time.Time      // Capture time of the packet (the last segment in case of TCP)
+	Client   netip.AddrPort // The client address
+	Server   netip.AddrPort // The server address
+	Protocol string         // "udp" or "tcp"
+	Response bool           // Whether the message is a response
+	ID       uint16         // Message ID
+	QName    string         // Name in the question section (cleaned, eg.: "example.com")
+	QType    uint16         // Type in the question section
+	Answers  []dns.Record   // Answer section
+	Rcode    int            // Response code
+}
+
+// Capture reads DNS events from a packet source.
+type Capture struct {
+	src      gopacket.PacketDataSource
+	linkType layers.LinkType
+	ports    []uint16
+	streams  *assembler
+	pending  []Event
+	close    func()
+}
+
+func newCapture(src gopacket.PacketDataSource, linkType layers.LinkType, close func()) *Capture {
+
+	return &Capture{src: src, linkType: linkType, ports: []uint16{53}, streams: newAssembler(), close: close}
+}
+
+// SetPorts sets the ports used to recognize DNS traffic. The default is 53.
+func (c *Capture) SetPorts(ports ...uint16) {
+	c.ports = ports
+}
+
+// isDNSPort returns whether any of the ports is a DNS port.
+func (c *Capture) isDNSPort(src uint16, dst uint16) bool {
+
+	for i := range c.ports {
+		if c.ports[i] == src || c.ports[i] == dst {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Next returns the next DNS event.
+//
+// Returns io.EOF at the end of a file.
+// In case of a live capture, returns ErrTimeout if no packet arrived within the poll timeout.
+func (c *Capture) Next() (Event, error) {
+
+	for len(c.pending) == 0 {
+
+		data, ci, err := c.src.ReadPacketData()
+		if err != nil {
+			return Event{}, err
+		}
+
+		c.decode(data, ci.Timestamp)
+	}
+
+	e := c.pending[0]
+	c.pending = c.pending[1:]
+
+	return e, nil
+}
+
+// Run sends the events to events until the end of the source or ctx is done.
+// The events channel is closed when Run returns.
+//
+// Returns nil at the end of a file, else the error (eg.: ctx.Err()).
+func (c *Capture) Run(ctx context.Context, events chan<- Event) error {
+
+	defer close(events)
+
+	for {
+
+		e, err := c.Next()
+		if err != nil {
+
+			if errors.Is(err, ErrTimeout) {
+
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+
+				continue
+			}
+
+			if isEOF(err) {
+				return nil
+			}
+
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case events <- e:
+		}
+	}
+}
+
+// Close closes the underlying source.
+func (c *Capture) Close() {
+
+	if c.close != nil {
+		c.close()
+	}
+}
+
+// toAddr converts ip to netip.Addr.
+func toAddr(ip []byte) netip.Addr {
+
+	a, _ := netip.AddrFromSlice(ip)
+
+	return a.Unmap()
+}
+
+// decode decodes the packet and appends the DNS events to c.pending.
+func (c *Capture) decode(data []byte, ts time.Time) {
+
+	p := gopacket.NewPacket(data, c.linkType, gopacket.DecodeOptions{Lazy: true, NoCopy: true})
+
+	var src, dst netip.Addr
+
+	switch v := p.NetworkLayer().(type) {
+	case *layers.IPv4:
+		src, dst = toAddr(v.SrcIP), toAddr(v.DstIP)
+	case *layers.IPv6:
+		src, dst = toAddr(v.SrcIP), toAddr(v.DstIP)
+	default:
+		return
+	}
+
+	switch v := p.TransportLayer().(type) {
+	case *layers.UDP:
+
+		if !c.isDNSPort(uint16(v.SrcPort), uint16(v.DstPort)) {
+			return
+		}
+
+		srcAP := netip.AddrPortFrom(src, uint16(v.SrcPort))
+		dstAP := netip.AddrPortFrom(dst, uint16(v.DstPort))
+
+		if e, ok := parseMessage(v.Payload, ts, srcAP, dstAP, "udp"); ok {
+			c.pending = append(c.pending, e)
+		}
+
+	case *layers.TCP:
+
+		if !c.isDNSPort(uint16(v.SrcPort), uint16(v.DstPort)) {
+			return
+		}
+
+		k := flowKey{src: netip.AddrPortFrom(src, uint16(v.SrcPort)), dst: netip.AddrPortFrom(dst, uint16(v.DstPort))}
+
+		msgs := c.streams.add(k, v, ts)
+
+		for i := range msgs {
+			if e, ok := parseMessage(msgs[i], ts, k.src, k.dst, "tcp"); ok {
+				c.pending = append(c.pending, e)
+			}
+		}
+	}
+}
+
+// parseMessage parses the DNS message in b.
+// Returns false if b is not a valid DNS message.
+func parseMessage(b []byte, ts time.Time, src netip.AddrPort, dst netip.AddrPort, protocol string) (Event, bool) {
+
+	m := new(mdns.Msg)
+
+	if err := m.Unpack(b); err != nil {
+		return Event{}, false
+	}
+
+	e := Event{Time: ts, Protocol: protocol, Response: m.Response, ID: m.Id, Rcode: m.Rcode}
+
+	if m.Response {
+		e.Client, e.Server = dst, src
+	} else {
+		e.Client, e.Server = src, dst
+	}
+
+	if len(m.Question) > 0 {
+		e.QName = dns.Clean(m.Question[0].Name)
+		e.QType = m.Question[0].Qtype
+	}
+
+	for i := range m.Answer {
+		e.Answers = append(e.Answers, dns.RecordFromRR(m.Answer[i]))
+	}
+
+	return e, true
+}
@@ -0,0 +1,224 @@
+package passive
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	mdns "github.com/miekg/dns"
+)
+
+var (
+	clientIP = net.IP{192, 168, 1, 10}
+	serverIP = net.IP{192, 168, 1, 1}
+)
+
+// packet creates an Ethernet frame with transport layer t and payload.
+func packet(t *testing.T, src net.IP, dst net.IP, tl gopacket.SerializableLayer, payload []byte) []byte {
+
+	eth := &layers.Ethernet{
+		SrcMAC:       net.HardwareAddr{0, 1, 2, 3, 4, 5},
+		DstMAC:       net.HardwareAddr{0, 1, 2, 3, 4, 6},
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+
+	ip := &layers.IPv4{Version: 4, TTL: 64, SrcIP: src, DstIP: dst}
+
+	switch v := tl.(type) {
+	case *layers.UDP:
+		ip.Protocol = layers.IPProtocolUDP
+		v.SetNetworkLayerForChecksum(ip)
+	case *layers.TCP:
+		ip.Protocol = layers.IPProtocolTCP
+		v.SetNetworkLayerForChecksum(ip)
+	}
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+
+	err := gopacket.SerializeLayers(buf, opts, eth, ip, tl, gopacket.Payload(payload))
+	if err != nil {
+		t.Fatalf("FAIL: failed to serialize: %s\n", err)
+	}
+
+	return buf.Bytes()
+}
+
+// messages returns a packed query and response for name.
+func messages(t *testing.T, name string) ([]byte, []byte) {
+
+	q := new(mdns.Msg)
+	q.SetQuestion(mdns.Fqdn(name), mdns.TypeA)
+
+	r := new(mdns.Msg)
+	r.SetReply(q)
+	r.Answer = append(r.Answer, &mdns.A{
+		Hdr: mdns.RR_Header{Name: mdns.Fqdn(name), Rrtype: mdns.TypeA, Class: mdns.ClassINET, Ttl: 60},
+		A:   net.IP{93, 184, 216, 34},
+	})
+
+	qb, err := q.Pack()
+	if err != nil {
+		t.Fatalf("FAIL: failed to pack query: %s\n", err)
+	}
+
+	rb, err := r.Pack()
+	if err != nil {
+		t.Fatalf("FAIL: failed to pack response: %s\n", err)
+	}
+
+	return qb, rb
+}
+
+// testPackets returns an UDP query/response and a TCP query/response with the response segments out-of-order.
+func testPackets(t *testing.T) [][]byte {
+
+	var r [][]byte
+
+	qb, rb := messages(t, "udp.example.com")
+
+	r = append(r, packet(t, clientIP, serverIP, &layers.UDP{SrcPort: 40000, DstPort: 53}, qb))
+	r = append(r, packet(t, serverIP, clientIP, &layers.UDP{SrcPort: 53, DstPort: 40000}, rb))
+
+	qb, rb = messages(t, "tcp.example.com")
+
+	tq := make([]byte, 2, 2+len(qb))
+	binary.BigEndian.PutUint16(tq, uint16(len(qb)))
+	tq = append(tq, qb...)
+
+	tr := make([]byte, 2, 2+len(rb))
+	binary.BigEndian.PutUint16(tr, uint16(len(rb)))
+	tr = append(tr, rb...)
+
+	r = append(r, packet(t, clientIP, serverIP, &layers.TCP{SrcPort: 40001, DstPort: 53, Seq: 100, SYN: true}, nil))
+	r = append(r, packet(t, serverIP, clientIP, &layers.TCP{SrcPort: 53, DstPort: 40001, Seq: 500, SYN: true, ACK: true}, nil))
+	r = append(r, packet(t, clientIP, serverIP, &layers.TCP{SrcPort: 40001, DstPort: 53, Seq: 101, ACK: true}, tq))
+
+	// Second half first
+	r = append(r, packet(t, serverIP, clientIP, &layers.TCP{SrcPort: 53, DstPort: 40001, Seq: 501 + 10, ACK: true}, tr[10:]))
+	r = append(r, packet(t, serverIP, clientIP, &layers.TCP{SrcPort: 53, DstPort: 40001, Seq: 501, ACK: true}, tr[:10]))
+
+	// Retransmission
+	r = append(r, packet(t, serverIP, clientIP, &layers.TCP{SrcPort: 53, DstPort: 40001, Seq: 501, ACK: true}, tr[:10]))
+
+	return r
+}
+
+func checkEvents(t *testing.T, events []Event) {
+
+	if len(events) != 4 {
+		t.Fatalf("FAIL: want 4 events, got %d: %#v\n", len(events), events)
+	}
+
+	want := []struct {
+		Protocol string
+		Response bool
+		QName    string
+	}{
+		{"udp", false, "udp.example.com"},
+		{"udp", true, "udp.example.com"},
+		{"tcp", false, "tcp.example.com"},
+		{"tcp", true, "tcp.example.com"},
+	}
+
+	for i := range events {
+
+		e := events[i]
+
+		if e.Protocol != want[i].Protocol || e.Response != want[i].Response || e.QName != want[i].QName || e.QType != mdns.TypeA {
+			t.Fatalf("FAIL: event %d: unexpected event: %#v\n", i, e)
+		}
+
+		if e.Client.Addr().String() != clientIP.String() || e.Server.Addr().String() != serverIP.String() || e.Server.Port() != 53 {
+			t.Fatalf("FAIL: event %d: invalid addresses: client=%s server=%s\n", i, e.Client, e.Server)
+		}
+
+		if e.Response && (len(e.Answers) != 1 || e.Answers[0].Value != "93.184.216.34" || e.Rcode != mdns.RcodeSuccess) {
+			t.Fatalf("FAIL: event %d: invalid answers: %#v\n", i, e.Answers)
+		}
+	}
+}
+
+func TestReadFilePcap(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "dns.pcap")
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	w := pcapgo.NewWriter(f)
+
+	if err := w.WriteFileHeader(65536, layers.LinkTypeEthernet); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	for _, p := range testPackets(t) {
+
+		ci := gopacket.CaptureInfo{Timestamp: time.Now(), CaptureLength: len(p), Length: len(p)}
+
+		if err := w.WritePacket(ci, p); err != nil {
+			t.Fatalf("FAIL: %s\n", err)
+		}
+	}
+
+	f.Close()
+
+	events, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	checkEvents(t, events)
+}
+
+func TestNewReaderPcapng(t *testing.T) {
+
+	buf := new(bytes.Buffer)
+
+	w, err := pcapgo.NewNgWriter(buf, layers.LinkTypeEthernet)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	for _, p := range testPackets(t) {
+
+		ci := gopacket.CaptureInfo{Timestamp: time.Now(), CaptureLength: len(p), Length: len(p)}
+
+		if err := w.WritePacket(ci, p); err != nil {
+			t.Fatalf("FAIL: %s\n", err)
+		}
+	}
+
+	w.Flush()
+
+	c, err := NewReader(buf)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	var events []Event
+
+	for {
+		e, err := c.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("FAIL: %s\n", err)
+		}
+
+		events = append(events, e)
+	}
+
+	checkEvents(t, events)
+}
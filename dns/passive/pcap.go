@@ -0,0 +1,93 @@
+package passive
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/gopacket/pcapgo"
+)
+
+// pcapngMagic is the block type of the Section Header Block, the first block in a pcapng file.
+var pcapngMagic = []byte{0x0A, 0x0D, 0x0D, 0x0A}
+
+func isEOF(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// NewReader creates a new Capture that reads pcap or pcapng data from r.
+// The format is detected from the magic number.
+func NewReader(r io.Reader) (*Capture, error) {
+
+	br := bufio.NewReader(r)
+
+	magic, err := br.Peek(4)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read magic: %w", err)
+	}
+
+	if string(magic) == string(pcapngMagic) {
+
+		ng, err := pcapgo.NewNgReader(br, pcapgo.DefaultNgReaderOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create pcapng reader: %w", err)
+		}
+
+		return newCapture(ng, ng.LinkType(), nil), nil
+	}
+
+	pr, err := pcapgo.NewReader(br)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pcap reader: %w", err)
+	}
+
+	return newCapture(pr, pr.LinkType(), nil), nil
+}
+
+// OpenFile creates a new Capture that reads the pcap or pcapng file path.
+func OpenFile(path string) (*Capture, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	c.close = func() { f.Close() }
+
+	return c, nil
+}
+
+// ReadFile returns every DNS event from the pcap or pcapng file path.
+func ReadFile(path string) ([]Event, error) {
+
+	c, err := OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	var r []Event
+
+	for {
+
+		e, err := c.Next()
+		if err != nil {
+
+			if isEOF(err) {
+				return r, nil
+			}
+
+			return r, err
+		}
+
+		r = append(r, e)
+	}
+}
@@ -0,0 +1,180 @@
+package passive
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"time"
+
+	"github.com/google/gopacket/layers"
+)
+
+const (
+	maxStreams       = 4096            // Maximum number of tracked streams, the least recently used is removed above this
+	maxSegments      = 64              // Maximum number of out-of-order segments per stream
+	maxSegmentBytes  = 2 + 65535       // Maximum size of the out-of-order segments per stream (the size of the largest message)
+	minMessageSize   = 12              // Size of the DNS header, a shorter length prefix is implausible
+	streamIdleTimout = 2 * time.Minute // Streams without packets for this time are removed
+)
+
+// flowKey identifies a direction of a TCP connection.
+type flowKey struct {
+	src netip.AddrPort
+	dst netip.AddrPort
+}
+
+// stream is a direction of a TCP connection.
+type stream struct {
+	next     uint32            // The next expected sequence number
+	buf      []byte            // Reassembled, but not processed bytes
+	segments map[uint32][]byte // Out-of-order segments by sequence number
+	pending  int               // Size of the out-of-order segments
+	last     time.Time         // Time of the last packet
+}
+
+// assembler reassembles the TCP streams and splits them into DNS messages.
+type assembler struct {
+	streams map[flowKey]*stream
+}
+
+func newAssembler() *assembler {
+	return &assembler{streams: make(map[flowKey]*stream)}
+}
+
+// gc removes the idle streams if the number of streams reached maxStreams.
+// If every stream is active, the least recently used is removed to make room for a new stream.
+func (a *assembler) gc(now time.Time) {
+
+	if len(a.streams) < maxStreams {
+		return
+	}
+
+	var (
+		oldest flowKey
+		last   time.Time
+	)
+
+	for k, v := range a.streams {
+
+		if now.Sub(v.last) > streamIdleTimout {
+			delete(a.streams, k)
+			continue
+		}
+
+		if last.IsZero() || v.last.Before(last) {
+			oldest, last = k, v.last
+		}
+	}
+
+	if len(a.streams) >= maxStreams {
+		delete(a.streams, oldest)
+	}
+}
+
+// add adds the TCP segment to the stream k and returns the complete DNS messages (without the length prefix).
+//
+// If the start of the stream was not captured (no SYN), the first payload is assumed to start with a message.
+// The stream is removed if a length prefix is implausible (eg.: the capture started in the middle of a message).
+// The buffered bytes of a stream are limited, the reassembled buffer holds at most one incomplete message
+// and the out-of-order segments are dropped above maxSegments or maxSegmentBytes.
+func (a *assembler) add(k flowKey, tcp *layers.TCP, ts time.Time) [][]byte {
+
+	if tcp.RST {
+		delete(a.streams, k)
+		return nil
+	}
+
+	s, ok := a.streams[k]
+
+	if !ok {
+
+		if !tcp.SYN && len(tcp.Payload) == 0 {
+			return nil
+		}
+
+		a.gc(ts)
+
+		s = &stream{next: tcp.Seq, segments: make(map[uint32][]byte)}
+		a.streams[k] = s
+	}
+
+	s.last = ts
+
+	if tcp.SYN {
+		s.next = tcp.Seq + 1
+		s.buf = nil
+		return nil
+	}
+
+	payload := tcp.Payload
+
+	if len(payload) > 0 {
+
+		diff := int32(tcp.Seq - s.next)
+
+		switch {
+		case diff > 0:
+
+			// Future segment, store a copy until the missing bytes arrive
+			if _, ok := s.segments[tcp.Seq]; !ok && len(s.segments) < maxSegments && s.pending+len(payload) <= maxSegmentBytes {
+				s.segments[tcp.Seq] = append([]byte(nil), payload...)
+				s.pending += len(payload)
+			}
+
+			payload = nil
+
+		case diff < 0:
+
+			// Retransmission, keep the new bytes only
+			if int(-diff) >= len(payload) {
+				payload = nil
+			} else {
+				payload = payload[-diff:]
+			}
+		}
+
+		if len(payload) > 0 {
+
+			s.buf = append(s.buf, payload...)
+			s.next += uint32(len(payload))
+
+			for {
+				seg, ok := s.segments[s.next]
+				if !ok {
+					break
+				}
+
+				delete(s.segments, s.next)
+				s.pending -= len(seg)
+
+				s.buf = append(s.buf, seg...)
+				s.next += uint32(len(seg))
+			}
+		}
+	}
+
+	var msgs [][]byte
+
+	// DNS over TCP messages are prefixed with a two byte length field
+	for len(s.buf) >= 2 {
+
+		l := int(binary.BigEndian.Uint16(s.buf))
+
+		if l < minMessageSize {
+			delete(a.streams, k)
+			return msgs
+		}
+
+		if len(s.buf) < 2+l {
+			break
+		}
+
+		msgs = append(msgs, s.buf[2:2+l])
+		s.buf = s.buf[2+l:]
+	}
+
+	if tcp.FIN {
+		delete(a.streams, k)
+	}
+
+	return msgs
+}
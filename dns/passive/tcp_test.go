@@ -0,0 +1,113 @@
+package passive
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket/layers"
+)
+
+// segment returns a TCP segment with seq and payload.
+func segment(seq uint32, syn bool, payload []byte) *layers.TCP {
+
+	tcp := &layers.TCP{Seq: seq, SYN: syn}
+	tcp.Payload = payload
+
+	return tcp
+}
+
+// testKey returns the flow key of the client port i.
+func testKey(i int) flowKey {
+
+	return flowKey{
+		src: netip.AddrPortFrom(netip.MustParseAddr("192.168.1.10"), uint16(1024+i)),
+		dst: netip.AddrPortFrom(netip.MustParseAddr("192.168.1.1"), 53),
+	}
+}
+
+// prefixed returns msg with the two byte length prefix.
+func prefixed(msg []byte) []byte {
+
+	b := make([]byte, 2, 2+len(msg))
+	binary.BigEndian.PutUint16(b, uint16(len(msg)))
+
+	return append(b, msg...)
+}
+
+func TestAssembler(t *testing.T) {
+
+	a := newAssembler()
+	k := testKey(0)
+	ts := time.Now()
+
+	msg := bytes.Repeat([]byte{1}, 20)
+	data := append(prefixed(msg), prefixed(msg)...)
+
+	a.add(k, segment(99, true, nil), ts)
+
+	// The second half arrives first
+	if msgs := a.add(k, segment(100+20, false, data[20:]), ts); len(msgs) != 0 {
+		t.Fatalf("FAIL: out-of-order segment returned %d messages\n", len(msgs))
+	}
+
+	if msgs := a.add(k, segment(100, false, data[:20]), ts); len(msgs) != 2 || !bytes.Equal(msgs[0], msg) || !bytes.Equal(msgs[1], msg) {
+		t.Fatalf("FAIL: invalid messages: %x\n", msgs)
+	}
+
+	if s := a.streams[k]; s == nil || len(s.segments) != 0 || s.pending != 0 || len(s.buf) != 0 {
+		t.Fatalf("FAIL: invalid stream after reassembly: %#v\n", s)
+	}
+}
+
+func TestAssemblerImplausible(t *testing.T) {
+
+	a := newAssembler()
+	k := testKey(0)
+
+	// The capture started in the middle of a message
+	if msgs := a.add(k, segment(100, false, []byte{0x00, 0x05, 1, 2, 3, 4, 5}), time.Now()); len(msgs) != 0 {
+		t.Fatalf("FAIL: implausible message returned: %x\n", msgs)
+	}
+
+	if _, ok := a.streams[k]; ok {
+		t.Fatalf("FAIL: stream with implausible length prefix is not dropped\n")
+	}
+}
+
+func TestAssemblerLimits(t *testing.T) {
+
+	a := newAssembler()
+	ts := time.Now()
+
+	for i := 0; i < maxStreams+10; i++ {
+		a.add(testKey(i), segment(1, true, nil), ts.Add(time.Duration(i)*time.Millisecond))
+	}
+
+	if len(a.streams) != maxStreams {
+		t.Fatalf("FAIL: want %d streams, got %d\n", maxStreams, len(a.streams))
+	}
+
+	// The least recently used are removed
+	if _, ok := a.streams[testKey(0)]; ok {
+		t.Fatalf("FAIL: the oldest stream is not removed\n")
+	}
+
+	if _, ok := a.streams[testKey(maxStreams+9)]; !ok {
+		t.Fatalf("FAIL: the newest stream is removed\n")
+	}
+
+	// Future segments above the size limit are dropped
+	k := testKey(maxStreams + 9)
+	seg := make([]byte, 1400)
+
+	for i := 0; i < maxSegments; i++ {
+		a.add(k, segment(uint32(10000+i*len(seg)), false, seg), ts)
+	}
+
+	if s := a.streams[k]; s.pending > maxSegmentBytes || len(s.segments) != maxSegmentBytes/len(seg) {
+		t.Fatalf("FAIL: invalid out-of-order segments: %d segments, %d bytes\n", len(s.segments), s.pending)
+	}
+}
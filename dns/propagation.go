@@ -0,0 +1,325 @@
+package dns
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	mdns "github.com/miekg/dns"
+)
+
+// PropagationResult is the answer of a single server in a propagation check.
+type PropagationResult struct {
+	Server        string   // The server (eg.: "udp://8.8.8.8:53")
+	Authoritative bool     // Whether the server is an authoritative nameserver of the zone
+	Answers       []string // The values of the records with the checked type
+	TTL           uint32   // The lowest TTL of the answers (the remaining TTL in case of a caching resolver)
+	Match         bool     // Whether Answers is equal to the expected values
+	Err           error    // Error of the query (eg.: ErrName), nil on success
+}
+
+// Propagation is the result of a propagation check.
+type Propagation struct {
+	Name      string
+	Type      uint16
+	Expected  []string // The expected values, if nil the answer of the first successful server is used
+	Results   []PropagationResult
+	Converged bool // Whether every server returned the expected values
+}
+
+// PropagationChecker checks the visibility of a record across many resolvers and the authoritative nameservers.
+type PropagationChecker struct {
+	resolvers     []Server
+	authoritative bool          // Query the authoritative nameservers too
+	timeout       time.Duration // Timeout of a single query, used to create the authoritative servers
+	interval      time.Duration // Time between two check in Wait()
+}
+
+// NewPropagationChecker creates a new PropagationChecker.
+// The resolvers have the same format as in NewServersStr (eg.: "udp://8.8.8.8:53", "1.1.1.1").
+// If authoritative is true, the authoritative nameservers of the zone are queried too.
+func NewPropagationChecker(authoritative bool, timeout time.Duration, resolvers ...string) (*PropagationChecker, error) {
+
+	if len(resolvers) == 0 {
+		return nil, fmt.Errorf("resolvers is empty")
+	}
+
+	p := &PropagationChecker{
+		resolvers:     make([]Server, 0, len(resolvers)),
+		authoritative: authoritative,
+		timeout:       timeout,
+		interval:      5 * time.Second,
+	}
+
+	for i := range resolvers {
+
+		srv, err := NewServerStr(resolvers[i], timeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create new server from %s: %w", resolvers[i], err)
+		}
+
+		p.resolvers = append(p.resolvers, srv)
+	}
+
+	return p, nil
+}
+
+// SetInterval sets the time between two check in Wait().
+func (p *PropagationChecker) SetInterval(d time.Duration) {
+	p.interval = d
+}
+
+// rrValue returns the value of rr in presentation format.
+// The multiple strings of a TXT record are concatenated.
+func rrValue(rr mdns.RR) string {
+
+	switch v := rr.(type) {
+	case *mdns.A:
+		return v.A.String()
+	case *mdns.AAAA:
+		return v.AAAA.String()
+	case *mdns.CAA:
+		return fmt.Sprintf("%d %s %s", v.Flag, v.Tag, v.Value)
+	case *mdns.CNAME:
+		return v.Target
+	case *mdns.DNAME:
+		return v.Target
+	case *mdns.MX:
+		return fmt.Sprintf("%d %s", v.Preference, v.Mx)
+	case *mdns.NS:
+		return v.Ns
+	case *mdns.SOA:
+		return fmt.Sprintf("%s %s %d %d %d %d %d", v.Ns, v.Mbox, v.Serial, v.Refresh, v.Retry, v.Expire, v.Minttl)
+	case *mdns.SRV:
+		return fmt.Sprintf("%d %d %d %s", v.Priority, v.Weight, v.Port, v.Target)
+	case *mdns.TXT:
+		return strings.Join(v.Txt, "")
+	default:
+		// Remove the header from the string
+		return strings.TrimPrefix(rr.String(), rr.Header().String())
+	}
+}
+
+// normalizeValues returns a sorted, lower cased copy of v without the trailing dots.
+func normalizeValues(v []string) []string {
+
+	r := make([]string, 0, len(v))
+
+	for i := range v {
+		r = append(r, strings.TrimSuffix(strings.ToLower(v[i]), "."))
+	}
+
+	sort.Strings(r)
+
+	return r
+}
+
+// equalValues returns whether a and b contains the same values.
+func equalValues(a []string, b []string) bool {
+
+	if len(a) != len(b) {
+		return false
+	}
+
+	a = normalizeValues(a)
+	b = normalizeValues(b)
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkServer queries srv for name with type t and returns the result.
+func checkServer(srv *Server, name string, t uint16, authoritative bool) PropagationResult {
+
+	r := PropagationResult{Server: srv.String(), Authoritative: authoritative}
+
+	rr, err := srv.query(name, t)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+
+	for i := range rr {
+
+		// Skip the CNAME/DNAME chain
+		if rr[i].Header().Rrtype != t {
+			continue
+		}
+
+		if len(r.Answers) == 0 || rr[i].Header().Ttl < r.TTL {
+			r.TTL = rr[i].Header().Ttl
+		}
+
+		r.Answers = append(r.Answers, rrValue(rr[i]))
+	}
+
+	return r
+}
+
+// authoritativeServers returns the authoritative nameservers of the zone of name.
+// Walks up the name until a NS record is found, every IPv4 and IPv6 address of the nameservers is used.
+func (p *PropagationChecker) authoritativeServers(name string) ([]Server, error) {
+
+	srvs := NewServersSlice(len(p.resolvers), p.resolvers...)
+
+	zone := Clean(name)
+
+	for {
+
+		ns, err := srvs.TryQueryNS(zone)
+		if err != nil && !errors.Is(err, ErrName) {
+			return nil, fmt.Errorf("failed to query NS for %s: %w", zone, err)
+		}
+
+		if len(ns) > 0 {
+
+			r := make([]Server, 0, len(ns))
+
+			for i := range ns {
+
+				ips, err := srvs.TryQueryA(ns[i])
+				if err != nil && !errors.Is(err, ErrName) {
+					return nil, fmt.Errorf("failed to query A for %s: %w", ns[i], err)
+				}
+
+				ips6, err := srvs.TryQueryAAAA(ns[i])
+				if err != nil && !errors.Is(err, ErrName) {
+					return nil, fmt.Errorf("failed to query AAAA for %s: %w", ns[i], err)
+				}
+
+				ips = append(ips, ips6...)
+
+				if len(ips) == 0 {
+					return nil, fmt.Errorf("no address found for %s", ns[i])
+				}
+
+				for ii := range ips {
+
+					srv, err := NewServer("udp", ips[ii].String(), "53", p.timeout)
+					if err != nil {
+						return nil, fmt.Errorf("failed to create server for %s: %w", ns[i], err)
+					}
+
+					r = append(r, srv)
+				}
+			}
+
+			return r, nil
+		}
+
+		dot := strings.IndexByte(zone, '.')
+		if dot == -1 {
+			return nil, fmt.Errorf("no NS found for %s", name)
+		}
+
+		zone = zone[dot+1:]
+	}
+}
+
+// Check queries every resolver (and the authoritative nameservers if enabled) concurrently for name with type t.
+//
+// If expected is not empty, every answer is compared to expected.
+// If expected is empty, the answers are compared to the answer of the first successful server.
+// NXDOMAIN and the empty answer (NODATA) are equal, so the check converges if the record is absent on every server.
+// The values are compared case insensitively and without order and the trailing dot.
+func (p *PropagationChecker) Check(name string, t uint16, expected ...string) (Propagation, error) {
+
+	type server struct {
+		srv           *Server
+		authoritative bool
+	}
+
+	srvs := make([]server, 0, len(p.resolvers))
+
+	for i := range p.resolvers {
+		srvs = append(srvs, server{srv: &p.resolvers[i]})
+	}
+
+	if p.authoritative {
+
+		auth, err := p.authoritativeServers(name)
+		if err != nil {
+			return Propagation{}, fmt.Errorf("failed to get authoritative servers: %w", err)
+		}
+
+		for i := range auth {
+			srvs = append(srvs, server{srv: &auth[i], authoritative: true})
+		}
+	}
+
+	r := Propagation{Name: name, Type: t, Expected: expected, Results: make([]PropagationResult, len(srvs))}
+
+	wg := new(sync.WaitGroup)
+
+	for i := range srvs {
+
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+			r.Results[i] = checkServer(srvs[i].srv, name, t, srvs[i].authoritative)
+		}(i)
+	}
+
+	wg.Wait()
+
+	want := expected
+
+	if len(want) == 0 {
+		for i := range r.Results {
+			if r.Results[i].Err == nil || errors.Is(r.Results[i].Err, ErrName) {
+				want = r.Results[i].Answers
+				break
+			}
+		}
+	}
+
+	r.Converged = true
+
+	for i := range r.Results {
+
+		// NXDOMAIN is the same as an empty answer (NODATA), the record is absent
+		absent := errors.Is(r.Results[i].Err, ErrName) || (r.Results[i].Err == nil && len(r.Results[i].Answers) == 0)
+
+		r.Results[i].Match = (absent && len(want) == 0) || (r.Results[i].Err == nil && equalValues(r.Results[i].Answers, want))
+
+		if !r.Results[i].Match {
+			r.Converged = false
+		}
+	}
+
+	return r, nil
+}
+
+// Wait calls Check until every server returns the expected values or timeout reached.
+// Returns the last Propagation and ErrNotConverged if the timeout is reached.
+func (p *PropagationChecker) Wait(name string, t uint16, timeout time.Duration, expected ...string) (Propagation, error) {
+
+	deadline := time.Now().Add(timeout)
+
+	for {
+
+		r, err := p.Check(name, t, expected...)
+		if err != nil {
+			return r, err
+		}
+
+		if r.Converged {
+			return r, nil
+		}
+
+		if time.Now().Add(p.interval).After(deadline) {
+			return r, ErrNotConverged
+		}
+
+		time.Sleep(p.interval)
+	}
+}
@@ -0,0 +1,191 @@
+package dns
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	mdns "github.com/miekg/dns"
+)
+
+// startTestServer starts a local UDP DNS server with handler h and returns the address in "udp://127.0.0.1:port" format.
+func startTestServer(t *testing.T, h mdns.HandlerFunc) string {
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("FAIL: failed to listen: %s\n", err)
+	}
+
+	srv := &mdns.Server{PacketConn: pc, Handler: h}
+
+	started := make(chan struct{})
+	srv.NotifyStartedFunc = func() { close(started) }
+
+	go srv.ActivateAndServe()
+
+	<-started
+
+	t.Cleanup(func() { srv.Shutdown() })
+
+	return "udp://" + pc.LocalAddr().String()
+}
+
+// txtHandler returns a handler that answers every TXT query with value and ttl.
+func txtHandler(value string, ttl uint32) mdns.HandlerFunc {
+
+	return func(w mdns.ResponseWriter, r *mdns.Msg) {
+
+		m := new(mdns.Msg)
+		m.SetReply(r)
+
+		if r.Question[0].Qtype == mdns.TypeTXT {
+			m.Answer = append(m.Answer, &mdns.TXT{
+				Hdr: mdns.RR_Header{Name: r.Question[0].Name, Rrtype: mdns.TypeTXT, Class: mdns.ClassINET, Ttl: ttl},
+				Txt: []string{value},
+			})
+		}
+
+		w.WriteMsg(m)
+	}
+}
+
+func TestPropagationCheck(t *testing.T) {
+
+	one := startTestServer(t, txtHandler("new", 300))
+	two := startTestServer(t, txtHandler("old", 120))
+
+	p, err := NewPropagationChecker(false, time.Second, one, two)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	r, err := p.Check("example.com", TypeTXT, "new")
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if r.Converged {
+		t.Fatalf("FAIL: converged with different answers\n")
+	}
+
+	if !r.Results[0].Match || r.Results[1].Match {
+		t.Fatalf("FAIL: invalid match: %#v\n", r.Results)
+	}
+
+	if r.Results[0].TTL != 300 || r.Results[1].TTL != 120 {
+		t.Fatalf("FAIL: invalid TTL: %#v\n", r.Results)
+	}
+
+	if r.Results[1].Server != two || len(r.Results[1].Answers) != 1 || r.Results[1].Answers[0] != "old" {
+		t.Fatalf("FAIL: invalid result: %#v\n", r.Results[1])
+	}
+}
+
+func TestPropagationWait(t *testing.T) {
+
+	one := startTestServer(t, txtHandler("value", 300))
+	two := startTestServer(t, txtHandler("value", 300))
+
+	p, err := NewPropagationChecker(false, time.Second, one, two)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	p.SetInterval(10 * time.Millisecond)
+
+	// Without expected value, compare to each other
+	r, err := p.Wait("example.com", TypeTXT, time.Second)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if !r.Converged {
+		t.Fatalf("FAIL: not converged: %#v\n", r.Results)
+	}
+
+	_, err = p.Wait("example.com", TypeTXT, 50*time.Millisecond, "other")
+	if err != ErrNotConverged {
+		t.Fatalf("FAIL: error got: %v, want: %s\n", err, ErrNotConverged)
+	}
+}
+
+func TestPropagationAbsent(t *testing.T) {
+
+	nxdomain := startTestServer(t, func(w mdns.ResponseWriter, r *mdns.Msg) {
+		m := new(mdns.Msg)
+		m.SetRcode(r, mdns.RcodeNameError)
+		w.WriteMsg(m)
+	})
+
+	nodata := startTestServer(t, txtHandler("value", 300))
+
+	p, err := NewPropagationChecker(false, time.Second, nxdomain, nodata)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	// nodata answers only TXT
+	r, err := p.Check("example.com", TypeA)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if !r.Converged || !r.Results[0].Match || !r.Results[1].Match {
+		t.Fatalf("FAIL: absent record not converged: %#v\n", r.Results)
+	}
+
+	if r, err = p.Check("example.com", TypeA, "192.0.2.1"); err != nil || r.Converged {
+		t.Fatalf("FAIL: absent record converged to an expected value: %v %#v\n", err, r.Results)
+	}
+
+	if r, err = p.Check("example.com", TypeTXT); err != nil || r.Converged {
+		t.Fatalf("FAIL: converged with NXDOMAIN and an answer: %v %#v\n", err, r.Results)
+	}
+}
+
+func TestPropagationAuthoritativeServers(t *testing.T) {
+
+	resolver := startTestServer(t, func(w mdns.ResponseWriter, r *mdns.Msg) {
+
+		m := new(mdns.Msg)
+		m.SetReply(r)
+
+		q := r.Question[0]
+		hdr := mdns.RR_Header{Name: q.Name, Rrtype: q.Qtype, Class: mdns.ClassINET, Ttl: 300}
+
+		switch {
+		case q.Name == "example.com." && q.Qtype == mdns.TypeNS:
+			m.Answer = append(m.Answer, &mdns.NS{Hdr: hdr, Ns: "ns1.example.com."}, &mdns.NS{Hdr: hdr, Ns: "ns2.example.com."})
+		case q.Name == "ns1.example.com." && q.Qtype == mdns.TypeA:
+			m.Answer = append(m.Answer, &mdns.A{Hdr: hdr, A: net.ParseIP("192.0.2.1")})
+		case q.Name == "ns1.example.com." && q.Qtype == mdns.TypeAAAA:
+			m.Answer = append(m.Answer, &mdns.AAAA{Hdr: hdr, AAAA: net.ParseIP("2001:db8::1")})
+		case q.Name == "ns2.example.com." && q.Qtype == mdns.TypeAAAA:
+			m.Answer = append(m.Answer, &mdns.AAAA{Hdr: hdr, AAAA: net.ParseIP("2001:db8::2")})
+		case q.Name != "example.com." && q.Name != "ns1.example.com." && q.Name != "ns2.example.com.":
+			m.Rcode = mdns.RcodeNameError
+		}
+
+		w.WriteMsg(m)
+	})
+
+	p, err := NewPropagationChecker(true, time.Second, resolver)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	srvs, err := p.authoritativeServers("_acme-challenge.example.com")
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	var ips []string
+
+	for i := range srvs {
+		ips = append(ips, srvs[i].IP)
+	}
+
+	if !equalValues(ips, []string{"192.0.2.1", "2001:db8::1", "2001:db8::2"}) {
+		t.Fatalf("FAIL: invalid authoritative servers: %v\n", ips)
+	}
+}
@@ -0,0 +1,201 @@
+package dns
+
+import (
+	"errors"
+	"time"
+
+	mdns "github.com/miekg/dns"
+)
+
+// Response is the complete response of a server.
+type Response struct {
+	Server             string        // The responding server (eg.: "udp://8.8.8.8:53", "tcp://..." if the UDP answer was truncated)
+	RTT                time.Duration // Round trip time
+	Rcode              int           // Response code
+	Authoritative      bool          // AA flag
+	RecursionAvailable bool          // RA flag
+	AuthenticatedData  bool          // AD flag, the data is validated with DNSSEC by the resolver
+	CheckingDisabled   bool          // CD flag
+	Answer             []mdns.RR     // Answer section
+	Authority          []mdns.RR     // Authority section
+	Additional         []mdns.RR     // Additional section, without the OPT record
+	OPT                *OPT          // EDNS(0) data, nil if the server did not return an OPT record
+	Msg                *mdns.Msg     // The received message (eg.: to forward it)
+}
+
+// RecordTTL is a Record with the owner name and TTL.
+type RecordTTL struct {
+	Record
+	Name string // Owner name (eg.: "example.com")
+	TTL  uint32
+}
+
+// newResponse creates a Response from msg.
+func newResponse(msg *mdns.Msg, rtt time.Duration, srv *Server) *Response {
+
+	r := &Response{
+		Server:             srv.String(),
+		RTT:                rtt,
+		Rcode:              msg.Rcode,
+		Authoritative:      msg.Authoritative,
+		RecursionAvailable: msg.RecursionAvailable,
+		AuthenticatedData:  msg.AuthenticatedData,
+		CheckingDisabled:   msg.CheckingDisabled,
+		Answer:             msg.Answer,
+		Authority:          msg.Ns,
+		OPT:                srv.edns.parseOPT(msg),
+		Msg:                msg,
+	}
+
+	for i := range msg.Extra {
+		if msg.Extra[i].Header().Rrtype != mdns.TypeOPT {
+			r.Additional = append(r.Additional, msg.Extra[i])
+		}
+	}
+
+	return r
+}
+
+// Err returns the error associated with the rcode (eg.: ErrName for NXDOMAIN).
+// Returns nil if the rcode is NOERROR.
+func (r *Response) Err() error {
+
+	if r.Rcode == 0 {
+		return nil
+	}
+
+	return RcodeToError(r.Rcode)
+}
+
+// Answers returns the records from the Answer section with type t and the TTLs.
+// The CNAME/DNAME records of the chain are skipped, except if t is TypeCNAME or TypeDNAME.
+func (r *Response) Answers(t uint16) []RecordTTL {
+
+	var rs []RecordTTL
+
+	for i := range r.Answer {
+
+		if r.Answer[i].Header().Rrtype != t {
+			continue
+		}
+
+		rs = append(rs, RecordTTL{Record: RecordFromRR(r.Answer[i]), Name: Clean(r.Answer[i].Header().Name), TTL: r.Answer[i].Header().Ttl})
+	}
+
+	return rs
+}
+
+// MinTTL returns the lowest TTL in the Answer section.
+// If the answer is empty, returns the negative caching TTL from the SOA in the Authority section (RFC 2308).
+// Returns 0 if none of them found.
+func (r *Response) MinTTL() uint32 {
+
+	var ttl uint32
+
+	for i := range r.Answer {
+		if i == 0 || r.Answer[i].Header().Ttl < ttl {
+			ttl = r.Answer[i].Header().Ttl
+		}
+	}
+
+	if len(r.Answer) > 0 {
+		return ttl
+	}
+
+	for i := range r.Authority {
+
+		if v, ok := r.Authority[i].(*mdns.SOA); ok {
+
+			// The negative TTL is the minimum of the SOA TTL and the MINIMUM field
+			if v.Minttl < v.Hdr.Ttl {
+				return v.Minttl
+			}
+
+			return v.Hdr.Ttl
+		}
+	}
+
+	return 0
+}
+
+// SOA returns the SOA record from the Authority section (returned with NXDOMAIN and NODATA responses).
+// Returns nil if the Authority section does not contain a SOA record.
+func (r *Response) SOA() *SOA {
+
+	for i := range r.Authority {
+
+		if v, ok := r.Authority[i].(*mdns.SOA); ok {
+			return &SOA{Mname: v.Ns, Rname: v.Mbox, Serial: int(v.Serial), Refresh: int(v.Refresh), Retry: int(v.Retry), Expire: int(v.Expire), MinTTL: int(v.Minttl)}
+		}
+	}
+
+	return nil
+}
+
+// QueryResponse asks the server for type t and returns the complete Response.
+//
+// The Response is returned with rcode errors too (eg.: ErrName with NXDOMAIN), the returned error is Response.Err().
+// In case of network error, the Response is nil.
+func (s *Server) QueryResponse(name string, t uint16) (*Response, error) {
+
+	in, rtt, srv, err := s.exchange(name, t)
+	if err != nil {
+		return nil, err
+	}
+
+	r := newResponse(in, rtt, srv)
+
+	return r, r.Err()
+}
+
+// QueryResponse asks a random server from servers for type t and returns the complete Response.
+//
+// The Response is returned with rcode errors too (eg.: ErrName with NXDOMAIN), the returned error is Response.Err().
+// In case of network error, the Response is nil.
+func (s *Servers) QueryResponse(name string, t uint16) (*Response, error) {
+
+	return s.Get(-1).QueryResponse(name, t)
+}
+
+// QueryResponse asks a random server from DefaultServers for type t and returns the complete Response.
+//
+// The Response is returned with rcode errors too (eg.: ErrName with NXDOMAIN), the returned error is Response.Err().
+// In case of network error, the Response is nil.
+func QueryResponse(name string, t uint16) (*Response, error) {
+
+	return DefaultServers.QueryResponse(name, t)
+}
+
+// TryQueryResponse asks the servers for type t. If any error occurred, retries with an other server (except if error is NXDOMAIN).
+//
+// The Response is returned with rcode errors too (eg.: ErrName with NXDOMAIN), the returned error is Response.Err().
+// In case of network error, the Response is nil.
+//
+// NOTE: The first used server is random.
+func (s *Servers) TryQueryResponse(name string, t uint16) (*Response, error) {
+
+	var (
+		err        = ErrInvalidMaxRetries
+		r          *Response
+		maxRetries = s.maxRetries - 1
+	)
+
+	for i := -1; i < maxRetries; i++ {
+
+		r, err = s.Get(i).QueryResponse(name, t)
+		if err == nil || errors.Is(err, ErrName) {
+			break
+		}
+	}
+
+	return r, err
+}
+
+// TryQueryResponse asks the DefaultServers for type t. If any error occurred, retries with an other server (except if error is NXDOMAIN).
+//
+// The Response is returned with rcode errors too (eg.: ErrName with NXDOMAIN), the returned error is Response.Err().
+// In case of network error, the Response is nil.
+func TryQueryResponse(name string, t uint16) (*Response, error) {
+
+	return DefaultServers.TryQueryResponse(name, t)
+}
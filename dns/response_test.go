@@ -0,0 +1,114 @@
+package dns
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	mdns "github.com/miekg/dns"
+)
+
+// responseHandler answers "www.example.com." with a CNAME chain and an authoritative A record,
+// every other name with NXDOMAIN and SOA in the authority section.
+func responseHandler(w mdns.ResponseWriter, r *mdns.Msg) {
+
+	m := new(mdns.Msg)
+	m.SetReply(r)
+	m.Authoritative = true
+
+	switch r.Question[0].Name {
+	case "www.example.com.":
+		m.Answer = append(m.Answer,
+			&mdns.CNAME{Hdr: mdns.RR_Header{Name: "www.example.com.", Rrtype: mdns.TypeCNAME, Class: mdns.ClassINET, Ttl: 600}, Target: "example.com."},
+			&mdns.A{Hdr: mdns.RR_Header{Name: "example.com.", Rrtype: mdns.TypeA, Class: mdns.ClassINET, Ttl: 300}, A: net.IP{93, 184, 216, 34}},
+		)
+		m.Extra = append(m.Extra, &mdns.A{Hdr: mdns.RR_Header{Name: "ns.example.com.", Rrtype: mdns.TypeA, Class: mdns.ClassINET, Ttl: 300}, A: net.IP{192, 0, 2, 1}})
+	default:
+		m.Rcode = mdns.RcodeNameError
+		m.Ns = append(m.Ns, &mdns.SOA{
+			Hdr: mdns.RR_Header{Name: "example.com.", Rrtype: mdns.TypeSOA, Class: mdns.ClassINET, Ttl: 3600},
+			Ns:  "ns.example.com.", Mbox: "admin.example.com.", Serial: 1, Refresh: 7200, Retry: 3600, Expire: 1209600, Minttl: 900,
+		})
+	}
+
+	w.WriteMsg(m)
+}
+
+func TestQueryResponse(t *testing.T) {
+
+	addr := startTestServer(t, responseHandler)
+
+	s, err := NewServerStr(addr, time.Second)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	r, err := s.QueryResponse("www.example.com", TypeA)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if r.Server != addr || !r.Authoritative || r.RTT <= 0 {
+		t.Fatalf("FAIL: invalid response: %#v\n", r)
+	}
+
+	if len(r.Answer) != 2 || len(r.Additional) != 1 {
+		t.Fatalf("FAIL: invalid sections: %#v\n", r)
+	}
+
+	a := r.Answers(TypeA)
+	if len(a) != 1 || a[0].Value != "93.184.216.34" || a[0].TTL != 300 || a[0].Name != "example.com" {
+		t.Fatalf("FAIL: invalid answers: %#v\n", a)
+	}
+
+	if r.MinTTL() != 300 {
+		t.Fatalf("FAIL: MinTTL got: %d, want: 300\n", r.MinTTL())
+	}
+}
+
+func TestQueryResponseNXDOMAIN(t *testing.T) {
+
+	addr := startTestServer(t, responseHandler)
+
+	srvs, err := NewServersStr(2, time.Second, addr)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	r, err := srvs.TryQueryResponse("notexist.example.com", TypeA)
+	if !errors.Is(err, ErrName) {
+		t.Fatalf("FAIL: error got: %v, want: %s\n", err, ErrName)
+	}
+
+	if r == nil || r.Rcode != mdns.RcodeNameError {
+		t.Fatalf("FAIL: invalid response: %#v\n", r)
+	}
+
+	soa := r.SOA()
+	if soa == nil || soa.Mname != "ns.example.com." || soa.MinTTL != 900 {
+		t.Fatalf("FAIL: invalid SOA: %#v\n", soa)
+	}
+
+	if r.MinTTL() != 900 {
+		t.Fatalf("FAIL: MinTTL got: %d, want: 900\n", r.MinTTL())
+	}
+}
+
+func TestQueryResponseNetworkError(t *testing.T) {
+
+	s, err := NewServerStr("udp://127.0.0.1:1", 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	r, err := s.QueryResponse("example.com", TypeA)
+	if err == nil || r != nil {
+		t.Fatalf("FAIL: want network error, got: %#v, %v\n", r, err)
+	}
+
+	if strings.Contains(err.Error(), "NXDOMAIN") {
+		t.Fatalf("FAIL: unexpected error: %s\n", err)
+	}
+}
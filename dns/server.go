@@ -15,6 +15,7 @@ type Server struct {
 	Port     string // Destination port
 	family   int    // IP address family, must be "4" for IPv4 or "6" for IPv6
 	client   *mdns.Client
+	edns     *ednsState // EDNS(0) configuration, nil means disabled
 }
 
 // NewServer creates a new Server.
@@ -173,32 +174,50 @@ func (s *Server) ToTCP() *Server {
 	srv.client = new(mdns.Client)
 	srv.client.Net = "tcp"
 	srv.client.Timeout = s.client.Timeout
+	srv.edns = s.edns
 
 	return srv
 }
 
-// Generic query for type t to server s.
-// Returns the Answer section.
-// In case of error, the answer will be nil and return ErrX or any unknown error.
+// exchange sends the question name with type t to server s and returns the response, the round trip time and the responding server.
+// If EDNS is set, the OPT record is added to the query.
 // If the returned messsage is truncated, create a TCP server from s and retry the query.
-func (s *Server) query(name string, t uint16) ([]mdns.RR, error) {
+func (s *Server) exchange(name string, t uint16) (*mdns.Msg, time.Duration, *Server, error) {
 
 	msg := new(mdns.Msg)
 	msg.SetQuestion(mdns.Fqdn(name), t)
 
-	in, _, err := s.client.Exchange(msg, s.Server())
+	if s.edns != nil {
+		s.edns.setOPT(msg, s.Protocol)
+	}
+
+	in, rtt, err := s.client.Exchange(msg, s.Server())
 	if err != nil {
-		return nil, err
+		return nil, rtt, s, err
 	}
 
 	if in.Truncated {
 
 		tcpS := s.ToTCP()
 		if tcpS == nil {
-			return nil, ErrTruncated
+			return nil, rtt, s, ErrTruncated
 		}
 
-		return tcpS.query(name, t)
+		return tcpS.exchange(name, t)
+	}
+
+	return in, rtt, s, nil
+}
+
+// Generic query for type t to server s.
+// Returns the Answer section.
+// In case of error, the answer will be nil and return ErrX or any unknown error.
+// If the returned messsage is truncated, create a TCP server from s and retry the query.
+func (s *Server) query(name string, t uint16) ([]mdns.RR, error) {
+
+	in, _, _, err := s.exchange(name, t)
+	if err != nil {
+		return nil, err
 	}
 
 	if in.Rcode == 0 {
@@ -207,3 +226,24 @@ func (s *Server) query(name string, t uint16) ([]mdns.RR, error) {
 
 	return nil, RcodeToError(in.Rcode)
 }
+
+// QueryEDNS asks the server for type t and returns the Answer section and the returned EDNS(0) data.
+// The OPT is nil if the server did not return an OPT record.
+//
+// In case of error, the answer will be nil and return ErrX or any unknown error.
+// The OPT is returned with an rcode error too (eg.: to read the extended errors of a SERVFAIL).
+func (s *Server) QueryEDNS(name string, t uint16) ([]mdns.RR, *OPT, error) {
+
+	in, _, _, err := s.exchange(name, t)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opt := s.edns.parseOPT(in)
+
+	if in.Rcode == 0 {
+		return in.Answer, opt, nil
+	}
+
+	return nil, opt, RcodeToError(in.Rcode)
+}
@@ -0,0 +1,315 @@
+package dns
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/elmasy-com/elnet/idn"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Sections of the Public Suffix List.
+const (
+	SectionICANN   = iota // ICANN managed suffixes (eg.: "com", "co.uk")
+	SectionPrivate        // Private suffixes (eg.: "github.io", "blogspot.com")
+	SectionCustom         // Suffixes added with AddRule()
+)
+
+// suffixRule is a parsed rule of the list.
+type suffixRule struct {
+	section   int
+	wildcard  bool // "*.example" rule
+	exception bool // "!www.example" rule
+}
+
+// SuffixList is a Public Suffix List engine.
+// See the format: https://github.com/publicsuffix/list/wiki/Format
+//
+// The list created with NewSuffixList() uses the list embedded in golang.org/x/net/publicsuffix.
+// An updated list can be loaded at runtime with LoadSuffixList() or LoadSuffixListFile().
+//
+// By default, only the ICANN section is used (eg.: the TLD of "foo.github.io" is "io").
+// If the private section is enabled, the TLD of "foo.github.io" is "github.io".
+//
+// A SuffixList is safe for concurrent use.
+type SuffixList struct {
+	builtin bool                  // Use golang.org/x/net/publicsuffix
+	private bool                  // Use the private section
+	rules   map[string]suffixRule // Key is the rule without the "*." and "!" prefix
+	m       *sync.RWMutex
+}
+
+// DefaultSuffixList is the list used by the package level functions (eg.: GetDomain()) if no list is given.
+// DefaultSuffixList uses the builtin list with ICANN-only semantics.
+var DefaultSuffixList = NewSuffixList(false)
+
+// NewSuffixList creates a SuffixList that uses the list embedded in golang.org/x/net/publicsuffix.
+// If private is true, the private section is used too.
+func NewSuffixList(private bool) *SuffixList {
+
+	return &SuffixList{builtin: true, private: private, rules: make(map[string]suffixRule), m: new(sync.RWMutex)}
+}
+
+// LoadSuffixList parses the Public Suffix List from r.
+// If private is true, the private section is used too.
+//
+// The sections are detected with the "===BEGIN PRIVATE DOMAINS===" comment.
+func LoadSuffixList(r io.Reader, private bool) (*SuffixList, error) {
+
+	l := &SuffixList{private: private, rules: make(map[string]suffixRule), m: new(sync.RWMutex)}
+
+	section := SectionICANN
+
+	s := bufio.NewScanner(r)
+
+	for s.Scan() {
+
+		line := strings.TrimSpace(s.Text())
+
+		if strings.HasPrefix(line, "//") {
+			if strings.Contains(line, "===BEGIN PRIVATE DOMAINS===") {
+				section = SectionPrivate
+			}
+			continue
+		}
+
+		// The rule is the first field of the line
+		if i := strings.IndexAny(line, " \t"); i != -1 {
+			line = line[:i]
+		}
+
+		if line == "" {
+			continue
+		}
+
+		if err := l.addRule(line, section); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read: %w", err)
+	}
+
+	if len(l.rules) == 0 {
+		return nil, fmt.Errorf("empty list")
+	}
+
+	return l, nil
+}
+
+// LoadSuffixListFile parses the Public Suffix List from the file at path (eg.: the downloaded public_suffix_list.dat).
+// If private is true, the private section is used too.
+func LoadSuffixListFile(path string, private bool) (*SuffixList, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open: %w", err)
+	}
+	defer f.Close()
+
+	return LoadSuffixList(f, private)
+}
+
+// addRule parses rule and adds it to the list.
+func (l *SuffixList) addRule(rule string, section int) error {
+
+	r := suffixRule{section: section}
+
+	rule = Clean(rule)
+
+	switch {
+	case strings.HasPrefix(rule, "*."):
+		r.wildcard = true
+		rule = rule[2:]
+	case strings.HasPrefix(rule, "!"):
+		r.exception = true
+		rule = rule[1:]
+	}
+
+	if rule == "" || rule[0] == '.' || strings.Contains(rule, "..") {
+		return fmt.Errorf("invalid rule: %s", rule)
+	}
+
+	if r.exception && !strings.Contains(rule, ".") {
+		return fmt.Errorf("invalid exception rule: !%s", rule)
+	}
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	// A wildcard and a normal rule can exist for the same name (eg.: "ck" and "*.ck"), store the wildcard with a prefix
+	if r.wildcard {
+		l.rules["*."+rule] = r
+	} else {
+		l.rules[rule] = r
+	}
+
+	return nil
+}
+
+// AddRule adds a custom rule to the list (eg.: "corp.internal", "*.dev.internal" or "!www.dev.internal").
+// The custom rules are used regardless of the private setting.
+func (l *SuffixList) AddRule(rule string) error {
+
+	if rule == "" {
+		return fmt.Errorf("rule is empty")
+	}
+
+	return l.addRule(rule, SectionCustom)
+}
+
+// SetPrivate sets whether the private section is used.
+func (l *SuffixList) SetPrivate(private bool) {
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.private = private
+}
+
+// usable returns whether the rule r is enabled.
+func (l *SuffixList) usable(r suffixRule, ok bool) bool {
+
+	return ok && (r.section != SectionPrivate || l.private)
+}
+
+// match returns the public suffix of d with the rules of l.
+// The found is false if no rule matched.
+func (l *SuffixList) match(d string) (suffix string, section int, found bool) {
+
+	l.m.RLock()
+	defer l.m.RUnlock()
+
+	if len(l.rules) == 0 {
+		return "", 0, false
+	}
+
+	// Indexes of the labels, the candidates are d[idx[i]:]
+	idx := []int{0}
+	for i := 0; i < len(d); i++ {
+		if d[i] == '.' {
+			idx = append(idx, i+1)
+		}
+	}
+
+	// The exception rules are always prevailing
+	for i := range idx {
+
+		r, ok := l.rules[d[idx[i]:]]
+
+		if l.usable(r, ok) && r.exception && i+1 < len(idx) {
+			return d[idx[i+1]:], r.section, true
+		}
+	}
+
+	// The longest matching rule is prevailing
+	for i := range idx {
+
+		if r, ok := l.rules[d[idx[i]:]]; l.usable(r, ok) && !r.exception {
+			return d[idx[i]:], r.section, true
+		}
+
+		if i+1 < len(idx) {
+			if r, ok := l.rules["*."+d[idx[i+1]:]]; l.usable(r, ok) {
+				return d[idx[i]:], r.section, true
+			}
+		}
+	}
+
+	return "", 0, false
+}
+
+// builtinSuffix returns the public suffix of d with golang.org/x/net/publicsuffix.
+func builtinSuffix(d string, private bool) (string, bool) {
+
+	tld, icann := publicsuffix.PublicSuffix(d)
+
+	if private {
+		return tld, icann
+	}
+
+	for !icann {
+
+		dot := strings.IndexByte(tld, '.')
+
+		// No dot means the TLD (eg.: "com")
+		if dot == -1 {
+			break
+		}
+
+		// Get the next part of the domain
+		tld, icann = publicsuffix.PublicSuffix(tld[dot+1:])
+	}
+
+	return tld, icann
+}
+
+// PublicSuffix returns the public suffix of d and whether it is ICANN managed.
+// If no rule matches, the last label is returned (the implicit "*" rule).
+//
+// Internationalized domain names are matched in ASCII form, but the suffix is returned in the form of d.
+//
+// The trailing dot is removed, d is not validated and not lowercased.
+func (l *SuffixList) PublicSuffix(d string) (string, bool) {
+
+	if d == "" || d == "." || d[0] == '.' {
+		return "", false
+	}
+
+	if d[len(d)-1] == '.' {
+		d = d[:len(d)-1]
+	}
+
+	if !idn.IsASCII(d) {
+		return l.unicodeSuffix(d)
+	}
+
+	suffix, section, found := l.match(d)
+
+	if l.builtin {
+
+		l.m.RLock()
+		private := l.private
+		l.m.RUnlock()
+
+		// The custom rules are prevailing if they are longer than the builtin suffix
+		if tld, icann := builtinSuffix(d, private); !found || len(tld) > len(suffix) {
+			return tld, icann
+		}
+	}
+
+	if !found {
+		return d[strings.LastIndexByte(d, '.')+1:], false
+	}
+
+	return suffix, section == SectionICANN
+}
+
+// unicodeSuffix returns the public suffix of the Unicode domain d.
+// The suffix of the ASCII form is found and the same number of labels are returned from d.
+func (l *SuffixList) unicodeSuffix(d string) (string, bool) {
+
+	a, err := idn.ToASCII(d)
+
+	// The mapping can change the number of labels (eg.: the full width dot "．"), in this case the labels can not be paired
+	if err != nil || !idn.IsASCII(a) || strings.Count(d, ".") != strings.Count(a, ".") {
+		return d[strings.LastIndexByte(d, '.')+1:], false
+	}
+
+	suffix, icann := l.PublicSuffix(a)
+
+	n := strings.Count(suffix, ".") + 1
+
+	i := len(d)
+
+	for ; n > 0 && i > 0; n-- {
+		i = strings.LastIndexByte(d[:i], '.')
+	}
+
+	return d[i+1:], icann
+}
@@ -0,0 +1,99 @@
+package dns
+
+import (
+	"strings"
+	"testing"
+)
+
+const testSuffixList = `// ===BEGIN ICANN DOMAINS===
+com
+uk
+co.uk
+*.ck
+!www.ck
+// ===END ICANN DOMAINS===
+
+// ===BEGIN PRIVATE DOMAINS===
+github.io
+*.compute.example.com
+// ===END PRIVATE DOMAINS===
+`
+
+func TestLoadSuffixList(t *testing.T) {
+
+	l, err := LoadSuffixList(strings.NewReader(testSuffixList), false)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if err := l.AddRule("corp.internal"); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	// 0. element = domain
+	// 1. element = suffix with ICANN-only
+	// 2. element = suffix with private
+	cases := [][3]string{
+		{"www.example.com", "com", "com"},
+		{"foo.github.io", "io", "github.io"},
+		{"amazon.co.uk", "co.uk", "co.uk"},
+		{"a.b.ck", "b.ck", "b.ck"},
+		{"www.ck", "ck", "ck"},
+		{"a.www.ck", "ck", "ck"},
+		{"host.corp.internal", "corp.internal", "corp.internal"},
+		{"a.b.compute.example.com", "com", "b.compute.example.com"},
+		{"there.is.no.such-tld", "such-tld", "such-tld"},
+	}
+
+	for i := range cases {
+		if s, _ := l.PublicSuffix(cases[i][0]); s != cases[i][1] {
+			t.Fatalf("FAIL: ICANN-only: %s: want %s, got %s\n", cases[i][0], cases[i][1], s)
+		}
+	}
+
+	l.SetPrivate(true)
+
+	for i := range cases {
+		if s, _ := l.PublicSuffix(cases[i][0]); s != cases[i][2] {
+			t.Fatalf("FAIL: private: %s: want %s, got %s\n", cases[i][0], cases[i][2], s)
+		}
+	}
+
+	if _, icann := l.PublicSuffix("foo.github.io"); icann {
+		t.Fatalf("FAIL: github.io must not be ICANN managed\n")
+	}
+
+	if _, err := LoadSuffixList(strings.NewReader("// empty\n"), false); err == nil {
+		t.Fatalf("FAIL: empty list must fail\n")
+	}
+}
+
+func TestSuffixListOption(t *testing.T) {
+
+	l := NewSuffixList(true)
+
+	if err := l.AddRule("corp.internal"); err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if d := GetDomain("www.foo.github.io", l); d != "foo.github.io" {
+		t.Fatalf("FAIL: want foo.github.io, got %s\n", d)
+	}
+
+	if d := GetDomain("www.foo.github.io"); d != "github.io" {
+		t.Fatalf("FAIL: default list: want github.io, got %s\n", d)
+	}
+
+	p := GetParts("a.b.corp.internal", l)
+	if p == nil || p.TLD != "corp.internal" || p.Domain != "b" || p.Sub != "a" {
+		t.Fatalf("FAIL: invalid parts: %#v\n", p)
+	}
+
+	if s := GetSub("www.foo.github.io", l); s != "www" {
+		t.Fatalf("FAIL: want www, got %s\n", s)
+	}
+
+	if HasSub("foo.github.io", l) {
+		t.Fatalf("FAIL: foo.github.io has no subdomain with private suffixes\n")
+	}
+}
@@ -0,0 +1,163 @@
+package takeover
+
+import (
+	"path"
+	"strings"
+)
+
+// Fingerprint describes a service that can be taken over.
+//
+// The patterns use the syntax of path.Match(), the "*" matches any sequence of characters, including dots (eg.: "*.s3.amazonaws.com").
+type Fingerprint struct {
+	Service  string   // Name of the service (eg.: "AWS S3")
+	CNAME    []string // Patterns of the CNAME targets that point to the service
+	NS       []string // Patterns of the nameservers of the service (NS delegation takeover)
+	NXDomain bool     // The dangling CNAME (target is NXDOMAIN) is takeoverable
+	Body     []string // Signatures in the HTTP response body of an unclaimed resource
+	Edge     bool     // The signature is not always exploitable, matches are reported as PossiblyVulnerable
+}
+
+// Fingerprints is the default fingerprint database.
+// Based on: https://github.com/EdOverflow/can-i-take-over-xyz
+var Fingerprints = []Fingerprint{
+	{
+		Service: "AWS S3",
+		CNAME:   []string{"*.s3.amazonaws.com", "*.s3-website*.amazonaws.com", "*.s3.*.amazonaws.com", "*.s3-*.amazonaws.com"},
+		Body:    []string{"NoSuchBucket", "The specified bucket does not exist"},
+	},
+	{
+		Service:  "AWS Elastic Beanstalk",
+		CNAME:    []string{"*.elasticbeanstalk.com"},
+		NXDomain: true,
+	},
+	{
+		Service: "AWS Route 53",
+		NS:      []string{"ns-*.awsdns-*"},
+	},
+	{
+		Service: "Microsoft Azure",
+		CNAME: []string{
+			"*.azurewebsites.net", "*.cloudapp.net", "*.cloudapp.azure.com", "*.trafficmanager.net",
+			"*.blob.core.windows.net", "*.azure-api.net", "*.azurehdinsight.net", "*.azureedge.net",
+			"*.azurecontainer.io", "*.database.windows.net", "*.azuredatalakestore.net", "*.search.windows.net",
+			"*.azurecr.io", "*.redis.cache.windows.net", "*.servicebus.windows.net", "*.visualstudio.com",
+		},
+		NXDomain: true,
+	},
+	{
+		Service: "Azure DNS",
+		NS:      []string{"ns*-*.azure-dns.com", "ns*-*.azure-dns.net", "ns*-*.azure-dns.org", "ns*-*.azure-dns.info"},
+	},
+	{
+		Service: "GitHub Pages",
+		CNAME:   []string{"*.github.io"},
+		Body:    []string{"There isn't a GitHub Pages site here."},
+	},
+	{
+		Service: "Heroku",
+		CNAME:   []string{"*.herokuapp.com", "*.herokudns.com", "*.herokussl.com"},
+		Body:    []string{"No such app", "herokucdn.com/error-pages/no-such-app.html"},
+		Edge:    true,
+	},
+	{
+		Service: "Bitbucket",
+		CNAME:   []string{"*.bitbucket.io"},
+		Body:    []string{"Repository not found"},
+	},
+	{
+		Service: "Ghost",
+		CNAME:   []string{"*.ghost.io"},
+		Body:    []string{"Site unavailable.|Failed to resolve DNS path for this host", "The thing you were looking for is no longer here"},
+	},
+	{
+		Service: "Pantheon",
+		CNAME:   []string{"*.pantheonsite.io"},
+		Body:    []string{"The gods are wise, but do not know of the site which you seek."},
+	},
+	{
+		Service: "Readme.io",
+		CNAME:   []string{"*.readme.io"},
+		Body:    []string{"Project doesnt exist... yet!"},
+	},
+	{
+		Service: "Shopify",
+		CNAME:   []string{"*.myshopify.com", "shops.myshopify.com"},
+		Body:    []string{"Sorry, this shop is currently unavailable."},
+		Edge:    true,
+	},
+	{
+		Service: "Surge.sh",
+		CNAME:   []string{"*.surge.sh"},
+		Body:    []string{"project not found"},
+	},
+	{
+		Service: "Tumblr",
+		CNAME:   []string{"domains.tumblr.com"},
+		Body:    []string{"Whatever you were looking for doesn't currently exist at this address."},
+		Edge:    true,
+	},
+	{
+		Service: "Unbounce",
+		CNAME:   []string{"unbouncepages.com", "*.unbouncepages.com"},
+		Body:    []string{"The requested URL was not found on this server."},
+		Edge:    true,
+	},
+	{
+		Service: "Zendesk",
+		CNAME:   []string{"*.zendesk.com"},
+		Body:    []string{"Help Center Closed"},
+		Edge:    true,
+	},
+	{
+		Service: "DigitalOcean DNS",
+		NS:      []string{"ns*.digitalocean.com"},
+	},
+	{
+		Service: "Google Cloud DNS",
+		NS:      []string{"ns-cloud-*.googledomains.com"},
+	},
+	{
+		Service: "Linode DNS",
+		NS:      []string{"ns*.linode.com"},
+	},
+}
+
+// match returns whether name matches any of the patterns.
+func match(patterns []string, name string) bool {
+
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+
+	for i := range patterns {
+		if ok, _ := path.Match(patterns[i], name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchCNAME returns the fingerprint that matches the CNAME target.
+// Returns nil if no fingerprint found.
+func MatchCNAME(fps []Fingerprint, target string) *Fingerprint {
+
+	for i := range fps {
+		if match(fps[i].CNAME, target) {
+			return &fps[i]
+		}
+	}
+
+	return nil
+}
+
+// MatchNS returns the fingerprint that matches the nameserver ns.
+// Returns nil if no fingerprint found.
+func MatchNS(fps []Fingerprint, ns string) *Fingerprint {
+
+	for i := range fps {
+		if match(fps[i].NS, ns) {
+			return &fps[i]
+		}
+	}
+
+	return nil
+}
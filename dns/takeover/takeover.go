@@ -0,0 +1,268 @@
+/*
+Package takeover detects subdomain takeover vulnerabilities.
+
+The Engine resolves the alias chain of the names and matches the CNAME targets against a fingerprint database.
+A name is vulnerable if the CNAME points to a non-existing resource of a service (dangling CNAME),
+the service returns the "unclaimed resource" page or the name is delegated to a DNS provider that does not host the zone.
+*/
+package takeover
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elmasy-com/elnet/dns"
+)
+
+// Status is the result of the evaluation.
+type Status int
+
+const (
+	Safe               Status = iota // No takeover found
+	PossiblyVulnerable               // Suspicious, needs manual verification
+	Vulnerable                       // Takeover is possible
+)
+
+func (s Status) String() string {
+
+	switch s {
+	case Safe:
+		return "safe"
+	case PossiblyVulnerable:
+		return "possibly vulnerable"
+	case Vulnerable:
+		return "vulnerable"
+	default:
+		return fmt.Sprintf("unknown status: %d", int(s))
+	}
+}
+
+// Result is the evaluation of a name.
+type Result struct {
+	Name     string     // The evaluated name
+	Status   Status     // The result of the evaluation
+	Service  string     // The matched service, empty if no fingerprint matched
+	Chain    *dns.Chain // The resolved alias chain, can be nil
+	Evidence []string   // Human readable evidences
+	Err      error      // Error occurred while evaluating, the Status is Safe if the name can not be evaluated
+}
+
+// Engine is the subdomain takeover detection engine.
+type Engine struct {
+	servers      *dns.Servers
+	fingerprints []Fingerprint
+	client       *http.Client
+	timeout      time.Duration // Timeout of the direct queries to the nameservers
+	nsPort       string        // Port of the nameservers
+}
+
+// NewEngine creates a new Engine that uses servers to resolve the names and the default Fingerprints.
+func NewEngine(servers *dns.Servers) *Engine {
+
+	return &Engine{
+		servers:      servers,
+		fingerprints: Fingerprints,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 5 {
+					return http.ErrUseLastResponse
+				}
+				return nil
+			},
+		},
+		timeout: 2 * time.Second,
+		nsPort:  "53",
+	}
+}
+
+// SetFingerprints replaces the fingerprint database.
+func (e *Engine) SetFingerprints(fps []Fingerprint) {
+	e.fingerprints = fps
+}
+
+// SetHTTPClient sets the client used for the HTTP probes.
+func (e *Engine) SetHTTPClient(c *http.Client) {
+	e.client = c
+}
+
+// SetTimeout sets the timeout of the direct queries to the nameservers of the NS delegations.
+func (e *Engine) SetTimeout(d time.Duration) {
+	e.timeout = d
+}
+
+// probe sends a HTTP GET request to name and returns the first signature found in the response body.
+// Returns an empty string if no signature found.
+func (e *Engine) probe(name string, signatures []string) (string, error) {
+
+	resp, err := e.client.Get("http://" + name + "/")
+	if err != nil {
+		return "", fmt.Errorf("failed to probe: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read body: %w", err)
+	}
+
+	for i := range signatures {
+		if strings.Contains(string(body), signatures[i]) {
+			return signatures[i], nil
+		}
+	}
+
+	return "", nil
+}
+
+// checkNS checks whether the nameservers of name are at a DNS provider that does not host the zone.
+func (e *Engine) checkNS(r *Result) {
+
+	ns, err := e.servers.TryQueryNS(r.Name)
+	if err != nil {
+		if errors.Is(err, dns.ErrServerFailure) || errors.Is(err, dns.ErrRefused) {
+			r.Status = PossiblyVulnerable
+			r.Evidence = append(r.Evidence, fmt.Sprintf("NS query returned %s, possibly lame delegation", err))
+		}
+		return
+	}
+
+	for i := range ns {
+
+		fp := MatchNS(e.fingerprints, ns[i])
+		if fp == nil {
+			continue
+		}
+
+		r.Service = fp.Service
+
+		ips, err := e.servers.TryQueryA(ns[i])
+		if err != nil || len(ips) == 0 {
+			r.Status = PossiblyVulnerable
+			r.Evidence = append(r.Evidence, fmt.Sprintf("nameserver %s of %s can not be resolved", dns.Clean(ns[i]), fp.Service))
+			continue
+		}
+
+		srv, err := dns.NewServer("udp", ips[0].String(), e.nsPort, e.timeout)
+		if err != nil {
+			r.Err = fmt.Errorf("failed to create server for %s: %w", ns[i], err)
+			continue
+		}
+
+		_, err = srv.QueryResponse(r.Name, dns.TypeSOA)
+		if errors.Is(err, dns.ErrRefused) || errors.Is(err, dns.ErrServerFailure) || errors.Is(err, dns.ErrName) {
+			r.Status = Vulnerable
+			r.Evidence = append(r.Evidence, fmt.Sprintf("nameserver %s of %s returned %s for the zone", dns.Clean(ns[i]), fp.Service, err))
+			return
+		}
+	}
+}
+
+// Check evaluates name.
+func (e *Engine) Check(name string) Result {
+
+	r := Result{Name: dns.Clean(name)}
+
+	chain, err := e.servers.ResolveChain(r.Name, dns.TypeA)
+	r.Chain = chain
+
+	if err != nil && !errors.Is(err, dns.ErrName) {
+		r.Err = err
+	}
+
+	if chain == nil || len(chain.Hops) == 0 {
+		e.checkNS(&r)
+		return r
+	}
+
+	var fp *Fingerprint
+
+	for i := range chain.Hops {
+		if fp = MatchCNAME(e.fingerprints, chain.Hops[i].Target); fp != nil {
+			r.Service = fp.Service
+			r.Evidence = append(r.Evidence, fmt.Sprintf("%s points to %s (%s)", chain.Hops[i].Name, chain.Hops[i].Target, fp.Service))
+			break
+		}
+	}
+
+	if chain.Dangling {
+
+		switch {
+		case fp == nil:
+			r.Status = PossiblyVulnerable
+			r.Evidence = append(r.Evidence, fmt.Sprintf("dangling CNAME: %s does not exist", chain.Target))
+		case fp.NXDomain:
+			r.Status = Vulnerable
+			r.Evidence = append(r.Evidence, fmt.Sprintf("CNAME target %s does not exist (NXDOMAIN)", chain.Target))
+		default:
+			r.Status = PossiblyVulnerable
+			r.Evidence = append(r.Evidence, fmt.Sprintf("CNAME target %s does not exist (NXDOMAIN), but %s is not known to be vulnerable", chain.Target, fp.Service))
+		}
+
+		return r
+	}
+
+	if fp == nil || len(fp.Body) == 0 {
+		return r
+	}
+
+	sig, err := e.probe(r.Name, fp.Body)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+
+	if sig == "" {
+		return r
+	}
+
+	if fp.Edge {
+		r.Status = PossiblyVulnerable
+	} else {
+		r.Status = Vulnerable
+	}
+
+	r.Evidence = append(r.Evidence, fmt.Sprintf("HTTP response contains %q", sig))
+
+	return r
+}
+
+// CheckAll evaluates names concurrently with workers goroutines.
+// The results are in the same order as names.
+func (e *Engine) CheckAll(names []string, workers int) []Result {
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]Result, len(names))
+	indexes := make(chan int)
+	wg := new(sync.WaitGroup)
+
+	for i := 0; i < workers; i++ {
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := range indexes {
+				results[j] = e.Check(names[j])
+			}
+		}()
+	}
+
+	for i := range names {
+		indexes <- i
+	}
+
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
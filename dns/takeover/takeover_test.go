@@ -0,0 +1,195 @@
+package takeover
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/elmasy-com/elnet/dns"
+	mdns "github.com/miekg/dns"
+)
+
+// testZone answers the queries of the test names.
+func testZone(w mdns.ResponseWriter, r *mdns.Msg) {
+
+	m := new(mdns.Msg)
+	m.SetReply(r)
+
+	q := r.Question[0]
+	hdr := func(name string, t uint16) mdns.RR_Header {
+		return mdns.RR_Header{Name: name, Rrtype: t, Class: mdns.ClassINET, Ttl: 60}
+	}
+	a := func(name string) *mdns.A {
+		return &mdns.A{Hdr: hdr(name, mdns.TypeA), A: net.ParseIP("127.0.0.1")}
+	}
+
+	switch q.Name {
+	case "gh.example.com.", "ok.example.com.":
+		target := "unclaimed.github.io."
+		if q.Name == "ok.example.com." {
+			target = "claimed.github.io."
+		}
+		m.Answer = append(m.Answer, &mdns.CNAME{Hdr: hdr(q.Name, mdns.TypeCNAME), Target: target})
+		if q.Qtype == mdns.TypeA {
+			m.Answer = append(m.Answer, a(target))
+		}
+	case "azure.example.com.", "unknown.example.com.":
+		target := "gone.azurewebsites.net."
+		if q.Name == "unknown.example.com." {
+			target = "gone.example.net."
+		}
+		m.Answer = append(m.Answer, &mdns.CNAME{Hdr: hdr(q.Name, mdns.TypeCNAME), Target: target})
+		m.Rcode = mdns.RcodeNameError
+	case "gone.azurewebsites.net.", "gone.example.net.":
+		m.Rcode = mdns.RcodeNameError
+	case "plain.example.com.", "ns-1.awsdns-01.com.":
+		if q.Qtype == mdns.TypeA {
+			m.Answer = append(m.Answer, a(q.Name))
+		}
+	case "lame.example.com.":
+		switch q.Qtype {
+		case mdns.TypeNS:
+			m.Answer = append(m.Answer, &mdns.NS{Hdr: hdr(q.Name, mdns.TypeNS), Ns: "ns-1.awsdns-01.com."})
+		case mdns.TypeSOA:
+			m.Rcode = mdns.RcodeRefused
+		default:
+			m.Rcode = mdns.RcodeServerFailure
+		}
+	default:
+		m.Rcode = mdns.RcodeNameError
+	}
+
+	w.WriteMsg(m)
+}
+
+// testEngine starts a local DNS and HTTP server and returns an Engine that uses them.
+func testEngine(t *testing.T) *Engine {
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("FAIL: failed to listen: %s\n", err)
+	}
+
+	srv := &mdns.Server{PacketConn: pc, Handler: mdns.HandlerFunc(testZone)}
+
+	started := make(chan struct{})
+	srv.NotifyStartedFunc = func() { close(started) }
+
+	go srv.ActivateAndServe()
+
+	<-started
+
+	t.Cleanup(func() { srv.Shutdown() })
+
+	servers, err := dns.NewServersStr(1, time.Second, "udp://"+pc.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host == "gh.example.com" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "<h1>404</h1><p>There isn't a GitHub Pages site here.</p>")
+			return
+		}
+		fmt.Fprint(w, "Hello")
+	}))
+	t.Cleanup(hs.Close)
+
+	e := NewEngine(&servers)
+
+	// Every HTTP request goes to the local server
+	e.SetHTTPClient(&http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return new(net.Dialer).DialContext(ctx, network, hs.Listener.Addr().String())
+		},
+	}})
+
+	_, e.nsPort, _ = net.SplitHostPort(pc.LocalAddr().String())
+
+	return e
+}
+
+func TestCheck(t *testing.T) {
+
+	e := testEngine(t)
+
+	cases := []struct {
+		name    string
+		status  Status
+		service string
+	}{
+		{"gh.example.com", Vulnerable, "GitHub Pages"},
+		{"ok.example.com", Safe, "GitHub Pages"},
+		{"azure.example.com", Vulnerable, "Microsoft Azure"},
+		{"unknown.example.com", PossiblyVulnerable, ""},
+		{"plain.example.com", Safe, ""},
+		{"lame.example.com", Vulnerable, "AWS Route 53"},
+	}
+
+	for i := range cases {
+
+		r := e.Check(cases[i].name)
+
+		if r.Status != cases[i].status || r.Service != cases[i].service {
+			t.Fatalf("FAIL: %s: want %s/%q, got %s/%q (evidence: %v, err: %v)\n", cases[i].name, cases[i].status, cases[i].service, r.Status, r.Service, r.Evidence, r.Err)
+		}
+
+		if r.Status != Safe && len(r.Evidence) == 0 {
+			t.Fatalf("FAIL: %s: evidence is empty\n", cases[i].name)
+		}
+	}
+}
+
+func TestCheckAll(t *testing.T) {
+
+	e := testEngine(t)
+
+	names := []string{"gh.example.com", "plain.example.com", "azure.example.com"}
+
+	rs := e.CheckAll(names, 2)
+
+	if len(rs) != len(names) {
+		t.Fatalf("FAIL: want %d results, got %d\n", len(names), len(rs))
+	}
+
+	for i := range rs {
+		if rs[i].Name != names[i] {
+			t.Fatalf("FAIL: result %d: want %s, got %s\n", i, names[i], rs[i].Name)
+		}
+	}
+
+	if rs[0].Status != Vulnerable || rs[1].Status != Safe || rs[2].Status != Vulnerable {
+		t.Fatalf("FAIL: unexpected statuses: %s, %s, %s\n", rs[0].Status, rs[1].Status, rs[2].Status)
+	}
+}
+
+func TestMatchCNAME(t *testing.T) {
+
+	cases := map[string]string{
+		"bucket.s3.amazonaws.com.":                  "AWS S3",
+		"bucket.s3-website-us-east-1.amazonaws.com": "AWS S3",
+		"App.HerokuApp.com":                         "Heroku",
+		"example.com":                               "",
+	}
+
+	for target, service := range cases {
+
+		fp := MatchCNAME(Fingerprints, target)
+
+		switch {
+		case fp == nil && service != "":
+			t.Fatalf("FAIL: %s: want %s, got nil\n", target, service)
+		case fp != nil && fp.Service != service:
+			t.Fatalf("FAIL: %s: want %q, got %q\n", target, service, fp.Service)
+		}
+	}
+
+	if fp := MatchNS(Fingerprints, "ns-1234.awsdns-56.org."); fp == nil || fp.Service != "AWS Route 53" {
+		t.Fatalf("FAIL: Route 53 nameserver is not matched\n")
+	}
+}
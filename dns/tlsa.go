@@ -0,0 +1,189 @@
+package dns
+
+import (
+	"fmt"
+	"strings"
+
+	mdns "github.com/miekg/dns"
+)
+
+var TypeTLSA uint16 = 52
+
+// TLSA certificate usages (RFC 7218).
+const (
+	TLSAUsagePKIXTA uint8 = 0 // CA constraint, PKIX validation required
+	TLSAUsagePKIXEE uint8 = 1 // Service certificate constraint, PKIX validation required
+	TLSAUsageDANETA uint8 = 2 // Trust anchor assertion
+	TLSAUsageDANEEE uint8 = 3 // Domain-issued certificate
+)
+
+// TLSA selectors (RFC 7218).
+const (
+	TLSASelectorCert uint8 = 0 // Full certificate
+	TLSASelectorSPKI uint8 = 1 // SubjectPublicKeyInfo
+)
+
+// TLSA matching types (RFC 7218).
+const (
+	TLSAMatchingFull   uint8 = 0 // Exact match
+	TLSAMatchingSHA256 uint8 = 1 // SHA-256 hash
+	TLSAMatchingSHA512 uint8 = 2 // SHA-512 hash
+)
+
+type TLSA struct {
+	Usage        uint8
+	Selector     uint8
+	MatchingType uint8
+	Certificate  string // Hex encoded certificate association data
+}
+
+func (t TLSA) String() string {
+	return fmt.Sprintf("%d %d %d %s", t.Usage, t.Selector, t.MatchingType, t.Certificate)
+}
+
+// TLSAName returns the owner name of the TLSA records of the service (eg.: 25, "tcp", "mx.example.com" -> "_25._tcp.mx.example.com").
+func TLSAName(port int, proto string, name string) string {
+	return fmt.Sprintf("_%d._%s.%s", port, strings.TrimPrefix(proto, "_"), Clean(name))
+}
+
+// tlsaFromRR returns the TLSA records from rr.
+func tlsaFromRR(rr []mdns.RR) ([]TLSA, error) {
+
+	r := make([]TLSA, 0, len(rr))
+
+	for i := range rr {
+
+		switch v := rr[i].(type) {
+		case *mdns.TLSA:
+			r = append(r, TLSA{Usage: v.Usage, Selector: v.Selector, MatchingType: v.MatchingType, Certificate: strings.ToLower(v.Certificate)})
+		case *mdns.CNAME:
+			// Ignore CNAME
+			continue
+		case *mdns.DNAME:
+			// Ignore DNAME
+			continue
+		case *mdns.RRSIG:
+			// Ignore the signatures if DNSSEC is enabled
+			continue
+		default:
+			return nil, fmt.Errorf("unknown type: %T", v)
+		}
+	}
+
+	return r, nil
+}
+
+// QueryTLSA ask the server and returns a slice of TLSA.
+// The answer slice will be nil in case of error.
+//
+// The other record types are ignored.
+func (s *Server) QueryTLSA(name string) ([]TLSA, error) {
+
+	rr, err := s.query(name, TypeTLSA)
+	if err != nil {
+		return nil, err
+	}
+
+	return tlsaFromRR(rr)
+}
+
+// QueryTLSA ask a random server from servers and returns a slice of TLSA.
+// The answer slice will be nil in case of error.
+//
+// The other record types are ignored.
+func (s *Servers) QueryTLSA(name string) ([]TLSA, error) {
+
+	return s.Get(-1).QueryTLSA(name)
+}
+
+// QueryTLSA ask a random server from DefaultServers and returns a slice of TLSA.
+// The answer slice will be nil in case of error.
+//
+// The other record types are ignored.
+func QueryTLSA(name string) ([]TLSA, error) {
+
+	return DefaultServers.QueryTLSA(name)
+}
+
+// TryQueryTLSA asks the servers for type TLSA. If any error occurred, retries with next server (except if error is NXDOMAIN).
+//
+// In case of error, the answer will be nil and return ErrX or any unknown error.
+//
+// The first used server is random. The other record types are ignored.
+func (s *Servers) TryQueryTLSA(name string) ([]TLSA, error) {
+
+	rr, err := s.TryQuery(name, TypeTLSA)
+	if err != nil {
+		return nil, err
+	}
+
+	return tlsaFromRR(rr)
+}
+
+// TryQueryTLSA asks the DefaultServers for type TLSA. If any error occurred, retries with next server (except if error is NXDOMAIN).
+//
+// In case of error, the answer will be nil and return ErrX or any unknown error.
+//
+// The first used server is random. The other record types are ignored.
+func TryQueryTLSA(name string) ([]TLSA, error) {
+
+	return DefaultServers.TryQueryTLSA(name)
+}
+
+// TLSASet is the TLSA record set of a service with the DNSSEC status.
+type TLSASet struct {
+	Name    string // Owner name (eg.: "_25._tcp.mx.example.com")
+	Records []TLSA
+	Secure  bool // The resolver validated the answer with DNSSEC (AD flag)
+}
+
+// GetTLSA returns the TLSA records of the service name:port/proto and the DNSSEC status.
+// To get a validated answer, the servers must be a validating resolver and DNSSEC must be enabled with SetEDNS(&EDNS{DO: true}).
+//
+// If the records not found (NXDOMAIN or empty answer), returns an empty set without error.
+func (s *Servers) GetTLSA(port int, proto string, name string) (*TLSASet, error) {
+
+	set := &TLSASet{Name: TLSAName(port, proto, name)}
+
+	r, err := s.TryQueryResponse(set.Name, TypeTLSA)
+	if r == nil {
+		return nil, err
+	}
+
+	set.Secure = r.AuthenticatedData
+
+	if r.Rcode == mdns.RcodeNameError {
+		return set, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	set.Records, err = tlsaFromRR(r.Answer)
+	if err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
+
+// GetTLSA returns the TLSA records of the service name:port/proto and the DNSSEC status using the DefaultServers.
+//
+// See Servers.GetTLSA() for more.
+func GetTLSA(port int, proto string, name string) (*TLSASet, error) {
+
+	return DefaultServers.GetTLSA(port, proto, name)
+}
+
+// IsSetTLSA checks whether a TLSA type record set for name.
+// NXDOMAIN is not an error here, because it means "not found".
+func (s *Servers) IsSetTLSA(name string) (bool, error) {
+	return s.IsSet(name, TypeTLSA)
+}
+
+// IsSetTLSA checks whether a TLSA type record set for name using the DefaultServers.
+// NXDOMAIN is not an error here, because it means "not found".
+func IsSetTLSA(name string) (bool, error) {
+	return DefaultServers.IsSetTLSA(name)
+}
@@ -0,0 +1,62 @@
+package dns
+
+import (
+	"testing"
+	"time"
+
+	mdns "github.com/miekg/dns"
+)
+
+func TestTLSAName(t *testing.T) {
+
+	if n := TLSAName(25, "tcp", "MX.Example.com."); n != "_25._tcp.mx.example.com" {
+		t.Fatalf("FAIL: invalid name: %s\n", n)
+	}
+}
+
+func TestGetTLSA(t *testing.T) {
+
+	addr := startTestServer(t, func(w mdns.ResponseWriter, r *mdns.Msg) {
+
+		m := new(mdns.Msg)
+		m.SetReply(r)
+
+		if r.Question[0].Name == "_443._tcp.example.com." {
+			m.AuthenticatedData = true
+			m.Answer = append(m.Answer, &mdns.TLSA{
+				Hdr:          mdns.RR_Header{Name: r.Question[0].Name, Rrtype: mdns.TypeTLSA, Class: mdns.ClassINET, Ttl: 60},
+				Usage:        3,
+				Selector:     1,
+				MatchingType: 1,
+				Certificate:  "ABCDEF",
+			})
+		} else {
+			m.Rcode = mdns.RcodeNameError
+		}
+
+		w.WriteMsg(m)
+	})
+
+	servers, err := NewServersStr(1, time.Second, addr)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	set, err := servers.GetTLSA(443, "tcp", "example.com")
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if !set.Secure || len(set.Records) != 1 || set.Records[0].String() != "3 1 1 abcdef" {
+		t.Fatalf("FAIL: invalid set: %#v\n", set)
+	}
+
+	set, err = servers.GetTLSA(25, "tcp", "example.com")
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if len(set.Records) != 0 {
+		t.Fatalf("FAIL: want empty set, got %v\n", set.Records)
+	}
+}
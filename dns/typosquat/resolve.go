@@ -0,0 +1,113 @@
+package typosquat
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/elmasy-com/elnet/dns"
+)
+
+// Result is the resolved Permutation.
+type Result struct {
+	Permutation
+	Registered bool     // Any record exists for the domain
+	A          []net.IP // A records
+	MX         []dns.MX // MX records
+	NS         []string // NS records
+	Err        error    // Error occurred while resolving
+}
+
+// resolve checks whether the domain of p is exists and queries the records.
+func resolve(servers *dns.Servers, p Permutation) Result {
+
+	r := Result{Permutation: p}
+
+	exists, err := servers.IsExists(p.Domain)
+	if errors.Is(err, dns.ErrName) {
+		// NXDOMAIN: the domain is not registered
+		return r
+	}
+	if err != nil {
+		r.Err = fmt.Errorf("failed to check %s: %w", p.Domain, err)
+		return r
+	}
+
+	if !exists {
+		return r
+	}
+
+	r.Registered = true
+
+	if r.A, err = servers.TryQueryA(p.Domain); err != nil && !errors.Is(err, dns.ErrName) {
+		r.Err = fmt.Errorf("failed to query A: %w", err)
+	}
+
+	if r.MX, err = servers.TryQueryMX(p.Domain); err != nil && !errors.Is(err, dns.ErrName) && r.Err == nil {
+		r.Err = fmt.Errorf("failed to query MX: %w", err)
+	}
+
+	if r.NS, err = servers.TryQueryNS(p.Domain); err != nil && !errors.Is(err, dns.ErrName) && r.Err == nil {
+		r.Err = fmt.Errorf("failed to query NS: %w", err)
+	}
+
+	return r
+}
+
+// Resolve resolves the permutations concurrently with workers goroutines using servers.
+// The results are in the same order as perms.
+func Resolve(servers *dns.Servers, perms []Permutation, workers int) []Result {
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]Result, len(perms))
+	indexes := make(chan int)
+	wg := new(sync.WaitGroup)
+
+	for i := 0; i < workers; i++ {
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := range indexes {
+				results[j] = resolve(servers, perms[j])
+			}
+		}()
+	}
+
+	for i := range perms {
+		indexes <- i
+	}
+
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// Scan generates the permutations of domain with the given techniques and returns the registered ones.
+// If techniques is empty, every technique is used.
+//
+// The permutations that failed to resolve are returned too with Err set.
+func Scan(servers *dns.Servers, domain string, workers int, techniques ...Technique) ([]Result, error) {
+
+	perms, err := Generate(domain, techniques...)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []Result
+
+	for _, r := range Resolve(servers, perms, workers) {
+		if r.Registered || r.Err != nil {
+			found = append(found, r)
+		}
+	}
+
+	return found, nil
+}
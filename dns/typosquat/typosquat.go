@@ -0,0 +1,252 @@
+/*
+Package typosquat generates the typosquatting and lookalike permutations of a domain and finds the registered ones.
+
+The techniques are based on dnstwist (https://github.com/elceef/dnstwist).
+*/
+package typosquat
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/elmasy-com/elnet/dns"
+	"github.com/elmasy-com/elnet/idn"
+	"github.com/elmasy-com/elnet/validator"
+)
+
+// Technique is the name of a permutation technique.
+type Technique string
+
+const (
+	Omission      Technique = "omission"      // Remove a character (eg.: "exmple.com")
+	Repetition    Technique = "repetition"    // Repeat a character (eg.: "exxample.com")
+	Transposition Technique = "transposition" // Swap two adjacent characters (eg.: "exmaple.com")
+	Replacement   Technique = "replacement"   // Replace a character with a keyboard-adjacent one (eg.: "exsmple.com")
+	Homoglyph     Technique = "homoglyph"     // Replace a character with a lookalike (eg.: "examp1e.com", "еxample.com")
+	BitFlip       Technique = "bitflip"       // Flip a bit of a character (eg.: "axample.com")
+	Hyphenation   Technique = "hyphenation"   // Insert a hyphen (eg.: "ex-ample.com")
+	VowelSwap     Technique = "vowel-swap"    // Replace a vowel with an other vowel (eg.: "exomple.com")
+	TLDSwap       Technique = "tld-swap"      // Replace the TLD (eg.: "example.net")
+	Subdomain     Technique = "subdomain"     // Insert a dot (eg.: "ex.ample.com")
+)
+
+// Techniques is the list of every technique.
+var Techniques = []Technique{Omission, Repetition, Transposition, Replacement, Homoglyph, BitFlip, Hyphenation, VowelSwap, TLDSwap, Subdomain}
+
+// TLDs is the list of TLDs used by the TLDSwap technique.
+var TLDs = []string{
+	"com", "net", "org", "info", "biz", "io", "co", "app", "dev", "xyz", "online", "site", "shop", "store",
+	"top", "me", "us", "uk", "co.uk", "de", "eu", "fr", "it", "nl", "ru", "cn", "in", "cc", "tv", "ws",
+}
+
+// Permutation is a generated domain.
+type Permutation struct {
+	Technique Technique
+	Domain    string // ASCII form (eg.: "xn--xample-2of.com")
+	Unicode   string // Unicode form for display, equals to Domain if ASCII
+}
+
+// keyboard is the adjacent keys on the QWERTY keyboard.
+var keyboard = map[rune]string{
+	'1': "2q", '2': "3wq1", '3': "4ew2", '4': "5re3", '5': "6tr4", '6': "7yt5", '7': "8uy6", '8': "9iu7", '9': "0oi8", '0': "po9",
+	'q': "12wa", 'w': "3esaq2", 'e': "4rdsw3", 'r': "5tfde4", 't': "6ygfr5", 'y': "7uhgt6", 'u': "8ijhy7", 'i': "9okju8", 'o': "0plki9", 'p': "lo0",
+	'a': "qwsz", 's': "edxzaw", 'd': "rfcxse", 'f': "tgvcdr", 'g': "yhbvft", 'h': "ujnbgy", 'j': "ikmnhu", 'k': "olmji", 'l': "kop",
+	'z': "asx", 'x': "zsdc", 'c': "xdfv", 'v': "cfgb", 'b': "vghn", 'n': "bhjm", 'm': "njk",
+}
+
+// glyphs is the lookalike characters and sequences.
+var glyphs = map[rune][]string{
+	'a': {"à", "á", "â", "ã", "ä", "å", "ɑ", "а", "ạ"},
+	'b': {"d", "lb", "ʙ", "ь", "ḃ"},
+	'c': {"e", "ϲ", "с", "ċ", "ć"},
+	'd': {"b", "cl", "dl", "ԁ", "ď"},
+	'e': {"c", "é", "è", "ê", "ë", "е", "ẹ"},
+	'f': {"ƒ", "ḟ"},
+	'g': {"q", "ɡ", "ġ", "ğ"},
+	'h': {"lh", "һ", "ḣ"},
+	'i': {"1", "l", "í", "ì", "ï", "і", "ı"},
+	'j': {"ј", "ʝ"},
+	'k': {"lk", "ik", "lc", "κ", "к"},
+	'l': {"1", "i", "ɫ", "ӏ", "ḷ"},
+	'm': {"n", "nn", "rn", "rr", "ṃ"},
+	'n': {"m", "r", "ń", "ñ", "ո"},
+	'o': {"0", "ο", "о", "ö", "ó", "ò", "օ"},
+	'p': {"ρ", "р", "ṗ"},
+	'q': {"g", "ԛ", "զ"},
+	'r': {"ʀ", "г", "ṛ"},
+	's': {"ѕ", "ś", "ṡ"},
+	't': {"τ", "ţ", "ṭ"},
+	'u': {"μ", "υ", "ս", "ü", "ú"},
+	'v': {"ѵ", "ν", "ṿ"},
+	'w': {"vv", "ԝ", "ẉ"},
+	'x': {"х", "ẋ"},
+	'y': {"у", "ý", "ÿ"},
+	'z': {"ʐ", "ż", "ź"},
+}
+
+const vowels = "aeiou"
+
+// generator collects the unique permutations.
+type generator struct {
+	orig  string
+	seen  map[string]bool
+	perms []Permutation
+}
+
+// add adds the domain d to the permutations if valid and not seen.
+func (g *generator) add(t Technique, d string) {
+
+	ascii := d
+
+	if !idn.IsASCII(d) {
+
+		a, err := idn.ToASCII(d)
+		if err != nil {
+			return
+		}
+
+		ascii = a
+	}
+
+	ascii = strings.ToLower(ascii)
+
+	if ascii == g.orig || g.seen[ascii] || !validator.Domain(ascii) {
+		return
+	}
+
+	g.seen[ascii] = true
+
+	u := ascii
+	if idn.HasPunycode(ascii) {
+		if v, err := idn.ToUnicode(ascii); err == nil {
+			u = v
+		}
+	}
+
+	g.perms = append(g.perms, Permutation{Technique: t, Domain: ascii, Unicode: u})
+}
+
+// Generate generates the permutations of the registrable domain of domain with the given techniques.
+// If techniques is empty, every technique is used.
+//
+// The subdomain part of domain is ignored, the permutations are generated on the domain part (eg.: "www.example.co.uk" -> "exmple.co.uk").
+// The permutations are valid, unique and does not contain the original domain.
+// The order follows the order of techniques.
+func Generate(domain string, techniques ...Technique) ([]Permutation, error) {
+
+	d, err := dns.ParseDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.IsTLD() {
+		return nil, fmt.Errorf("%w: %s is a TLD", dns.ErrInvalidDomain, domain)
+	}
+
+	parts := d.Parts()
+
+	if len(techniques) == 0 {
+		techniques = Techniques
+	}
+
+	g := &generator{orig: parts.Domain + "." + parts.TLD, seen: make(map[string]bool)}
+
+	// Work on the Unicode form to generate homoglyphs on the visible characters
+	label := parts.Domain
+	if idn.HasPunycode(label) {
+		if u, err := idn.ToUnicode(label); err == nil {
+			label = u
+		}
+	}
+
+	tld := "." + parts.TLD
+	l := []rune(label)
+
+	for _, t := range techniques {
+
+		switch t {
+
+		case Omission:
+			for i := range l {
+				g.add(t, string(l[:i])+string(l[i+1:])+tld)
+			}
+
+		case Repetition:
+			for i := range l {
+				g.add(t, string(l[:i+1])+string(l[i:])+tld)
+			}
+
+		case Transposition:
+			for i := 0; i < len(l)-1; i++ {
+				if l[i] != l[i+1] {
+					g.add(t, string(l[:i])+string(l[i+1])+string(l[i])+string(l[i+2:])+tld)
+				}
+			}
+
+		case Replacement:
+			for i := range l {
+				for _, k := range keyboard[l[i]] {
+					g.add(t, string(l[:i])+string(k)+string(l[i+1:])+tld)
+				}
+			}
+
+		case Homoglyph:
+			for i := range l {
+				for _, gl := range glyphs[l[i]] {
+					g.add(t, string(l[:i])+gl+string(l[i+1:])+tld)
+				}
+			}
+
+		case BitFlip:
+			for i := range l {
+
+				if l[i] >= 0x80 {
+					continue
+				}
+
+				for b := 0; b < 8; b++ {
+
+					c := l[i] ^ (1 << b)
+
+					if c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' {
+						g.add(t, string(l[:i])+string(c)+string(l[i+1:])+tld)
+					}
+				}
+			}
+
+		case Hyphenation:
+			for i := 1; i < len(l); i++ {
+				g.add(t, string(l[:i])+"-"+string(l[i:])+tld)
+			}
+
+		case VowelSwap:
+			for i := range l {
+
+				if !strings.ContainsRune(vowels, l[i]) {
+					continue
+				}
+
+				for _, v := range vowels {
+					if v != l[i] {
+						g.add(t, string(l[:i])+string(v)+string(l[i+1:])+tld)
+					}
+				}
+			}
+
+		case TLDSwap:
+			for _, v := range TLDs {
+				g.add(t, label+"."+v)
+			}
+
+		case Subdomain:
+			for i := 1; i < len(l); i++ {
+				g.add(t, string(l[:i])+"."+string(l[i:])+tld)
+			}
+
+		default:
+			return nil, fmt.Errorf("invalid technique: %s", t)
+		}
+	}
+
+	return g.perms, nil
+}
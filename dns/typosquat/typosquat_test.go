@@ -0,0 +1,145 @@
+package typosquat
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/elmasy-com/elnet/dns"
+	mdns "github.com/miekg/dns"
+)
+
+func TestGenerate(t *testing.T) {
+
+	perms, err := Generate("www.example.com")
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	want := map[string]Technique{
+		"exmple.com":         Omission,
+		"exxample.com":       Repetition,
+		"xeample.com":        Transposition,
+		"exsmple.com":        Replacement,
+		"examp1e.com":        Homoglyph,
+		"xn--xample-2of.com": Homoglyph, // Cyrillic "е"
+		"axample.com":        BitFlip,
+		"ex-ample.com":       Hyphenation,
+		"exomple.com":        VowelSwap,
+		"example.net":        TLDSwap,
+		"ex.ample.com":       Subdomain,
+	}
+
+	seen := make(map[string]bool)
+
+	for _, p := range perms {
+
+		if p.Domain == "example.com" {
+			t.Fatalf("FAIL: the original domain is generated\n")
+		}
+
+		if seen[p.Domain] {
+			t.Fatalf("FAIL: duplicate: %s\n", p.Domain)
+		}
+
+		seen[p.Domain] = true
+
+		if tech, ok := want[p.Domain]; ok {
+
+			if p.Technique != tech {
+				t.Fatalf("FAIL: %s: want %s, got %s\n", p.Domain, tech, p.Technique)
+			}
+
+			delete(want, p.Domain)
+		}
+	}
+
+	if len(want) != 0 {
+		t.Fatalf("FAIL: missing permutations: %v\n", want)
+	}
+
+	perms, err = Generate("example.co.uk", TLDSwap)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	for _, p := range perms {
+		if p.Technique != TLDSwap {
+			t.Fatalf("FAIL: unwanted technique: %s\n", p.Technique)
+		}
+	}
+
+	if _, err := Generate("com"); err == nil {
+		t.Fatalf("FAIL: TLD must fail\n")
+	}
+}
+
+func TestScan(t *testing.T) {
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("FAIL: failed to listen: %s\n", err)
+	}
+
+	// Only exmple.com is registered
+	srv := &mdns.Server{PacketConn: pc, Handler: mdns.HandlerFunc(func(w mdns.ResponseWriter, r *mdns.Msg) {
+
+		m := new(mdns.Msg)
+		m.SetReply(r)
+
+		q := r.Question[0]
+
+		if q.Name != "exmple.com." {
+			m.Rcode = mdns.RcodeNameError
+			w.WriteMsg(m)
+			return
+		}
+
+		hdr := mdns.RR_Header{Name: q.Name, Rrtype: q.Qtype, Class: mdns.ClassINET, Ttl: 60}
+
+		switch q.Qtype {
+		case mdns.TypeA:
+			m.Answer = append(m.Answer, &mdns.A{Hdr: hdr, A: net.ParseIP("192.0.2.1")})
+		case mdns.TypeMX:
+			m.Answer = append(m.Answer, &mdns.MX{Hdr: hdr, Preference: 10, Mx: "mail.exmple.com."})
+		case mdns.TypeNS:
+			m.Answer = append(m.Answer, &mdns.NS{Hdr: hdr, Ns: "ns1.exmple.com."})
+		}
+
+		w.WriteMsg(m)
+	})}
+
+	started := make(chan struct{})
+	srv.NotifyStartedFunc = func() { close(started) }
+
+	go srv.ActivateAndServe()
+
+	<-started
+
+	t.Cleanup(func() { srv.Shutdown() })
+
+	servers, err := dns.NewServersStr(1, time.Second, "udp://"+pc.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	rs, err := Scan(&servers, "example.com", 8, Omission, Repetition)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if len(rs) != 1 {
+		t.Fatalf("FAIL: want 1 result, got %d: %#v\n", len(rs), rs)
+	}
+
+	r := rs[0]
+
+	switch {
+	case r.Domain != "exmple.com" || r.Technique != Omission || !r.Registered || r.Err != nil:
+		t.Fatalf("FAIL: invalid result: %#v\n", r)
+	case len(r.A) != 1 || !r.A[0].Equal(net.ParseIP("192.0.2.1")):
+		t.Fatalf("FAIL: invalid A: %v\n", r.A)
+	case len(r.MX) != 1 || len(r.NS) != 1:
+		t.Fatalf("FAIL: invalid MX/NS: %v %v\n", r.MX, r.NS)
+	}
+}
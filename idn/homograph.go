@@ -0,0 +1,222 @@
+package idn
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// scriptNames is the sorted names of the unicode.Scripts.
+var scriptNames = func() []string {
+
+	names := make([]string, 0, len(unicode.Scripts))
+
+	for n := range unicode.Scripts {
+		if n != "Common" && n != "Inherited" {
+			names = append(names, n)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}()
+
+// allowedScripts is the allowed script combinations of the "Highly Restrictive" level of UTS #39 section 5.2.
+var allowedScripts = [][]string{
+	{"Latin", "Han", "Hiragana", "Katakana"},
+	{"Latin", "Han", "Bopomofo"},
+	{"Latin", "Han", "Hangul"},
+}
+
+// confusables maps the characters to the prototype Latin characters.
+// This is a subset of the confusables.txt of UTS #39, contains the characters that are valid in the domain names.
+var confusables = map[rune]string{
+	// Cyrillic
+	'а': "a", 'в': "b", 'ь': "b", 'с': "c", 'ԁ': "d", 'е': "e", 'ҽ': "e", 'һ': "h", 'і': "i", 'ӏ': "l",
+	'ј': "j", 'к': "k", 'м': "m", 'п': "n", 'о': "o", 'р': "p", 'ԛ': "q", 'г': "r", 'ѕ': "s", 'т': "t",
+	'ц': "u", 'ѵ': "v", 'ԝ': "w", 'х': "x", 'у': "y", 'ү': "y", 'з': "3", 'ӡ': "3",
+	// Greek
+	'α': "a", 'β': "b", 'ϲ': "c", 'ε': "e", 'η': "n", 'ι': "i", 'ϳ': "j", 'κ': "k", 'ν': "v", 'ο': "o",
+	'ρ': "p", 'τ': "t", 'υ': "u", 'χ': "x", 'γ': "y", 'ω': "w",
+	// Armenian
+	'օ': "o", 'ս': "u", 'ց': "g", 'հ': "h", 'ո': "n", 'զ': "q",
+	// Latin
+	'ı': "i", 'ȷ': "j", 'ɡ': "g", 'ɑ': "a", 'ɩ': "i", 'ʟ': "l", 'ℓ': "l", 'ɾ': "r", 'ʋ': "u", 'ɒ': "a",
+	// Digits
+	'0': "o", '1': "l",
+}
+
+// multiConfusables are the character sequences that look like a single character.
+var multiConfusables = strings.NewReplacer("rn", "m", "vv", "w", "cl", "d")
+
+// Script returns the Unicode script of r (eg.: "Latin", "Cyrillic").
+// Returns "Common" for the characters used by multiple scripts (eg.: digits, hyphen) and "Inherited" for the combining marks.
+func Script(r rune) string {
+
+	switch {
+	case unicode.Is(unicode.Common, r):
+		return "Common"
+	case unicode.Is(unicode.Inherited, r):
+		return "Inherited"
+	case unicode.Is(unicode.Latin, r):
+		// Fast path for the most common script
+		return "Latin"
+	}
+
+	for _, n := range scriptNames {
+		if unicode.Is(unicode.Scripts[n], r) {
+			return n
+		}
+	}
+
+	return "Unknown"
+}
+
+// Scripts returns the sorted list of scripts in s.
+// The Common and Inherited scripts are not included.
+func Scripts(s string) []string {
+
+	set := make(map[string]bool)
+
+	for _, r := range s {
+
+		sc := Script(r)
+
+		if sc != "Common" && sc != "Inherited" {
+			set[sc] = true
+		}
+	}
+
+	r := make([]string, 0, len(set))
+
+	for sc := range set {
+		r = append(r, sc)
+	}
+
+	sort.Strings(r)
+
+	return r
+}
+
+// IsMixedScript returns whether label s mixes scripts.
+// The combinations allowed by the "Highly Restrictive" level of UTS #39 are not mixed (eg.: Japanese with Latin).
+func IsMixedScript(s string) bool {
+
+	scripts := Scripts(s)
+
+	if len(scripts) < 2 {
+		return false
+	}
+
+	for _, allowed := range allowedScripts {
+
+		subset := true
+
+		for _, sc := range scripts {
+
+			found := false
+
+			for _, a := range allowed {
+				if sc == a {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				subset = false
+				break
+			}
+		}
+
+		if subset {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Skeleton returns the skeleton of s based on UTS #39 section 4.
+// Two strings are confusable if their skeletons are equal.
+//
+// Unlike UTS #39, the combining marks are removed and the result is lowercased (eg.: "Ехаmрlé" -> "example").
+func Skeleton(s string) string {
+
+	var b strings.Builder
+
+	for _, r := range norm.NFD.String(s) {
+
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+
+		r = unicode.ToLower(r)
+
+		if v, ok := confusables[r]; ok {
+			b.WriteString(v)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+
+	return multiConfusables.Replace(b.String())
+}
+
+// toUnicode returns the Unicode form of d, or d if the conversion failed.
+func toUnicode(d string) string {
+
+	a, err := ToASCII(d)
+	if err != nil {
+		return d
+	}
+
+	u, err := ToUnicode(a)
+	if err != nil {
+		return d
+	}
+
+	return u
+}
+
+// IsConfusable returns whether the different domains a and b are visually confusable (eg.: "example.com" and "еxample.com" with Cyrillic "е").
+// The A-labels are converted to Unicode before the comparison.
+func IsConfusable(a, b string) bool {
+
+	a = strings.TrimSuffix(toUnicode(a), ".")
+	b = strings.TrimSuffix(toUnicode(b), ".")
+
+	if a == b {
+		return false
+	}
+
+	return Skeleton(a) == Skeleton(b)
+}
+
+// IsHomograph returns whether domain d is a possible homograph attack.
+// A label is suspicious if mixes scripts (eg.: Latin and Cyrillic) or
+// written entirely in a non-Latin script but confusable with a Latin label (eg.: "аррӏе" in Cyrillic).
+// The A-labels are converted to Unicode before the check.
+func IsHomograph(d string) bool {
+
+	for _, l := range strings.Split(toUnicode(d), ".") {
+
+		if IsASCII(l) {
+			continue
+		}
+
+		if IsMixedScript(l) {
+			return true
+		}
+
+		// Whole-script confusable
+		if scripts := Scripts(l); len(scripts) == 1 && scripts[0] != "Latin" && IsASCII(Skeleton(l)) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,68 @@
+package idn
+
+import "testing"
+
+func TestIsMixedScript(t *testing.T) {
+
+	cases := []struct {
+		Label  string
+		Result bool
+	}{
+		{"example", false},
+		{"bücher", false},
+		{"еxample", true}, // Cyrillic "е"
+		{"ソニー", false},
+		{"abcソニー漢字", false}, // Latin + Japanese is allowed
+		{"пример", false},
+		{"test-123", false},
+	}
+
+	for i := range cases {
+		if r := IsMixedScript(cases[i].Label); r != cases[i].Result {
+			t.Fatalf("FAIL: %s: want %v, got %v (scripts: %v)\n", cases[i].Label, cases[i].Result, r, Scripts(cases[i].Label))
+		}
+	}
+}
+
+func TestIsConfusable(t *testing.T) {
+
+	cases := []struct {
+		A, B   string
+		Result bool
+	}{
+		{"example.com", "еxample.com", true},
+		{"apple.com", "аррӏе.com", true},
+		{"apple.com", "xn--80ak6aa92e.com", true},
+		{"paypal.com", "paypa1.com", true},
+		{"modern.com", "modem.com", true},
+		{"example.com", "example.com", false},
+		{"example.com", "examples.com", false},
+	}
+
+	for i := range cases {
+		if r := IsConfusable(cases[i].A, cases[i].B); r != cases[i].Result {
+			t.Fatalf("FAIL: %s - %s: want %v, got %v (%s, %s)\n", cases[i].A, cases[i].B, cases[i].Result, r, Skeleton(cases[i].A), Skeleton(cases[i].B))
+		}
+	}
+}
+
+func TestIsHomograph(t *testing.T) {
+
+	cases := []struct {
+		Domain string
+		Result bool
+	}{
+		{"example.com", false},
+		{"bücher.example", false},
+		{"еxample.com", true},
+		{"xn--80ak6aa92e.com", true},
+		{"пример.рф", false},
+		{"例え.テスト", false},
+	}
+
+	for i := range cases {
+		if r := IsHomograph(cases[i].Domain); r != cases[i].Result {
+			t.Fatalf("FAIL: %s: want %v, got %v\n", cases[i].Domain, cases[i].Result, r)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Package idn implements the Internationalized Domain Name processing (IDNA2008 with UTS #46 mapping)
+and the detection of the homograph attacks (mixed-script and confusable names, UTS #39).
+*/
+package idn
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// profile is the UTS #46 lookup profile.
+// The STD3 rules and the hyphen checks are disabled to allow the underscore labels (eg.: "_dmarc") and
+// the valid ASCII labels with hyphens in the 3. and 4. position (eg.: "r3---sn-example").
+var profile = idna.New(
+	idna.MapForLookup(),
+	idna.BidiRule(),
+	idna.Transitional(false),
+	idna.StrictDomainName(false),
+	idna.CheckHyphens(false),
+	idna.CheckJoiners(true),
+)
+
+// IsASCII returns whether s contains only ASCII characters.
+func IsASCII(s string) bool {
+
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+
+	return true
+}
+
+// HasPunycode returns whether any label of d is an A-label (starts with "xn--").
+func HasPunycode(d string) bool {
+
+	for i := 0; i+4 <= len(d); i++ {
+
+		if i > 0 && d[i-1] != '.' {
+			continue
+		}
+
+		if (d[i] == 'x' || d[i] == 'X') && (d[i+1] == 'n' || d[i+1] == 'N') && d[i+2] == '-' && d[i+3] == '-' {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ToASCII converts domain d to the ASCII form (eg.: "Bücher.example" -> "xn--bcher-kva.example").
+// The Unicode labels are mapped and validated by UTS #46 (eg.: lowercased and normalized).
+// The A-labels in d are validated with ToUnicode().
+// The trailing dot is kept.
+func ToASCII(d string) (string, error) {
+
+	if HasPunycode(d) {
+		if _, err := ToUnicode(d); err != nil {
+			return "", err
+		}
+	}
+
+	r, err := profile.ToASCII(d)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert %s to ASCII: %w", d, err)
+	}
+
+	return r, nil
+}
+
+// ToUnicode converts domain d to the Unicode form for display (eg.: "xn--bcher-kva.example" -> "bücher.example").
+// The A-labels are validated, an A-label must decode to a non-ASCII label (RFC 5890 section 2.3.2.1).
+// The trailing dot is kept.
+func ToUnicode(d string) (string, error) {
+
+	r, err := profile.ToUnicode(d)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert %s to Unicode: %w", d, err)
+	}
+
+	ls := strings.Split(d, ".")
+	us := strings.Split(r, ".")
+
+	for i := range ls {
+
+		if i >= len(us) || len(ls[i]) < 4 || !strings.EqualFold(ls[i][:4], "xn--") {
+			continue
+		}
+
+		if us[i] == "" || IsASCII(us[i]) {
+			return "", fmt.Errorf("failed to convert %s to Unicode: invalid A-label: %s", d, ls[i])
+		}
+	}
+
+	return r, nil
+}
+
+// Valid returns whether d is a valid internationalized domain name.
+// The ASCII labels are not checked, only the Unicode and the A-labels.
+func Valid(d string) bool {
+
+	if IsASCII(d) && !HasPunycode(d) {
+		return true
+	}
+
+	_, err := ToASCII(d)
+
+	return err == nil
+}
@@ -0,0 +1,55 @@
+package idn
+
+import "testing"
+
+func TestToASCII(t *testing.T) {
+
+	cases := [][2]string{
+		{"Bücher.example", "xn--bcher-kva.example"},
+		{"bücher.example.", "xn--bcher-kva.example."},
+		{"例え.テスト", "xn--r8jz45g.xn--zckzah"},
+		{"_dmarc.bücher.example", "_dmarc.xn--bcher-kva.example"},
+		{"example.com", "example.com"},
+	}
+
+	for i := range cases {
+
+		r, err := ToASCII(cases[i][0])
+		if err != nil {
+			t.Fatalf("FAIL: %s: %s\n", cases[i][0], err)
+		}
+
+		if r != cases[i][1] {
+			t.Fatalf("FAIL: %s: want %s, got %s\n", cases[i][0], cases[i][1], r)
+		}
+
+		u, err := ToUnicode(r)
+		if err != nil {
+			t.Fatalf("FAIL: %s: %s\n", r, err)
+		}
+
+		if a, _ := ToASCII(u); a != r {
+			t.Fatalf("FAIL: round trip of %s failed: %s -> %s\n", r, u, a)
+		}
+	}
+}
+
+func TestValid(t *testing.T) {
+
+	cases := []struct {
+		Domain string
+		Result bool
+	}{
+		{"bücher.example", true},
+		{"xn--bcher-kva.example", true},
+		{"xn--invalid-.example", false},
+		{"a‍b.example", false}, // ZWJ without context
+		{"example.com", true},
+	}
+
+	for i := range cases {
+		if r := Valid(cases[i].Domain); r != cases[i].Result {
+			t.Fatalf("FAIL: %s: want %v, got %v\n", cases[i].Domain, cases[i].Result, r)
+		}
+	}
+}
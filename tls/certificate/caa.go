@@ -0,0 +1,105 @@
+package certificate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/elmasy-com/elnet/dns"
+)
+
+var ErrUnknownIssuer = errors.New("unknown issuer")
+
+// CAADomains maps the issuer organizations to the CAA issuer domain names of the CA.
+var CAADomains = map[string][]string{
+	"Let's Encrypt":                  {"letsencrypt.org"},
+	"Google Trust Services LLC":      {"pki.goog"},
+	"Google Trust Services":          {"pki.goog"},
+	"DigiCert Inc":                   {"digicert.com", "symantec.com", "geotrust.com", "rapidssl.com", "thawte.com"},
+	"DigiCert, Inc.":                 {"digicert.com", "symantec.com", "geotrust.com", "rapidssl.com", "thawte.com"},
+	"Sectigo Limited":                {"sectigo.com", "comodoca.com", "comodo.com", "usertrust.com", "trust-provider.com"},
+	"ZeroSSL":                        {"sectigo.com", "zerossl.com"},
+	"Amazon":                         {"amazon.com", "amazontrust.com", "awstrust.com", "amazonaws.com"},
+	"GlobalSign nv-sa":               {"globalsign.com"},
+	"GoDaddy.com, Inc.":              {"godaddy.com", "starfieldtech.com"},
+	"Starfield Technologies, Inc.":   {"starfieldtech.com", "godaddy.com"},
+	"Microsoft Corporation":          {"microsoft.com"},
+	"Buypass AS-983163327":           {"buypass.com", "buypass.no"},
+	"SSL Corporation":                {"ssl.com"},
+	"Entrust, Inc.":                  {"entrust.net"},
+	"IdenTrust":                      {"identrust.com"},
+	"Certainly":                      {"certainly.com"},
+	"Actalis S.p.A.":                 {"actalis.it"},
+	"HARICA":                         {"harica.gr"},
+	"Hellenic Academic and Research": {"harica.gr"},
+}
+
+// CAADomainsOf returns the CAA issuer domain names of the issuer of c.
+// Returns nil if the issuer organization is not in CAADomains.
+func CAADomainsOf(c Cert) []string {
+
+	if v, ok := CAADomains[c.IssuerOrganization]; ok {
+		return v
+	}
+
+	// Try the organizations one by one if the issuer has more
+	for _, o := range strings.Split(c.IssuerOrganization, ", ") {
+		if v, ok := CAADomains[o]; ok {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// CheckCAA returns whether the CAA policy p permits the issuer of c to issue the certificate.
+// Every name is checked against its own property (RFC 8659 section 4):
+// the wildcard name of p.Name (eg.: "*.example.com") with "issuewild", any other name with "issue".
+// If c contains both, both properties must permit the issuer.
+//
+// Returns ErrUnknownIssuer if the issuer organization is not in CAADomains.
+func CheckCAA(c Cert, p *dns.CAAPolicy) (bool, error) {
+
+	cas := CAADomainsOf(c)
+	if cas == nil {
+		return false, fmt.Errorf("%w: %s", ErrUnknownIssuer, c.IssuerOrganization)
+	}
+
+	wildcard := false
+	plain := false
+
+	for i := range c.AlternativeNames {
+		if strings.EqualFold(c.AlternativeNames[i], "*."+p.Name) {
+			wildcard = true
+		} else {
+			plain = true
+		}
+	}
+
+	// No SAN, the policy is checked for the name itself
+	if !wildcard {
+		plain = true
+	}
+
+	if plain && !permitsAny(p, cas, false) {
+		return false, nil
+	}
+
+	if wildcard && !permitsAny(p, cas, true) {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// permitsAny returns whether p permits any of the CA domains cas.
+func permitsAny(p *dns.CAAPolicy, cas []string, wildcard bool) bool {
+
+	for i := range cas {
+		if p.Permits(cas[i], wildcard) {
+			return true
+		}
+	}
+
+	return false
+}
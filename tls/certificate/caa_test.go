@@ -0,0 +1,60 @@
+package certificate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/elmasy-com/elnet/dns"
+)
+
+func TestCheckCAA(t *testing.T) {
+
+	c := Cert{CommonName: "example.com", AlternativeNames: []string{"example.com"}, IssuerOrganization: "Let's Encrypt"}
+
+	p := dns.NewCAAPolicy("example.com", "example.com", []dns.CAA{{Tag: "issue", Value: "letsencrypt.org"}})
+
+	ok, err := CheckCAA(c, p)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	if !ok {
+		t.Fatalf("FAIL: Let's Encrypt must be permitted\n")
+	}
+
+	p = dns.NewCAAPolicy("example.com", "example.com", []dns.CAA{{Tag: "issue", Value: "pki.goog"}})
+
+	if ok, _ := CheckCAA(c, p); ok {
+		t.Fatalf("FAIL: Let's Encrypt must not be permitted\n")
+	}
+
+	// issue denies, issuewild allows
+	p = dns.NewCAAPolicy("example.com", "example.com", []dns.CAA{{Tag: "issue", Value: "pki.goog"}, {Tag: "issuewild", Value: "letsencrypt.org"}})
+
+	c.AlternativeNames = []string{"example.com", "*.example.com"}
+
+	if ok, _ := CheckCAA(c, p); ok {
+		t.Fatalf("FAIL: the apex must be checked with issue\n")
+	}
+
+	c.AlternativeNames = []string{"*.example.com"}
+
+	if ok, _ := CheckCAA(c, p); !ok {
+		t.Fatalf("FAIL: the wildcard must be permitted with issuewild\n")
+	}
+
+	// issue allows, issuewild denies
+	p = dns.NewCAAPolicy("example.com", "example.com", []dns.CAA{{Tag: "issue", Value: "letsencrypt.org"}, {Tag: "issuewild", Value: ";"}})
+
+	c.AlternativeNames = []string{"example.com", "*.example.com"}
+
+	if ok, _ := CheckCAA(c, p); ok {
+		t.Fatalf("FAIL: the wildcard must be checked with issuewild\n")
+	}
+
+	c.IssuerOrganization = "Unknown CA"
+
+	if _, err := CheckCAA(c, p); !errors.Is(err, ErrUnknownIssuer) {
+		t.Fatalf("FAIL: want ErrUnknownIssuer, got %v\n", err)
+	}
+}
@@ -2,6 +2,7 @@ package certificate
 
 import (
 	"bytes"
+	"context"
 	"crypto"
 	"crypto/sha256"
 	"crypto/x509"
@@ -13,7 +14,8 @@ import (
 	"strings"
 	"time"
 
-	etls "github.com/elmasy-com/elmasy/pkg/protocols/tls"
+	"github.com/elmasy-com/elnet/ctlog"
+	etls "github.com/elmasy-com/elnet/tls"
 	"golang.org/x/crypto/ocsp"
 )
 
@@ -41,33 +43,47 @@ type Cert struct {
 	PublicKey          PubKey
 	SerialNumber       *big.Int
 	Issuer             string
+	IssuerOrganization string
 	NotBefore          time.Time
 	NotAfter           time.Time
 	Verified           bool
 	VerifiedError      error // This is set if Verified == false
 	Chain              []Additional
+	CT                 *ctlog.SCTReport // SCTs and CT policy compliance, nil if CTError is set
+	CTError            error
 }
 
 // Ordered by usage
 var tlsVersions = []string{"tls12", "tls13", "tls11", "tls10", "ssl30"}
 
-// Get the certificate from network://ip:port without verification.
-//
-// Servername is used for SNI.
-func Get(network, ip, port string, timeout time.Duration, servername string) ([]x509.Certificate, error) {
+// handshake returns the result of the first successful handshake with network://ip:port.
+func handshake(network, ip, port string, timeout time.Duration, servername string) (etls.TLS, error) {
 
 	for i := range tlsVersions {
 		r, err := etls.Handshake(tlsVersions[i], network, ip, port, timeout, servername)
 		if err != nil {
-			return nil, err
+			return r, err
 		}
 
 		if r.Supported {
-			return r.Certificates, nil
+			return r, nil
 		}
 	}
 
-	return nil, fmt.Errorf("TLS not supported")
+	return etls.TLS{}, fmt.Errorf("TLS not supported")
+}
+
+// Get the certificate from network://ip:port without verification.
+//
+// Servername is used for SNI.
+func Get(network, ip, port string, timeout time.Duration, servername string) ([]x509.Certificate, error) {
+
+	r, err := handshake(network, ip, port, timeout, servername)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Certificates, nil
 }
 
 func verifyOCSP(leaf x509.Certificate, issuer x509.Certificate) error {
@@ -177,6 +193,7 @@ func parseLeafCert(cert x509.Certificate, r *Cert) {
 	r.SerialNumber = cert.SerialNumber
 
 	r.Issuer = cert.Issuer.CommonName
+	r.IssuerOrganization = strings.Join(cert.Issuer.Organization, ", ")
 
 	r.NotBefore = cert.NotBefore
 	r.NotAfter = cert.NotAfter
@@ -200,6 +217,7 @@ func parseChain(certs []x509.Certificate, r *Cert) {
 }
 
 // Scan get, verify and parse certificate on the certificate from network://ip:port.
+// The SCTs are verified with VerifySCTs(), timeout limits the wait for the download of the log list.
 func Scan(network, ip, port string, timeout time.Duration, servername string) (Cert, error) {
 
 	if servername == "" {
@@ -208,11 +226,13 @@ func Scan(network, ip, port string, timeout time.Duration, servername string) (C
 
 	result := Cert{}
 
-	certs, err := Get(network, ip, port, timeout, servername)
+	r, err := handshake(network, ip, port, timeout, servername)
 	if err != nil {
 		return result, err
 	}
 
+	certs := r.Certificates
+
 	if len(certs) == 0 {
 		return result, fmt.Errorf("no certificate")
 	}
@@ -223,6 +243,11 @@ func Scan(network, ip, port string, timeout time.Duration, servername string) (C
 		result.VerifiedError = err
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	result.CT, result.CTError = VerifySCTsContext(ctx, certs, r.SignedCertificateTimestamps, r.OCSPResponse)
+
 	parseLeafCert(certs[0], &result)
 
 	if len(certs) > 1 {
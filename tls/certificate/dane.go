@@ -0,0 +1,360 @@
+package certificate
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/elmasy-com/elnet/dns"
+)
+
+var (
+	ErrTLSANotMatched = errors.New("certificate association data not matched")
+	ErrTLSAUnusable   = errors.New("unusable TLSA record")
+)
+
+// TLSAMatch is the result of a TLSA record evaluation.
+type TLSAMatch struct {
+	Record  dns.TLSA
+	Matched bool  // The record matched the chain and the requirements of the usage are met
+	Depth   int   // Index of the matched certificate in the chain (0 is the leaf), len(chain) if the trust anchor is only in the record, -1 if not matched
+	Err     error // The reason of the failure, nil if Matched
+}
+
+// DANEResult is the result of a DANE verification.
+type DANEResult struct {
+	Name    string             // Owner name of the TLSA records (eg.: "_25._tcp.mx.example.com")
+	Secure  bool               // The TLSA records are validated with DNSSEC
+	Chain   []x509.Certificate // The certificate chain of the server
+	Matches []TLSAMatch        // The evaluated records
+	Valid   bool               // The records are secure and at least one record matched
+}
+
+// TLSAAssociation returns the certificate association data of cert with the given selector and matching type.
+func TLSAAssociation(cert *x509.Certificate, selector uint8, matchingType uint8) ([]byte, error) {
+
+	var data []byte
+
+	switch selector {
+	case dns.TLSASelectorCert:
+		data = cert.Raw
+	case dns.TLSASelectorSPKI:
+		data = cert.RawSubjectPublicKeyInfo
+	default:
+		return nil, fmt.Errorf("%w: invalid selector: %d", ErrTLSAUnusable, selector)
+	}
+
+	switch matchingType {
+	case dns.TLSAMatchingFull:
+		return data, nil
+	case dns.TLSAMatchingSHA256:
+		h := sha256.Sum256(data)
+		return h[:], nil
+	case dns.TLSAMatchingSHA512:
+		h := sha512.Sum512(data)
+		return h[:], nil
+	default:
+		return nil, fmt.Errorf("%w: invalid matching type: %d", ErrTLSAUnusable, matchingType)
+	}
+}
+
+// matchCert returns whether cert matches the association data of r.
+func matchCert(r dns.TLSA, data []byte, cert *x509.Certificate) (bool, error) {
+
+	v, err := TLSAAssociation(cert, r.Selector, r.MatchingType)
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(v, data), nil
+}
+
+// verifyTA verifies that leaf chains up to the trust anchor ta through intermediates and is valid for servername.
+func verifyTA(leaf, ta *x509.Certificate, intermediates []x509.Certificate, servername string) error {
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ta)
+
+	inter := x509.NewCertPool()
+	for i := range intermediates {
+		inter.AddCert(&intermediates[i])
+	}
+
+	_, err := leaf.Verify(x509.VerifyOptions{DNSName: servername, Roots: roots, Intermediates: inter})
+
+	return err
+}
+
+// MatchTLSA evaluates the TLSA record r against the certificate chain certs (the leaf is the first).
+//
+// The usages are evaluated by RFC 7671:
+//   - PKIX-TA (0): a CA certificate in the PKIX validated chain must match
+//   - PKIX-EE (1): the leaf must match and the chain must pass the PKIX validation
+//   - DANE-TA (2): a certificate in the chain must match and the leaf must chain up to it, the name is checked.
+//     If no certificate in the chain is usable, a full certificate in the record (selector 0, matching type 0) is used as the trust anchor
+//   - DANE-EE (3): the leaf must match, the name and the validity period are not checked
+func MatchTLSA(r dns.TLSA, certs []x509.Certificate, servername string) TLSAMatch {
+
+	m := TLSAMatch{Record: r, Depth: -1}
+
+	if len(certs) == 0 {
+		m.Err = fmt.Errorf("zero certificate given")
+		return m
+	}
+
+	data, err := hex.DecodeString(r.Certificate)
+	if err != nil {
+		m.Err = fmt.Errorf("%w: invalid certificate association data: %s", ErrTLSAUnusable, err)
+		return m
+	}
+
+	leaf := &certs[0]
+
+	intermediates := x509.NewCertPool()
+	for i := 1; i < len(certs); i++ {
+		intermediates.AddCert(&certs[i])
+	}
+
+	switch r.Usage {
+	case dns.TLSAUsageDANEEE, dns.TLSAUsagePKIXEE:
+
+		ok, err := matchCert(r, data, leaf)
+		if err != nil {
+			m.Err = err
+			return m
+		}
+
+		if !ok {
+			m.Err = ErrTLSANotMatched
+			return m
+		}
+
+		if r.Usage == dns.TLSAUsagePKIXEE {
+			if _, err := leaf.Verify(x509.VerifyOptions{DNSName: servername, Intermediates: intermediates}); err != nil {
+				m.Err = fmt.Errorf("PKIX validation failed: %w", err)
+				return m
+			}
+		}
+
+		m.Depth = 0
+
+	case dns.TLSAUsageDANETA:
+
+		var verifyErr error
+
+		for i := 1; i < len(certs) && m.Depth == -1; i++ {
+
+			ok, err := matchCert(r, data, &certs[i])
+			if err != nil {
+				m.Err = err
+				return m
+			}
+
+			if !ok {
+				continue
+			}
+
+			// A matched certificate can fail (eg.: expired cross-signed CA with the same key), try the next one
+			if verifyErr = verifyTA(leaf, &certs[i], certs[1:i], servername); verifyErr == nil {
+				m.Depth = i
+			}
+		}
+
+		// The trust anchor is not in the chain, but the full certificate is in the record (RFC 7671 section 5.2.2)
+		if m.Depth == -1 && r.Selector == dns.TLSASelectorCert && r.MatchingType == dns.TLSAMatchingFull {
+
+			ta, err := x509.ParseCertificate(data)
+			if err != nil {
+				m.Err = fmt.Errorf("%w: invalid trust anchor certificate: %s", ErrTLSAUnusable, err)
+				return m
+			}
+
+			if verifyErr = verifyTA(leaf, ta, certs[1:], servername); verifyErr == nil {
+				m.Depth = len(certs)
+			}
+		}
+
+		if m.Depth == -1 {
+			if verifyErr != nil {
+				m.Err = fmt.Errorf("failed to chain up to the trust anchor: %w", verifyErr)
+			} else {
+				m.Err = ErrTLSANotMatched
+			}
+			return m
+		}
+
+	case dns.TLSAUsagePKIXTA:
+
+		chains, err := leaf.Verify(x509.VerifyOptions{DNSName: servername, Intermediates: intermediates})
+		if err != nil {
+			m.Err = fmt.Errorf("PKIX validation failed: %w", err)
+			return m
+		}
+
+		for _, chain := range chains {
+			for i := 1; i < len(chain) && m.Depth == -1; i++ {
+
+				ok, err := matchCert(r, data, chain[i])
+				if err != nil {
+					m.Err = err
+					return m
+				}
+
+				if ok {
+					m.Depth = i
+				}
+			}
+		}
+
+		if m.Depth == -1 {
+			m.Err = ErrTLSANotMatched
+			return m
+		}
+
+	default:
+		m.Err = fmt.Errorf("%w: invalid usage: %d", ErrTLSAUnusable, r.Usage)
+		return m
+	}
+
+	m.Matched = true
+
+	return m
+}
+
+// VerifyTLSA evaluates every record in records against the certificate chain certs.
+// See MatchTLSA() for more.
+func VerifyTLSA(records []dns.TLSA, certs []x509.Certificate, servername string) []TLSAMatch {
+
+	ms := make([]TLSAMatch, 0, len(records))
+
+	for i := range records {
+		ms = append(ms, MatchTLSA(records[i], certs, servername))
+	}
+
+	return ms
+}
+
+// verifyDANE evaluates set against certs.
+// If smtp is true, the PKIX usages are unusable (RFC 7672 section 3.1.3).
+func verifyDANE(set *dns.TLSASet, certs []x509.Certificate, servername string, smtp bool) DANEResult {
+
+	r := DANEResult{Name: set.Name, Secure: set.Secure, Chain: certs}
+
+	for i := range set.Records {
+
+		if smtp && (set.Records[i].Usage == dns.TLSAUsagePKIXTA || set.Records[i].Usage == dns.TLSAUsagePKIXEE) {
+			r.Matches = append(r.Matches, TLSAMatch{Record: set.Records[i], Depth: -1, Err: fmt.Errorf("%w: PKIX usage with SMTP", ErrTLSAUnusable)})
+			continue
+		}
+
+		m := MatchTLSA(set.Records[i], certs, servername)
+
+		if m.Matched && r.Secure {
+			r.Valid = true
+		}
+
+		r.Matches = append(r.Matches, m)
+	}
+
+	return r
+}
+
+// DANE gets the certificate chain from network://ip:port with Get() and verifies it against the TLSA records of servername.
+// The TLSA records are queried with servers, see dns.Servers.GetTLSA() for the DNSSEC requirements.
+//
+// Returns an error if the TLSA records can not be queried or the certificates can not be get.
+func DANE(network, ip, port string, timeout time.Duration, servername string, servers *dns.Servers) (DANEResult, error) {
+
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return DANEResult{}, fmt.Errorf("invalid port: %s", port)
+	}
+
+	set, err := servers.GetTLSA(p, network, servername)
+	if err != nil {
+		return DANEResult{}, fmt.Errorf("failed to get TLSA: %w", err)
+	}
+
+	certs, err := Get(network, ip, port, timeout, servername)
+	if err != nil {
+		return DANEResult{Name: set.Name, Secure: set.Secure}, fmt.Errorf("failed to get certificates: %w", err)
+	}
+
+	return verifyDANE(set, certs, servername, false), nil
+}
+
+// GetSMTP gets the certificate chain from the SMTP server at ip:port with STARTTLS without verification.
+//
+// Servername is used for SNI and in the EHLO.
+func GetSMTP(ip, port string, timeout time.Duration, servername string) ([]x509.Certificate, error) {
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, port), timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	c, err := smtp.NewClient(conn, servername)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SMTP client: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); !ok {
+		return nil, fmt.Errorf("STARTTLS not supported")
+	}
+
+	if err := c.StartTLS(&tls.Config{ServerName: servername, InsecureSkipVerify: true}); err != nil {
+		return nil, fmt.Errorf("failed to STARTTLS: %w", err)
+	}
+
+	state, ok := c.TLSConnectionState()
+	if !ok {
+		return nil, fmt.Errorf("no TLS connection state")
+	}
+
+	certs := make([]x509.Certificate, 0, len(state.PeerCertificates))
+
+	for i := range state.PeerCertificates {
+		certs = append(certs, *state.PeerCertificates[i])
+	}
+
+	c.Quit()
+
+	return certs, nil
+}
+
+// DANESMTP gets the certificate chain from the SMTP server at ip:port with STARTTLS and verifies it against the TLSA records of servername (the MX host).
+// The PKIX-TA and PKIX-EE usages are unusable with SMTP (RFC 7672).
+//
+// The TLSA records are queried with servers, see dns.Servers.GetTLSA() for the DNSSEC requirements.
+func DANESMTP(ip, port string, timeout time.Duration, servername string, servers *dns.Servers) (DANEResult, error) {
+
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return DANEResult{}, fmt.Errorf("invalid port: %s", port)
+	}
+
+	set, err := servers.GetTLSA(p, "tcp", servername)
+	if err != nil {
+		return DANEResult{}, fmt.Errorf("failed to get TLSA: %w", err)
+	}
+
+	certs, err := GetSMTP(ip, port, timeout, servername)
+	if err != nil {
+		return DANEResult{Name: set.Name, Secure: set.Secure}, fmt.Errorf("failed to get certificates: %w", err)
+	}
+
+	return verifyDANE(set, certs, servername, true), nil
+}
@@ -0,0 +1,233 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/elmasy-com/elnet/dns"
+)
+
+// testChain creates a leaf certificate for name signed by a self-signed CA.
+func testChain(t *testing.T, name string) []x509.Certificate {
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA", Organization: []string{"Let's Encrypt"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	leafTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTmpl, ca, &leafKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	leaf, err := x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	return []x509.Certificate{*leaf, *ca}
+}
+
+func TestMatchTLSA(t *testing.T) {
+
+	certs := testChain(t, "mx.example.com")
+
+	leafSPKI := sha256.Sum256(certs[0].RawSubjectPublicKeyInfo)
+	leafFull := sha512.Sum512(certs[0].Raw)
+	caCert := sha256.Sum256(certs[1].Raw)
+
+	cases := []struct {
+		record     dns.TLSA
+		servername string
+		matched    bool
+		depth      int
+	}{
+		{dns.TLSA{Usage: 3, Selector: 1, MatchingType: 1, Certificate: hex.EncodeToString(leafSPKI[:])}, "mx.example.com", true, 0},
+		{dns.TLSA{Usage: 3, Selector: 0, MatchingType: 2, Certificate: hex.EncodeToString(leafFull[:])}, "other.example.com", true, 0},
+		{dns.TLSA{Usage: 3, Selector: 0, MatchingType: 0, Certificate: hex.EncodeToString(certs[0].Raw)}, "mx.example.com", true, 0},
+		{dns.TLSA{Usage: 2, Selector: 0, MatchingType: 1, Certificate: hex.EncodeToString(caCert[:])}, "mx.example.com", true, 1},
+		{dns.TLSA{Usage: 2, Selector: 0, MatchingType: 1, Certificate: hex.EncodeToString(caCert[:])}, "other.example.com", false, -1},
+		{dns.TLSA{Usage: 3, Selector: 1, MatchingType: 1, Certificate: hex.EncodeToString(caCert[:])}, "mx.example.com", false, -1},
+		// The test CA is not trusted by the system
+		{dns.TLSA{Usage: 1, Selector: 1, MatchingType: 1, Certificate: hex.EncodeToString(leafSPKI[:])}, "mx.example.com", false, -1},
+		{dns.TLSA{Usage: 0, Selector: 0, MatchingType: 1, Certificate: hex.EncodeToString(caCert[:])}, "mx.example.com", false, -1},
+	}
+
+	for i := range cases {
+
+		m := MatchTLSA(cases[i].record, certs, cases[i].servername)
+
+		if m.Matched != cases[i].matched || m.Depth != cases[i].depth {
+			t.Fatalf("FAIL: case %d (%s): want %v/%d, got %v/%d (%v)\n", i, cases[i].record, cases[i].matched, cases[i].depth, m.Matched, m.Depth, m.Err)
+		}
+	}
+
+	m := MatchTLSA(dns.TLSA{Usage: 3, Selector: 2, MatchingType: 1, Certificate: "00"}, certs, "mx.example.com")
+	if !errors.Is(m.Err, ErrTLSAUnusable) {
+		t.Fatalf("FAIL: invalid selector must be unusable, got %v\n", m.Err)
+	}
+}
+
+func TestVerifyDANE(t *testing.T) {
+
+	certs := testChain(t, "mx.example.com")
+
+	leafSPKI := sha256.Sum256(certs[0].RawSubjectPublicKeyInfo)
+
+	set := &dns.TLSASet{
+		Name:   "_25._tcp.mx.example.com",
+		Secure: true,
+		Records: []dns.TLSA{
+			{Usage: 1, Selector: 1, MatchingType: 1, Certificate: hex.EncodeToString(leafSPKI[:])},
+			{Usage: 3, Selector: 1, MatchingType: 1, Certificate: hex.EncodeToString(leafSPKI[:])},
+		},
+	}
+
+	r := verifyDANE(set, certs, "mx.example.com", true)
+
+	if !r.Valid || len(r.Matches) != 2 || !errors.Is(r.Matches[0].Err, ErrTLSAUnusable) || !r.Matches[1].Matched {
+		t.Fatalf("FAIL: invalid result: %#v\n", r)
+	}
+
+	set.Secure = false
+
+	if r = verifyDANE(set, certs, "mx.example.com", true); r.Valid {
+		t.Fatalf("FAIL: insecure records must not be valid\n")
+	}
+}
+
+// testCert creates a certificate from tmpl signed by parent (self-signed if parent is nil).
+func testCert(t *testing.T, tmpl, parent *x509.Certificate, pub *ecdsa.PublicKey, key *ecdsa.PrivateKey) *x509.Certificate {
+
+	if parent == nil {
+		parent = tmpl
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, pub, key)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	return cert
+}
+
+func TestMatchTLSADANETA(t *testing.T) {
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	ca := testCert(t, caTmpl, nil, &caKey.PublicKey, caKey)
+
+	// The same CA key and subject, but expired
+	caTmpl.SerialNumber = big.NewInt(2)
+	caTmpl.NotBefore = time.Now().Add(-48 * time.Hour)
+	caTmpl.NotAfter = time.Now().Add(-24 * time.Hour)
+
+	expired := testCert(t, caTmpl, nil, &caKey.PublicKey, caKey)
+
+	leaf := testCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "mx.example.com"},
+		DNSNames:     []string{"mx.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, &leafKey.PublicKey, caKey)
+
+	caSPKI := sha256.Sum256(ca.RawSubjectPublicKeyInfo)
+
+	cases := []struct {
+		name    string
+		record  dns.TLSA
+		certs   []x509.Certificate
+		matched bool
+		depth   int
+	}{
+		{"TA only in the record", dns.TLSA{Usage: 2, Selector: 0, MatchingType: 0, Certificate: hex.EncodeToString(ca.Raw)}, []x509.Certificate{*leaf}, true, 1},
+		{"TA in the chain and in the record", dns.TLSA{Usage: 2, Selector: 0, MatchingType: 0, Certificate: hex.EncodeToString(ca.Raw)}, []x509.Certificate{*leaf, *ca}, true, 1},
+		{"expired TA only in the record", dns.TLSA{Usage: 2, Selector: 0, MatchingType: 0, Certificate: hex.EncodeToString(expired.Raw)}, []x509.Certificate{*leaf}, false, -1},
+		{"hashed TA not in the chain", dns.TLSA{Usage: 2, Selector: 1, MatchingType: 1, Certificate: hex.EncodeToString(caSPKI[:])}, []x509.Certificate{*leaf}, false, -1},
+		{"first match fails", dns.TLSA{Usage: 2, Selector: 1, MatchingType: 1, Certificate: hex.EncodeToString(caSPKI[:])}, []x509.Certificate{*leaf, *expired, *ca}, true, 2},
+		{"every match fails", dns.TLSA{Usage: 2, Selector: 1, MatchingType: 1, Certificate: hex.EncodeToString(caSPKI[:])}, []x509.Certificate{*leaf, *expired}, false, -1},
+	}
+
+	for _, c := range cases {
+
+		m := MatchTLSA(c.record, c.certs, "mx.example.com")
+
+		if m.Matched != c.matched || m.Depth != c.depth {
+			t.Fatalf("FAIL: %s: want %v/%d, got %v/%d (%v)\n", c.name, c.matched, c.depth, m.Matched, m.Depth, m.Err)
+		}
+	}
+
+	m := MatchTLSA(dns.TLSA{Usage: 2, Selector: 0, MatchingType: 0, Certificate: "00"}, []x509.Certificate{*leaf}, "mx.example.com")
+	if !errors.Is(m.Err, ErrTLSAUnusable) {
+		t.Fatalf("FAIL: invalid trust anchor must be unusable, got %v\n", m.Err)
+	}
+}
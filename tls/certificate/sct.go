@@ -0,0 +1,245 @@
+package certificate
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/elmasy-com/elnet/ctlog"
+	gct "github.com/google/certificate-transparency-go"
+	"golang.org/x/crypto/ocsp"
+)
+
+// ErrNoLogList is returned by VerifySCTs() if neither the log list nor the key of the log list is set.
+var ErrNoLogList = errors.New("no log list, use SetLogList() or SetLogListKey()")
+
+var (
+	// LogListRefresh is the age of the downloaded log list after it is downloaded again.
+	// The old list is used until the new one is ready.
+	LogListRefresh = 24 * time.Hour
+
+	// LogListRetry is the time to wait after a failed download, the error is returned until it is elapsed.
+	LogListRetry = 5 * time.Minute
+)
+
+var (
+	logListURL    = ctlog.LogListURL
+	logListSigURL = ctlog.LogListSignatureURL
+
+	logList        *ctlog.LogList
+	logListKey     crypto.PublicKey
+	logListFetched time.Time     // Time of the download, zero if the list is set by SetLogList()
+	logListErr     error         // Error of the last failed download
+	logListRetry   time.Time     // The failed download is not retried before
+	logListLoading chan struct{} // Closed when the running download is done, nil if no download runs
+	logListM       sync.Mutex
+)
+
+// SetLogList sets the log list used to verify the SCTs.
+// The list is not refreshed.
+func SetLogList(l *ctlog.LogList) {
+
+	logListM.Lock()
+	defer logListM.Unlock()
+
+	logList = l
+	logListFetched = time.Time{}
+}
+
+// SetLogListKey enables the download of the log list from ctlog.LogListURL and sets the public key to verify its signature.
+// The list is downloaded at the first use and refreshed after LogListRefresh.
+//
+// The key should come from a trusted source (eg.: embedded into the binary), not from the origin of the list.
+func SetLogListKey(key crypto.PublicKey) {
+
+	logListM.Lock()
+	defer logListM.Unlock()
+
+	logListKey = key
+	logListErr = nil
+	logListRetry = time.Time{}
+}
+
+// loadLogList downloads the log list, verifies it with key and closes done.
+func loadLogList(key crypto.PublicKey, done chan struct{}) {
+
+	l, err := ctlog.FetchLogListContext(context.Background(), nil, logListURL, logListSigURL, key)
+
+	logListM.Lock()
+	defer logListM.Unlock()
+
+	defer close(done)
+
+	logListLoading = nil
+
+	if err != nil {
+		logListErr = fmt.Errorf("failed to get log list: %w", err)
+		logListRetry = time.Now().Add(LogListRetry)
+		return
+	}
+
+	// Do not overwrite the list set by SetLogList() during the download
+	if logList != nil && logListFetched.IsZero() {
+		return
+	}
+
+	logList = l
+	logListFetched = time.Now()
+	logListErr = nil
+}
+
+// startLoadLogList starts the download of the log list if no download is running and returns the channel closed when it is done.
+// logListM must be held.
+func startLoadLogList() chan struct{} {
+
+	if logListLoading == nil {
+		logListLoading = make(chan struct{})
+		go loadLogList(logListKey, logListLoading)
+	}
+
+	return logListLoading
+}
+
+// getLogList returns the log list.
+// If the list is not set with SetLogList(), downloads and verifies it with the key set by SetLogListKey().
+// Returns ErrNoLogList if no list and no key is set.
+//
+// The download runs in the background, ctx limits only the wait for it.
+// A failed download is not retried for LogListRetry, the error is returned immediately.
+// The static list (ctlog.DefaultLogList()) is not used as a fallback, the states of its logs are outdated.
+func getLogList(ctx context.Context) (*ctlog.LogList, error) {
+
+	logListM.Lock()
+
+	now := time.Now()
+
+	if logList != nil {
+
+		// Refresh in the background, the old list is used until the new one is ready
+		if !logListFetched.IsZero() && logListKey != nil && now.Sub(logListFetched) > LogListRefresh && !now.Before(logListRetry) {
+			startLoadLogList()
+		}
+
+		l := logList
+
+		logListM.Unlock()
+
+		return l, nil
+	}
+
+	if logListKey == nil {
+		logListM.Unlock()
+		return nil, ErrNoLogList
+	}
+
+	if logListLoading == nil && now.Before(logListRetry) {
+		err := logListErr
+		logListM.Unlock()
+		return nil, err
+	}
+
+	done := startLoadLogList()
+
+	logListM.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to get log list: %w", ctx.Err())
+	case <-done:
+	}
+
+	logListM.Lock()
+	defer logListM.Unlock()
+
+	if logList == nil {
+		return nil, logListErr
+	}
+
+	return logList, nil
+}
+
+// parseSCTs parses the serialized SCTs sent in the signed_certificate_timestamp TLS extension.
+func parseSCTs(scts [][]byte) ([]*gct.SignedCertificateTimestamp, error) {
+
+	r := make([]*gct.SignedCertificateTimestamp, 0, len(scts))
+
+	for i := range scts {
+
+		sct, err := ctlog.ParseSCT(scts[i])
+		if err != nil {
+			return nil, err
+		}
+
+		r = append(r, sct)
+	}
+
+	return r, nil
+}
+
+// parseOCSPSCTs parses the SCTs from the single extension of the stapled OCSP response.
+// Returns nil if resp is empty or has no SCT extension.
+func parseOCSPSCTs(resp []byte) ([]*gct.SignedCertificateTimestamp, error) {
+
+	if len(resp) == 0 {
+		return nil, nil
+	}
+
+	r, err := ocsp.ParseResponse(resp, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OCSP response: %w", err)
+	}
+
+	for i := range r.Extensions {
+		if r.Extensions[i].Id.Equal(ctlog.OIDOCSPSCTList) {
+			return ctlog.ParseSCTListExtension(r.Extensions[i].Value)
+		}
+	}
+
+	return nil, nil
+}
+
+// VerifySCTs verifies the SCTs of the certificate chain delivered via every path (embedded, TLS extension and stapled OCSP)
+// and checks the compliance with the Chrome and Apple CT policies.
+// tlsSCTs is the serialized SCTs of the signed_certificate_timestamp extension, ocspResp is the stapled OCSP response.
+//
+// The logs are set with SetLogList() or downloaded after SetLogListKey().
+// Returns error if the log list can not be loaded (eg.: ErrNoLogList), the policies are not evaluated without a current list.
+func VerifySCTs(certs []x509.Certificate, tlsSCTs [][]byte, ocspResp []byte) (*ctlog.SCTReport, error) {
+
+	return VerifySCTsContext(context.Background(), certs, tlsSCTs, ocspResp)
+}
+
+// VerifySCTsContext is VerifySCTs with a context to limit the wait for the download of the log list.
+func VerifySCTsContext(ctx context.Context, certs []x509.Certificate, tlsSCTs [][]byte, ocspResp []byte) (*ctlog.SCTReport, error) {
+
+	if len(certs) < 1 {
+		return nil, fmt.Errorf("zero certificate given")
+	}
+
+	list, err := getLogList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := make([][]byte, len(certs))
+
+	for i := range certs {
+		chain[i] = certs[i].Raw
+	}
+
+	fromTLS, err := parseSCTs(tlsSCTs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TLS SCTs: %w", err)
+	}
+
+	fromOCSP, err := parseOCSPSCTs(ocspResp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OCSP SCTs: %w", err)
+	}
+
+	return list.VerifySCTs(chain, fromTLS, fromOCSP)
+}
@@ -0,0 +1,194 @@
+package certificate
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/elmasy-com/elnet/ctlog"
+)
+
+const testLogList = `{"version":"1.0","log_list_timestamp":"2026-10-01T12:00:00Z","operators":[]}`
+
+// testLogListServer serves a signed log list and counts the requests of the list.
+type testLogListServer struct {
+	sig      []byte
+	fail     bool
+	block    chan struct{} // If not nil, the list is served after it is closed
+	requests int
+	m        sync.Mutex
+}
+
+func (s *testLogListServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	s.m.Lock()
+	fail, block := s.fail, s.block
+	if r.URL.Path == "/log_list.json" {
+		s.requests++
+	}
+	s.m.Unlock()
+
+	if block != nil {
+		<-block
+	}
+
+	switch {
+	case fail:
+		w.WriteHeader(http.StatusInternalServerError)
+	case r.URL.Path == "/log_list.json":
+		w.Write([]byte(testLogList))
+	case r.URL.Path == "/log_list.sig":
+		w.Write(s.sig)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *testLogListServer) count() int {
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.requests
+}
+
+// resetLogList resets the log list state and restores it when the test ends.
+func resetLogList(t *testing.T) {
+
+	reset := func() {
+		logListM.Lock()
+		defer logListM.Unlock()
+
+		logListURL, logListSigURL = ctlog.LogListURL, ctlog.LogListSignatureURL
+		logList, logListKey, logListErr, logListLoading = nil, nil, nil, nil
+		logListFetched, logListRetry = time.Time{}, time.Time{}
+	}
+
+	reset()
+	t.Cleanup(reset)
+}
+
+// waitLogList waits for the running download of the log list.
+func waitLogList() {
+
+	logListM.Lock()
+	done := logListLoading
+	logListM.Unlock()
+
+	if done != nil {
+		<-done
+	}
+}
+
+func TestGetLogList(t *testing.T) {
+
+	resetLogList(t)
+
+	ctx := context.Background()
+
+	// The list is not downloaded without a key
+	if _, err := getLogList(ctx); !errors.Is(err, ErrNoLogList) {
+		t.Fatalf("FAIL: want ErrNoLogList, got %v\n", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	hash := sha256.Sum256([]byte(testLogList))
+
+	sig, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	s := &testLogListServer{sig: sig}
+
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	logListM.Lock()
+	logListURL, logListSigURL = srv.URL+"/log_list.json", srv.URL+"/log_list.sig"
+	logListM.Unlock()
+
+	SetLogListKey(key.Public())
+
+	l, err := getLogList(ctx)
+	if err != nil {
+		t.Fatalf("FAIL: %s\n", err)
+	}
+
+	// Cached
+	if l2, err := getLogList(ctx); err != nil || l2 != l || s.count() != 1 {
+		t.Fatalf("FAIL: want cached list, got %d requests: %v\n", s.count(), err)
+	}
+
+	// The old list is returned during the refresh
+	logListM.Lock()
+	logListFetched = time.Now().Add(-2 * LogListRefresh)
+	logListM.Unlock()
+
+	if l2, err := getLogList(ctx); err != nil || l2 != l {
+		t.Fatalf("FAIL: want the old list during the refresh: %v\n", err)
+	}
+
+	waitLogList()
+
+	if l2, err := getLogList(ctx); err != nil || l2 == l || s.count() != 2 {
+		t.Fatalf("FAIL: want refreshed list, got %d requests: %v\n", s.count(), err)
+	}
+
+	// The failed download is cached
+	logListM.Lock()
+	logList = nil
+	logListM.Unlock()
+
+	s.m.Lock()
+	s.fail = true
+	s.m.Unlock()
+
+	if _, err := getLogList(ctx); err == nil {
+		t.Fatalf("FAIL: failed download returned no error\n")
+	}
+
+	if _, err := getLogList(ctx); err == nil || s.count() != 3 {
+		t.Fatalf("FAIL: want cached error, got %d requests: %v\n", s.count(), err)
+	}
+
+	// The wait is limited by ctx
+	block := make(chan struct{})
+
+	s.m.Lock()
+	s.fail = false
+	s.block = block
+	s.m.Unlock()
+
+	SetLogListKey(key.Public())
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	if _, err := getLogList(canceled); !errors.Is(err, context.Canceled) {
+		t.Fatalf("FAIL: want context.Canceled, got %v\n", err)
+	}
+
+	// The list set during the download is kept
+	set := &ctlog.LogList{}
+	SetLogList(set)
+
+	close(block)
+	waitLogList()
+
+	if l2, err := getLogList(ctx); err != nil || l2 != set {
+		t.Fatalf("FAIL: list set by SetLogList() overwritten: %v\n", err)
+	}
+}
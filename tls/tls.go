@@ -6,31 +6,33 @@ import (
 	"time"
 
 	"github.com/elmasy-com/elmasy/pkg/protocols/tls/ciphersuite"
-	"github.com/elmasy-com/elmasy/pkg/protocols/tls/ssl30"
-	"github.com/elmasy-com/elmasy/pkg/protocols/tls/tls10"
-	"github.com/elmasy-com/elmasy/pkg/protocols/tls/tls11"
-	"github.com/elmasy-com/elmasy/pkg/protocols/tls/tls12"
-	"github.com/elmasy-com/elmasy/pkg/protocols/tls/tls13"
+	"github.com/elmasy-com/elnet/tls/ssl30"
+	"github.com/elmasy-com/elnet/tls/tls10"
+	"github.com/elmasy-com/elnet/tls/tls11"
+	"github.com/elmasy-com/elnet/tls/tls12"
+	"github.com/elmasy-com/elnet/tls/tls13"
 )
 
 type TLS struct {
-	Supported     bool
-	Certificates  []x509.Certificate
-	DefaultCipher ciphersuite.CipherSuite
-	Ciphers       []ciphersuite.CipherSuite
+	Supported                   bool
+	Certificates                []x509.Certificate
+	DefaultCipher               ciphersuite.CipherSuite
+	Ciphers                     []ciphersuite.CipherSuite
+	SignedCertificateTimestamps [][]byte // Serialized SCTs sent in the signed_certificate_timestamp extension (TLS 1.2 and 1.3 only)
+	OCSPResponse                []byte   // Stapled OCSP response (TLS 1.2 and 1.3 only)
 }
 
 func Scan(version, network, ip, port string, timeout time.Duration, servername string) (TLS, error) {
 	switch version {
 	case "ssl30":
 		r, err := ssl30.Scan(network, ip, port, timeout)
-		return TLS(r), err
+		return TLS{Supported: r.Supported, Certificates: r.Certificates, DefaultCipher: r.DefaultCipher, Ciphers: r.Ciphers}, err
 	case "tls10":
 		r, err := tls10.Scan(network, ip, port, timeout, servername)
-		return TLS(r), err
+		return TLS{Supported: r.Supported, Certificates: r.Certificates, DefaultCipher: r.DefaultCipher, Ciphers: r.Ciphers}, err
 	case "tls11":
 		r, err := tls11.Scan(network, ip, port, timeout, servername)
-		return TLS(r), err
+		return TLS{Supported: r.Supported, Certificates: r.Certificates, DefaultCipher: r.DefaultCipher, Ciphers: r.Ciphers}, err
 	case "tls12":
 		r, err := tls12.Scan(network, ip, port, timeout, servername)
 		return TLS(r), err
@@ -47,13 +49,13 @@ func Handshake(version, network, ip, port string, timeout time.Duration, servern
 	switch version {
 	case "ssl30":
 		r, err := ssl30.Handshake(network, ip, port, timeout)
-		return TLS(r), err
+		return TLS{Supported: r.Supported, Certificates: r.Certificates, DefaultCipher: r.DefaultCipher, Ciphers: r.Ciphers}, err
 	case "tls10":
 		r, err := tls10.Handshake(network, ip, port, timeout, servername)
-		return TLS(r), err
+		return TLS{Supported: r.Supported, Certificates: r.Certificates, DefaultCipher: r.DefaultCipher, Ciphers: r.Ciphers}, err
 	case "tls11":
 		r, err := tls11.Handshake(network, ip, port, timeout, servername)
-		return TLS(r), err
+		return TLS{Supported: r.Supported, Certificates: r.Certificates, DefaultCipher: r.DefaultCipher, Ciphers: r.Ciphers}, err
 	case "tls12":
 		r, err := tls12.Handshake(network, ip, port, timeout, servername)
 		return TLS(r), err
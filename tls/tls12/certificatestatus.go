@@ -0,0 +1,56 @@
+package tls12
+
+import (
+	"fmt"
+
+	"github.com/elmasy-com/bytebuilder"
+)
+
+/*
+	enum { ocsp(1), (255) } CertificateStatusType;
+
+	opaque OCSPResponse<1..2^24-1>;
+
+	struct {
+	    CertificateStatusType status_type;
+	    select (status_type) {
+	        case ocsp: OCSPResponse;
+	    } response;
+	} CertificateStatus;
+*/
+
+type certificateStatus struct {
+	OCSPResponse []byte // DER encoded OCSP response
+}
+
+func unmarshalCertificateStatus(bytes []byte) (certificateStatus, error) {
+
+	var (
+		status certificateStatus
+		ok     bool
+		buf    = bytebuilder.NewBuffer(bytes)
+	)
+
+	statusType, ok := buf.ReadUint8()
+	if !ok {
+		return status, fmt.Errorf("failed to read status_type")
+	}
+
+	if statusType != 1 {
+		return status, fmt.Errorf("invalid status_type: %d", statusType)
+	}
+
+	if status.OCSPResponse, ok = buf.ReadVector(24); !ok {
+		return status, fmt.Errorf("failed to read OCSPResponse")
+	}
+
+	if len(status.OCSPResponse) == 0 {
+		return status, fmt.Errorf("empty OCSPResponse")
+	}
+
+	if !buf.Empty() {
+		return status, fmt.Errorf("trailing data after OCSPResponse")
+	}
+
+	return status, nil
+}
@@ -0,0 +1,45 @@
+package tls12
+
+import (
+	"bytes"
+	"testing"
+)
+
+// testOCSPResponse is the beginning of a DER encoded OCSPResponse (SEQUENCE, responseStatus successful).
+const testOCSPResponse = "3003" + "0a0100"
+
+func TestUnmarshalCertificateStatus(t *testing.T) {
+
+	cases := []struct {
+		name  string
+		data  string
+		isErr bool
+	}{
+		{"ocsp", "01" + "000005" + testOCSPResponse, false},
+		{"empty", "", true},
+		{"invalid status_type", "02" + "000005" + testOCSPResponse, true},
+		{"empty OCSPResponse", "01" + "000000", true},
+		{"truncated length", "01" + "0000", true},
+		{"truncated OCSPResponse", "01" + "000006" + testOCSPResponse, true},
+		{"trailing data", "01" + "000005" + testOCSPResponse + "00", true},
+	}
+
+	for _, c := range cases {
+
+		status, err := unmarshalCertificateStatus(fromHex(c.data))
+		if c.isErr {
+			if err == nil {
+				t.Fatalf("FAIL: %s: invalid CertificateStatus accepted\n", c.name)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("FAIL: %s: %s\n", c.name, err)
+		}
+
+		if !bytes.Equal(status.OCSPResponse, fromHex(testOCSPResponse)) {
+			t.Fatalf("FAIL: %s: invalid OCSPResponse: %x\n", c.name, status.OCSPResponse)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package tls12
 
-import "github.com/elmasy-com/bytebuilder"
+import (
+	"fmt"
+
+	"github.com/elmasy-com/bytebuilder"
+)
 
 func marshalExtensions(ServerName string) []byte {
 
@@ -21,6 +25,12 @@ func marshalExtensions(ServerName string) []byte {
 	// extended_master_secret
 	buf.WriteBytes(0x00, 0x17, 0x00, 0x00)
 
+	// status_request (OCSP, without responder IDs and extensions)
+	buf.WriteBytes(0x00, 0x05, 0x00, 0x05, 0x01, 0x00, 0x00, 0x00, 0x00)
+
+	// signed_certificate_timestamp
+	buf.WriteBytes(0x00, 0x12, 0x00, 0x00)
+
 	if ServerName != "" {
 		buf.WriteBytes(marshalExtensionSNI(ServerName)...)
 	}
@@ -41,3 +51,76 @@ func marshalExtensionSNI(ServerName string) []byte {
 
 	return buf.Bytes()
 }
+
+// unmarshalExtensionSCT returns the serialized SCTs of the signed_certificate_timestamp extension from the extensions of the ServerHello.
+// Returns nil if the extension is not present.
+func unmarshalExtensionSCT(bytes []byte) ([][]byte, error) {
+
+	buf := bytebuilder.NewBuffer(bytes)
+
+	for !buf.Empty() {
+
+		extType, ok := buf.ReadUint16()
+		if !ok {
+			return nil, fmt.Errorf("failed to read extension type")
+		}
+
+		data, ok := buf.ReadVector(16)
+		if !ok {
+			return nil, fmt.Errorf("failed to read extension data")
+		}
+
+		if extType == 0x12 {
+			return unmarshalSCTList(data)
+		}
+	}
+
+	return nil, nil
+}
+
+/*
+	opaque SerializedSCT<1..2^16-1>;
+
+	struct {
+	    SerializedSCT sct_list <1..2^16-1>;
+	} SignedCertificateTimestampList;
+*/
+
+func unmarshalSCTList(bytes []byte) ([][]byte, error) {
+
+	var (
+		scts [][]byte
+		buf  = bytebuilder.NewBuffer(bytes)
+	)
+
+	list, ok := buf.ReadVector(16)
+	if !ok {
+		return nil, fmt.Errorf("failed to read sct_list")
+	}
+
+	if len(list) == 0 {
+		return nil, fmt.Errorf("empty sct_list")
+	}
+
+	if !buf.Empty() {
+		return nil, fmt.Errorf("trailing data after sct_list")
+	}
+
+	buf = bytebuilder.NewBuffer(list)
+
+	for !buf.Empty() {
+
+		sct, ok := buf.ReadVector(16)
+		if !ok {
+			return nil, fmt.Errorf("failed to read SerializedSCT")
+		}
+
+		if len(sct) == 0 {
+			return nil, fmt.Errorf("empty SerializedSCT")
+		}
+
+		scts = append(scts, sct)
+	}
+
+	return scts, nil
+}
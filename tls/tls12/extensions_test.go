@@ -0,0 +1,113 @@
+package tls12
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// testSCT is a serialized v1 SCT with an ECDSA P-256 signature (RFC 6962 section 3.2).
+const testSCT = "00d22dcb8eb01f4d4609ffdfaf902a37b96bd047d4e2f41d6607d7e41c5db28166000001a0f4c2c40000000403004830460221009fdb54286e47f1c081d72625fb5b936b56f5e5ffd873cb1843e3854fe68fc3b5022100c205bd1fc6e1baa903f65e0b86948fc62a77a78fb16a3adf755079159a7e705a"
+
+// The SignedCertificateTimestampList with testSCT.
+const testSCTList = "0079" + "0077" + testSCT
+
+// The signed_certificate_timestamp extension with testSCTList.
+const testSCTExtension = "0012" + "007b" + testSCTList
+
+// The renegotiation_info extension.
+const testRenegotiationInfo = "ff01" + "0001" + "00"
+
+func fromHex(s string) []byte {
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+func TestUnmarshalSCTList(t *testing.T) {
+
+	cases := []struct {
+		name  string
+		data  string
+		n     int
+		isErr bool
+	}{
+		{"one SCT", testSCTList, 1, false},
+		{"two SCTs", "00f2" + "0077" + testSCT + "0077" + testSCT, 2, false},
+		{"empty", "", 0, true},
+		{"empty sct_list", "0000", 0, true},
+		{"empty SerializedSCT", "0002" + "0000", 0, true},
+		{"truncated length", "00", 0, true},
+		{"truncated sct_list", testSCTList[:len(testSCTList)-2], 0, true},
+		{"truncated SerializedSCT", "0079" + "0078" + testSCT, 0, true},
+		{"trailing data", testSCTList + "00", 0, true},
+	}
+
+	for _, c := range cases {
+
+		scts, err := unmarshalSCTList(fromHex(c.data))
+		if c.isErr {
+			if err == nil {
+				t.Fatalf("FAIL: %s: invalid list accepted\n", c.name)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("FAIL: %s: %s\n", c.name, err)
+		}
+
+		if len(scts) != c.n {
+			t.Fatalf("FAIL: %s: want %d SCTs, got %d\n", c.name, c.n, len(scts))
+		}
+
+		for i := range scts {
+			if !bytes.Equal(scts[i], fromHex(testSCT)) {
+				t.Fatalf("FAIL: %s: invalid SCT %d: %x\n", c.name, i, scts[i])
+			}
+		}
+	}
+}
+
+func TestUnmarshalExtensionSCT(t *testing.T) {
+
+	cases := []struct {
+		name  string
+		data  string
+		n     int
+		isErr bool
+	}{
+		{"no extension", "", 0, false},
+		{"SCT only", testSCTExtension, 1, false},
+		{"after renegotiation_info", testRenegotiationInfo + testSCTExtension, 1, false},
+		{"without SCT", testRenegotiationInfo, 0, false},
+		{"truncated type", "00", 0, true},
+		{"truncated length", "001200", 0, true},
+		{"truncated data", testSCTExtension[:len(testSCTExtension)-2], 0, true},
+		{"truncated renegotiation_info", testRenegotiationInfo[:len(testRenegotiationInfo)-2], 0, true},
+		{"invalid SCT list", "0012" + "0002" + "0000", 0, true},
+	}
+
+	for _, c := range cases {
+
+		scts, err := unmarshalExtensionSCT(fromHex(c.data))
+		if c.isErr {
+			if err == nil {
+				t.Fatalf("FAIL: %s: invalid extensions accepted\n", c.name)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("FAIL: %s: %s\n", c.name, err)
+		}
+
+		if len(scts) != c.n {
+			t.Fatalf("FAIL: %s: want %d SCTs, got %d\n", c.name, c.n, len(scts))
+		}
+	}
+}
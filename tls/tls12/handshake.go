@@ -12,7 +12,7 @@ import (
 	    certificate(11), server_key_exchange (12),
 	    certificate_request(13), server_hello_done(14),
 	    certificate_verify(15), client_key_exchange(16),
-	    finished(20), (255)
+	    finished(20), certificate_status(22), (255)
 	} HandshakeType;
 
 	struct {
@@ -29,6 +29,7 @@ import (
 	        case certificate_verify: CertificateVerify;
 	        case client_key_exchange: ClientKeyExchange;
 	        case finished: Finished;
+	        case certificate_status: CertificateStatus;
 	    } body;
 	} Handshake;
 */
@@ -104,6 +105,10 @@ func unmarshalHandshake(bytes []byte) ([]interface{}, error) {
 			return messages, fmt.Errorf("handshake type client_key_exchange is not supported")
 		case 20:
 			return messages, fmt.Errorf("handshake type finished is not supported")
+		case 22:
+			if message, err = unmarshalCertificateStatus(body); err != nil {
+				return messages, fmt.Errorf("failed to unmarshal CertificateStatus: %s", err)
+			}
 		default:
 			return messages, fmt.Errorf("unknown Handshake type: %d", msgType)
 		}
@@ -14,7 +14,18 @@ import (
 	    SessionID session_id;
 	    CipherSuite cipher_suite;
 		CompressionMethod compression_method;
+	    select (extensions_present) {
+	        case false:
+	            struct {};
+	        case true:
+	            Extension extensions<0..2^16-1>;
+	    };
 	} ServerHello
+
+	struct {
+	    ExtensionType extension_type;
+	    opaque extension_data<0..2^16-1>;
+	} Extension;
 */
 
 type serverHello struct {
@@ -23,6 +34,7 @@ type serverHello struct {
 	SessionID         []byte
 	CipherSuite       ciphersuite.CipherSuite
 	CompressionMethod uint8
+	SCTs              [][]byte // Serialized SCTs of the signed_certificate_timestamp extension (RFC 6962 section 3.3.1)
 }
 
 func unmarshalServerHello(bytes []byte) (serverHello, error) {
@@ -57,6 +69,23 @@ func unmarshalServerHello(bytes []byte) (serverHello, error) {
 		return hello, fmt.Errorf("failed to read CompressionMethod")
 	}
 
+	if buf.Empty() {
+		return hello, nil
+	}
+
+	exts, ok := buf.ReadVector(16)
+	if !ok {
+		return hello, fmt.Errorf("failed to read Extensions")
+	}
+
+	if !buf.Empty() {
+		return hello, fmt.Errorf("trailing data after Extensions")
+	}
+
+	if hello.SCTs, err = unmarshalExtensionSCT(exts); err != nil {
+		return hello, fmt.Errorf("failed to read Extensions: %s", err)
+	}
+
 	return hello, nil
 }
 
@@ -0,0 +1,61 @@
+package tls12
+
+import (
+	"bytes"
+	"testing"
+)
+
+// The ServerHello without extensions: TLS 1.2, empty session_id, TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 and null compression.
+const testServerHello = "0303" + "6a1b2c3d" + "9f8e7d6c5b4a39281706f5e4d3c2b1a09f8e7d6c5b4a39281706f5e4" + "00" + "c02f" + "00"
+
+func TestUnmarshalServerHello(t *testing.T) {
+
+	exts := testRenegotiationInfo + testSCTExtension
+
+	cases := []struct {
+		name  string
+		data  string
+		n     int
+		isErr bool
+	}{
+		{"no extensions", testServerHello, 0, false},
+		{"empty extensions", testServerHello + "0000", 0, false},
+		{"SCT extension", testServerHello + "0084" + exts, 1, false},
+		{"without SCT extension", testServerHello + "0005" + testRenegotiationInfo, 0, false},
+		{"HTTP response", "5454", 0, true},
+		{"truncated random", testServerHello[:20], 0, true},
+		{"truncated cipher suite", testServerHello[:len(testServerHello)-4], 0, true},
+		{"truncated compression", testServerHello[:len(testServerHello)-2], 0, true},
+		{"truncated extensions length", testServerHello + "00", 0, true},
+		{"truncated extensions", testServerHello + "0084" + exts[:len(exts)-2], 0, true},
+		{"truncated SCT extension", testServerHello + "0083" + exts[:len(exts)-2], 0, true},
+		{"trailing data", testServerHello + "0000" + "00", 0, true},
+	}
+
+	for _, c := range cases {
+
+		hello, err := unmarshalServerHello(fromHex(c.data))
+		if c.isErr {
+			if err == nil {
+				t.Fatalf("FAIL: %s: invalid ServerHello accepted\n", c.name)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("FAIL: %s: %s\n", c.name, err)
+		}
+
+		if hello.CipherSuite.Name != "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256" {
+			t.Fatalf("FAIL: %s: invalid cipher suite: %s\n", c.name, hello.CipherSuite.Name)
+		}
+
+		if len(hello.SCTs) != c.n {
+			t.Fatalf("FAIL: %s: want %d SCTs, got %d\n", c.name, c.n, len(hello.SCTs))
+		}
+
+		if c.n > 0 && !bytes.Equal(hello.SCTs[0], fromHex(testSCT)) {
+			t.Fatalf("FAIL: %s: invalid SCT: %x\n", c.name, hello.SCTs[0])
+		}
+	}
+}
@@ -17,10 +17,12 @@ const (
 )
 
 type TLS12 struct {
-	Supported     bool
-	Certificates  []x509.Certificate
-	DefaultCipher ciphersuite.CipherSuite
-	Ciphers       []ciphersuite.CipherSuite
+	Supported                   bool
+	Certificates                []x509.Certificate
+	DefaultCipher               ciphersuite.CipherSuite
+	Ciphers                     []ciphersuite.CipherSuite
+	SignedCertificateTimestamps [][]byte // Serialized SCTs sent in the signed_certificate_timestamp extension
+	OCSPResponse                []byte   // Stapled OCSP response, nil if not sent
 }
 
 func sendClientHello(conn *net.Conn, timeout time.Duration, ciphers []ciphersuite.CipherSuite, servername string) error {
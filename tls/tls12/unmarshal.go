@@ -32,8 +32,11 @@ func unmarshalResponse(bytes []byte) (TLS12, error) {
 		case serverHello:
 			result.Supported = true
 			result.DefaultCipher = message.CipherSuite
+			result.SignedCertificateTimestamps = message.SCTs
 		case certificate:
 			result.Certificates = message.Certificates
+		case certificateStatus:
+			result.OCSPResponse = message.OCSPResponse
 
 		}
 	}
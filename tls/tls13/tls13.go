@@ -13,10 +13,12 @@ import (
 )
 
 type TLS13 struct {
-	Supported     bool
-	Certificates  []x509.Certificate
-	DefaultCipher ciphersuite.CipherSuite
-	Ciphers       []ciphersuite.CipherSuite
+	Supported                   bool
+	Certificates                []x509.Certificate
+	DefaultCipher               ciphersuite.CipherSuite
+	Ciphers                     []ciphersuite.CipherSuite
+	SignedCertificateTimestamps [][]byte // Serialized SCTs sent in the signed_certificate_timestamp extension
+	OCSPResponse                []byte   // Stapled OCSP response, nil if not sent
 }
 
 func ciphersToUint16(ciphers []ciphersuite.CipherSuite) []uint16 {
@@ -78,6 +80,8 @@ func handshake(network, ip, port string, timeout time.Duration, ciphers []cipher
 			&tls.KeyShareExtension{KeyShares: []tls.KeyShare{{Group: tls.X25519}}},
 			&tls.PSKKeyExchangeModesExtension{Modes: []uint8{1}}, // pskModeDHE
 			&tls.SupportedVersionsExtension{Versions: []uint16{tls.VersionTLS13}},
+			&tls.StatusRequestExtension{},
+			&tls.SCTExtension{},
 		},
 		GetSessionID: nil,
 	}
@@ -110,6 +114,8 @@ func handshake(network, ip, port string, timeout time.Duration, ciphers []cipher
 	}
 
 	result.Supported = true
+	result.SignedCertificateTimestamps = uTlsConn.ConnectionState().SignedCertificateTimestamps
+	result.OCSPResponse = uTlsConn.ConnectionState().OCSPResponse
 
 	for i := range uTlsConn.ConnectionState().PeerCertificates {
 		result.Certificates = append(result.Certificates, *uTlsConn.ConnectionState().PeerCertificates[i])
@@ -1,10 +1,25 @@
 package validator
 
+import "github.com/elmasy-com/elnet/idn"
+
 // Domain returns whether d is valid domain.
 //
+// Internationalized domain names are accepted in Unicode (eg.: "bücher.example") and ASCII (eg.: "xn--bcher-kva.example") form.
+// The Unicode labels are mapped and validated by IDNA2008/UTS #46 and the A-labels must be valid punycode.
+//
 // This function returns false for "." (root domain).
 func Domain(d string) bool {
 
+	if !idn.IsASCII(d) || idn.HasPunycode(d) {
+
+		a, err := idn.ToASCII(d)
+		if err != nil {
+			return false
+		}
+
+		d = a
+	}
+
 	// Domain checks if a ByteSeq is a presentation-format domain name
 	// (currently restricted to hostname-compatible "preferred name" LDH labels and
 	// SRV-like "underscore labels".
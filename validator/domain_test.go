@@ -29,6 +29,13 @@ func TestDomain(t *testing.T) {
 		{Domain: "a=a", Result: false},
 		{Domain: " ", Result: false},
 		{Domain: "*", Result: false},
+		{Domain: "bücher.example", Result: true},
+		{Domain: "Bücher.Example.", Result: true},
+		{Domain: "xn--bcher-kva.example", Result: true},
+		{Domain: "例え.テスト", Result: true},
+		{Domain: "xn--invalid-.example", Result: false},
+		{Domain: "xn--zz-zzzz.example", Result: false},
+		{Domain: "bü cher.example", Result: false},
 	}
 
 	for i := range cases {
@@ -42,6 +42,9 @@ func emailLocal(v string) bool {
 	return last != '.'
 }
 
+// Email returns whether v is a valid email address.
+//
+// The local part must be ASCII, the domain part can be an internationalized domain name (eg.: "user@bücher.example").
 func Email(v string) bool {
 
 	// 64 is Unicode '@'
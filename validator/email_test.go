@@ -18,6 +18,8 @@ func TestEmail(t *testing.T) {
 		{URL: "x@example.com", Result: true},
 		{URL: "example-indeed@strange-example.com", Result: true},
 		{URL: "test/test@test.com", Result: true},
+		{URL: "user@bücher.example", Result: true},
+		{URL: "user@xn--bcher-kva.example", Result: true},
 
 		{URL: "test", Result: false},
 		{URL: "test.com", Result: false},
@@ -31,6 +33,7 @@ func TestEmail(t *testing.T) {
 		{URL: "just\"not\"right@example.com", Result: false},
 		{URL: "this is\"not\\allowed@example.com", Result: false},
 		{URL: "1234567890123456789012345678901234567890123456789012345678901234+x@example.com", Result: false},
+		{URL: "user@xn--invalid-.example", Result: false},
 	}
 
 	for i := range cases {